@@ -3,29 +3,44 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
 	"github.com/Kong/konnect-orchestrator/internal/config"
+	"github.com/Kong/konnect-orchestrator/internal/deck/generate"
 	"github.com/Kong/konnect-orchestrator/internal/deck/patch"
 	"github.com/Kong/konnect-orchestrator/internal/docker"
+	"github.com/Kong/konnect-orchestrator/internal/fakekonnect"
 	"github.com/Kong/konnect-orchestrator/internal/gateway"
 	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/gitea"
 	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/git/provider"
+	"github.com/Kong/konnect-orchestrator/internal/lint"
 	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/naming"
 	"github.com/Kong/konnect-orchestrator/internal/notification"
 	"github.com/Kong/konnect-orchestrator/internal/organization/auth"
 	"github.com/Kong/konnect-orchestrator/internal/organization/portal"
 	"github.com/Kong/konnect-orchestrator/internal/organization/role"
+	"github.com/Kong/konnect-orchestrator/internal/organization/systemaccount"
 	"github.com/Kong/konnect-orchestrator/internal/organization/team"
 	"github.com/Kong/konnect-orchestrator/internal/platform"
+	"github.com/Kong/konnect-orchestrator/internal/policy"
 	"github.com/Kong/konnect-orchestrator/internal/reports"
 	"github.com/Kong/konnect-orchestrator/internal/server"
+	"github.com/Kong/konnect-orchestrator/internal/spec"
 	"github.com/Kong/konnect-orchestrator/internal/tea/addorgprogram"
 	"github.com/Kong/konnect-orchestrator/internal/tea/initprogram"
 	"github.com/Kong/konnect-orchestrator/internal/tea/runprogram"
@@ -35,16 +50,16 @@ import (
 	kkInternalComps "github.com/Kong/sdk-konnect-go-internal/models/components"
 	kkInternalOps "github.com/Kong/sdk-konnect-go-internal/models/operations"
 	kkComps "github.com/Kong/sdk-konnect-go/models/components"
-	giturl "github.com/kubescape/go-git-url"
 )
 
-//go:embed resources/platform/* resources/platform/.github/* resources/platform/.gitignore
+//go:embed resources/platform/* resources/platform/.github/* resources/platform/.gitignore resources/platform/.gitlab-ci.yml
 var resourceFiles embed.FS
 
 const (
 	defaultOrchestratorPath = "konnect/"
 	defaultTeamsFilePath    = defaultOrchestratorPath + "teams.yaml"
 	defaultOrgsFilePath     = defaultOrchestratorPath + "organizations.yaml"
+	defaultPlatformFilePath = defaultOrchestratorPath + "platform.yaml"
 )
 
 var (
@@ -52,12 +67,18 @@ var (
 	wholeFileArg         string
 	teamsFileArg         string
 	organizationsFileArg string
+	platformFileArg      string
 	orgKonnectTokenArg   string
 	orgNameArg           string
 	version              = "dev"
 	commit               = "unknown"
 	date                 = "unknown"
 	createNewRepo        = false
+	generateDeckFiles    = false
+	forcePush            = false
+	runLocal             = false
+	konnectPortArg       int
+	seedWebURLArg        string
 )
 
 var rootCmd = &cobra.Command{
@@ -105,6 +126,23 @@ var runAPICmd = &cobra.Command{
 	RunE:  runRunAPI,
 }
 
+var runKonnectCmd = &cobra.Command{
+	Use:   "konnect",
+	Short: "Run a fake Konnect API for the local demo",
+	Long: `Serves an in-memory stand-in of the Konnect API. Point koctl apply at it by setting KONNECT_API_URL,
+e.g. KONNECT_API_URL=http://localhost:8082.`,
+	RunE: runRunKonnect,
+}
+
+var runSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Seed a Gitea server with the repositories of the local demo",
+	Long: `Creates the kong-air organization with example service repositories and a platform repository
+configured for them. The Gitea API and credentials are read from PLATFORM_REPO_API_URL, PLATFORM_REPO_USERNAME
+and PLATFORM_REPO_GITHUB_TOKEN. Existing repositories are left untouched.`,
+	RunE: runRunSeed,
+}
+
 var runExportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export a docker-compose.yaml file for the API server and the self service UI",
@@ -123,6 +161,30 @@ var addOrganizationCmd = &cobra.Command{
 	RunE:  runAddOrganization,
 }
 
+var generateCmd = &cobra.Command{
+	Use:   "generate [konnect-dir]",
+	Short: "Generate the decK files of a platform repository",
+	Long: `Converts the openapi.yaml of every service in the platform repository to kong-from-oas.yaml,
+applying the service's ko-patch.yaml, and merges the result of each team and environment into kong.yaml.
+The directory defaults to ./konnect.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGenerate,
+}
+
+var generateServiceCmd = &cobra.Command{
+	Use:   "service <service-dir>",
+	Short: "Generate kong-from-oas.yaml for a single service directory",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGenerateService,
+}
+
+var generateTeamCmd = &cobra.Command{
+	Use:   "team <team-dir>",
+	Short: "Merge the kong-from-oas.yaml files of a team directory into kong.yaml",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGenerateTeam,
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version of koctl",
@@ -152,8 +214,20 @@ func init() {
 		"orgs",
 		"./"+defaultOrgsFilePath,
 		"Path to the organizations configuration file. Superseded by --file")
+	applyCmd.Flags().StringVar(&platformFileArg,
+		"platform",
+		"./"+defaultPlatformFilePath,
+		"Path to the platform configuration file, ignored when missing. Superseded by --file")
 	applyCmd.Flags().IntVarP(&loopInterval,
 		"loop", "l", 0, "Run apply in a loop with specified interval in seconds (0 = run once)")
+	applyCmd.Flags().BoolVar(&generateDeckFiles,
+		"generate",
+		false,
+		"Generate the decK files of the services in the platform repository changes instead of relying on CI")
+	applyCmd.Flags().BoolVar(&forcePush,
+		"force-push",
+		false,
+		"Force push the platform repository branches, even when they have commits which weren't made by koctl")
 
 	addOrganizationCmd.Flags().StringVar(&orgKonnectTokenArg,
 		"konnect-token",
@@ -166,18 +240,86 @@ func init() {
 
 	cobra.OnInitialize(initConfig)
 
+	runCmd.Flags().BoolVar(&runLocal,
+		"local",
+		false,
+		"Run an offline demo with a local Gitea server seeded with an example platform repository and a fake Konnect API")
+	runKonnectCmd.Flags().IntVar(&konnectPortArg,
+		"port",
+		8082,
+		"Port of the fake Konnect API")
+	runSeedCmd.Flags().StringVar(&seedWebURLArg,
+		"web-url",
+		"http://localhost:3000",
+		"Address of the Gitea server for koctl users, written to the platform repository configuration")
+
 	runCmd.AddCommand(runUICmd)
 	runCmd.AddCommand(runAPICmd)
 	runCmd.AddCommand(runExportCmd)
+	runCmd.AddCommand(runKonnectCmd)
+	runCmd.AddCommand(runSeedCmd)
 
 	rootCmd.AddCommand(runCmd)
 
+	generateCmd.AddCommand(generateServiceCmd)
+	generateCmd.AddCommand(generateTeamCmd)
+
 	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(initCmd)
 }
 
+// serviceGitConfig returns the git configuration of a service repository, which uses the platform
+// repository auth unless it has its own
+func serviceGitConfig(platformGit manifest.GitConfig, serviceConfig manifest.Service) *manifest.GitConfig {
+	svcGitCfg := serviceConfig.Git
+	if svcGitCfg.Auth == nil {
+		// If the user doesn't provide a service level git auth config, we use the platform level git auth
+		if platformGit.Auth == nil {
+			svcGitCfg.GitHub = platformGit.GitHub
+			if svcGitCfg.GitLab == nil {
+				svcGitCfg.GitLab = platformGit.GitLab
+			}
+			if svcGitCfg.Bitbucket == nil {
+				svcGitCfg.Bitbucket = platformGit.Bitbucket
+			}
+			if svcGitCfg.AzureDevOps == nil {
+				svcGitCfg.AzureDevOps = platformGit.AzureDevOps
+			}
+			if svcGitCfg.Gitea == nil {
+				svcGitCfg.Gitea = platformGit.Gitea
+			}
+		} else {
+			svcGitCfg.Auth = platformGit.Auth
+		}
+	}
+	return svcGitCfg
+}
+
+// serviceSpecVersion returns the version of the spec of a service in an environment
+func serviceSpecVersion(platformGit manifest.GitConfig, s teamService) (string, error) {
+	serviceRepoDir, err := git.CloneBranch(*serviceGitConfig(platformGit, s.config), s.envConfig.Branch)
+	if err != nil {
+		return "", fmt.Errorf("failed to clone service repository for %s: %w", s.name, err)
+	}
+	defer os.RemoveAll(serviceRepoDir)
+
+	serviceSpecs, err := spec.Load(serviceRepoDir, s.config.SpecFiles())
+	if err != nil {
+		return "", fmt.Errorf("failed to get service specs for %s: %w", s.name, err)
+	}
+	specDoc, err := spec.Describe(serviceSpecs)
+	if err != nil {
+		return "", fmt.Errorf("invalid spec for service %s: %w", s.name, err)
+	}
+	if specDoc.Info == nil {
+		return "", nil
+	}
+	return specDoc.Info.Version, nil
+}
+
 func applyService(
 	platformRepoDir string,
 	platformGit manifest.GitConfig,
@@ -192,31 +334,60 @@ func applyService(
 	region string,
 	accessToken string,
 	cpID string,
+	names *naming.Templates,
+	versioning *manifest.APIVersioning,
+	policies []manifest.PluginPolicy,
 	labels map[string]string,
-) error {
+) (*serviceResult, error) {
 	labels["team-name"] = teamName
-	labels["service-name"] = *serviceConfig.Name
+	result := &serviceResult{Name: serviceName, Findings: map[string][]lint.Finding{}}
 
-	svcGitCfg := serviceConfig.Git
-	if svcGitCfg.Auth == nil {
-		// If the user doesn't provide a service level git auth config, we use the platform level git auth
-		if platformGit.Auth == nil {
-			svcGitCfg.GitHub = platformGit.GitHub
-		} else {
-			svcGitCfg.Auth = platformGit.Auth
-		}
-	}
+	svcGitCfg := serviceGitConfig(platformGit, serviceConfig)
 
-	// This loads the Service Spec from the teams Git Repository
+	// This loads the Service Specs from the teams Git Repository
 	// into memory
-	serviceSpec, err := git.GetRemoteFile(
-		*svcGitCfg,
-		serviceEnvConfig.Branch,
-		serviceConfig.SpecPath)
+	serviceRepoDir, err := git.CloneBranch(*svcGitCfg, serviceEnvConfig.Branch)
 	if err != nil {
-		return fmt.Errorf("failed to get service spec for %s: %w",
+		return nil, fmt.Errorf("failed to clone service repository for %s: %w",
 			serviceName, err)
 	}
+	defer os.RemoveAll(serviceRepoDir)
+
+	if svcGitCfg.Remote != nil {
+		result.Repository = *svcGitCfg.Remote
+	}
+	result.Branch = serviceEnvConfig.Branch
+	result.Commit, err = git.HeadCommit(serviceRepoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the commit of service repository for %s: %w", serviceName, err)
+	}
+
+	serviceSpecs, err := spec.Load(serviceRepoDir, serviceConfig.SpecFiles())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service specs for %s: %w",
+			serviceName, err)
+	}
+
+	// External $refs are resolved against the service repository checkout so the platform repository
+	// and Konnect both receive self-contained OpenAPI documents
+	for i, s := range serviceSpecs {
+		if !s.Type.IsOpenAPI() {
+			continue
+		}
+		serviceSpecs[i], err = spec.Bundle(serviceRepoDir, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bundle service spec %s for %s: %w",
+				s.Path, serviceName, err)
+		}
+	}
+
+	specDoc, err := spec.Describe(serviceSpecs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec for service %s: %w", serviceName, err)
+	}
+	if specDoc.Info != nil {
+		result.Version = specDoc.Info.Version
+	}
 
 	// Create path in the platform repo: konnect/<org>/envs/<env>/teams/<team>/services/<service-name>
 	servicePath := filepath.Join(
@@ -230,23 +401,120 @@ func applyService(
 		"services",
 		serviceName,
 	)
+	result.Dir = path.Join("konnect", orgName, "envs", envName, "teams", teamName, "services", serviceName)
+
+	// Specs failing error severity rules of the platform's OpenAPI ruleset are not written to the
+	// platform repository, the findings are reported in the pull request instead
+	oasRules, err := lint.LoadIfExists(filepath.Join(platformRepoDir, "konnect", lint.OASRulesFileName))
+	if err != nil {
+		return nil, err
+	}
+	lintFailed := false
+	if oasRules != nil {
+		for _, s := range serviceSpecs {
+			if !s.Type.IsOpenAPI() {
+				continue
+			}
+			findings, err := oasRules.Lint(s.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to lint service spec %s for %s: %w", s.Path, serviceName, err)
+			}
+			if len(findings) > 0 {
+				result.Findings[s.Path] = findings
+			}
+			if lint.HasErrors(findings) {
+				lintFailed = true
+			}
+		}
+	}
+	if lintFailed {
+		result.Blocked = "the specs fail error severity lint rules"
+		fmt.Printf("--Service %s specs fail the lint rules, the platform repository is not updated:\n", serviceName)
+		printFindings(result.Findings)
+		return result, nil
+	}
+
+	// The changes to the primary spec are compared with the revision in the platform repository
+	if primarySpec, ok := spec.Primary(serviceSpecs); ok {
+		previous, err := os.ReadFile(filepath.Join(servicePath, primarySpec.Name()))
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, fmt.Errorf("failed to read the current spec of %s: %w", serviceName, err)
+		default:
+			changelog, err := spec.Diff(previous, primarySpec.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare the spec of %s with the current revision: %w", serviceName, err)
+			}
+			result.Changelog = &changelog
+		}
+	}
+	if result.Changelog != nil && envType == "PROD" && versioning != nil &&
+		versioning.EnforceVersionBump != nil && *versioning.EnforceVersionBump && !result.Changelog.VersionBumped() {
+		result.Blocked = fmt.Sprintf("the spec changes require a version bump from %s", result.Changelog.OldVersion)
+		if result.Changelog.HasBreaking() {
+			result.Blocked = fmt.Sprintf("the spec has breaking changes which require a new major version, %s was %s",
+				result.Changelog.NewVersion, result.Changelog.OldVersion)
+		}
+		fmt.Printf("--Service %s is not updated, %s\n", serviceName, result.Blocked)
+		return result, nil
+	}
+
+	// The API is named after the service, falling back to the spec title and then the manifest key
+	baseName := serviceName
+	switch {
+	case serviceConfig.Name != nil && *serviceConfig.Name != "":
+		baseName = *serviceConfig.Name
+	case specDoc.Info != nil && specDoc.Info.Title != "":
+		baseName = specDoc.Info.Title
+	}
+	if value := spec.LabelValue(baseName); value != "" {
+		labels["service-name"] = value
+	}
 
 	if err := os.MkdirAll(servicePath, 0o755); err != nil {
-		return fmt.Errorf("failed to create service directory structure for %s: %w",
+		return nil, fmt.Errorf("failed to create service directory structure for %s: %w",
 			serviceName, err)
 	}
 
-	// This copies the Spec from memory into the Platform team Git repository location
-	// TODO: Stop waving hands at non-YAML spec files
-	if err := os.WriteFile(filepath.Join(servicePath, "openapi.yaml"), serviceSpec, 0o600); err != nil {
-		return fmt.Errorf("failed to write service spec for %s: %w",
-			serviceName, err)
+	// This copies the Specs from memory into the Platform team Git repository location,
+	// each under its real file name
+	for _, s := range serviceSpecs {
+		if err := os.WriteFile(filepath.Join(servicePath, s.Name()), s.Content, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write service spec %s for %s: %w",
+				s.Path, serviceName, err)
+		}
+	}
+
+	// Only OpenAPI 3 specs feed the decK generation pipeline, which reads the spec from openapi.yaml.
+	// JSON specs are valid YAML so they can be copied as is. Without an OpenAPI 3 spec a previous
+	// openapi.yaml and its decK file are removed so the service isn't generated from a stale spec.
+	deckSpec, hasDeckSpec := spec.DeckSpec(serviceSpecs)
+	switch {
+	case !hasDeckSpec:
+		for _, name := range []string{spec.DeckFileName, generate.ServiceDeckFileName} {
+			if err := os.Remove(filepath.Join(servicePath, name)); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove the stale %s of %s: %w", name, serviceName, err)
+			}
+		}
+	case deckSpec.Name() != spec.DeckFileName:
+		if err := os.WriteFile(filepath.Join(servicePath, spec.DeckFileName), deckSpec.Content, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write service spec for %s: %w",
+				serviceName, err)
+		}
 	}
 
-	apiName := serviceConfig.Name
-	if envType != "PROD" {
-		apiName = kk.String(fmt.Sprintf("%s-%s", *apiName, envName))
+	renderedAPIName, err := names.API(naming.Vars{
+		Org:     orgName,
+		Env:     envName,
+		EnvType: envType,
+		Team:    teamName,
+		Service: baseName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to name API for service %s: %w", serviceName, err)
 	}
+	apiName := kk.String(renderedAPIName)
 
 	// Write a patch files for the service adding some metadata so we can relate the API to the GW service later
 	apiNameServicePatch := patch.Patch{
@@ -258,74 +526,123 @@ func applyService(
 		},
 	}
 	koPatchFile := patch.File{
-		FormatVersion: "1.0",
+		FormatVersion: patch.FormatVersion,
 		Patches:       []patch.Patch{apiNameServicePatch},
 	}
 
+	// The plugins enforced by the platform policies are added to every gateway service of the spec
+	policyPlugins, exemptions := policy.Plugins(policies, teamName, serviceName, time.Now())
+	for _, e := range exemptions {
+		fmt.Printf("--Service %s is exempted from the %s plugin policy: %s\n", serviceName, e.Plugin, e.Justification)
+	}
+	koPatchFile.Patches = append(koPatchFile.Patches, policy.Patches(policyPlugins)...)
+
 	// write a patch file to the service directory under the name "ko-patch.yaml"
 	koPatchFileBytes, err := yaml.Marshal(koPatchFile)
 	if err != nil {
-		return fmt.Errorf("failed to marshal patch file for %s: %w", serviceName, err)
+		return nil, fmt.Errorf("failed to marshal patch file for %s: %w", serviceName, err)
 	}
 	if err := os.WriteFile(filepath.Join(servicePath, "ko-patch.yaml"), koPatchFileBytes, 0o600); err != nil {
-		return fmt.Errorf("failed to write patch file for %s: %w", serviceName, err)
+		return nil, fmt.Errorf("failed to write patch file for %s: %w", serviceName, err)
+	}
+
+	if generateDeckFiles {
+		if hasDeckSpec {
+			deckFile, err := generate.Service(servicePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate decK file for %s: %w", serviceName, err)
+			}
+			deckRules, err := lint.LoadIfExists(filepath.Join(platformRepoDir, "konnect", lint.DeckRulesFileName))
+			if err != nil {
+				return nil, err
+			}
+			if deckRules != nil {
+				content, err := os.ReadFile(deckFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read decK file for %s: %w", serviceName, err)
+				}
+				findings, err := deckRules.Lint(content)
+				if err != nil {
+					return nil, fmt.Errorf("failed to lint decK file for %s: %w", serviceName, err)
+				}
+				if len(findings) > 0 {
+					result.Findings[generate.ServiceDeckFileName] = findings
+				}
+			}
+		} else {
+			fmt.Printf("Warn: service %s has no OpenAPI 3 spec, no decK file is generated\n", serviceName)
+		}
 	}
 
 	internalRegionSdk := kkInternal.New(
 		kkInternal.WithSecurity(kkInternalComps.Security{
 			PersonalAccessToken: kkInternal.String(accessToken),
 		}),
-		kkInternal.WithServerURL(fmt.Sprintf("https://%s.api.konghq.com", region)),
+		kkInternal.WithServerURL(konnectServerURL(region)),
 	)
 
 	// We can now query for GW Services that have the `ko-api-name` tag, this will require that the
-	// APIOps pipeline in the Platform repository has ran, such that the entity is tagged properly so we can find it
-	// here. If we can't find the service, we just ignore and proceed.
+	// APIOps pipeline in the Platform repository has ran, such that the entities are tagged properly so we can find
+	// them here. openapi2kong can generate several services for one spec, each of them implements the API.
 	resp, err := internalRegionSdk.Services.ListService(context.Background(),
 		kkInternalOps.ListServiceRequest{
 			ControlPlaneID: cpID,
 			Tags:           kkInternal.String("ko-api-name=" + *apiName),
 		})
 	if err != nil {
-		return fmt.Errorf("failed to list services: %w", err)
+		return nil, fmt.Errorf("failed to list services: %w", err)
 	}
 	if resp == nil {
-		return fmt.Errorf("failed to list services: response is nil")
+		return nil, fmt.Errorf("failed to list services: response is nil")
 	}
 	services := resp.Object.GetData()
 	if services == nil {
-		return fmt.Errorf("failed to list services: data is nil")
+		return nil, fmt.Errorf("failed to list services: data is nil")
 	}
-	var serviceID string
-	if len(services) == 1 {
-		serviceID = *services[0].GetID()
-	} else {
-		fmt.Printf("Warn: Found %d services for API %s. Cannot create API implementation relation, "+
-			"requires exactly 1 service with `ko-api-name` tag. APIOps workflows may need to be ran.\n", len(services), *apiName)
+	serviceIDs := make([]string, 0, len(services))
+	for _, svc := range services {
+		if svc.GetID() != nil {
+			serviceIDs = append(serviceIDs, *svc.GetID())
+		}
 	}
 
-	_, err = portal.ApplyAPIConfig(
+	apiResult, err := portal.ApplyAPIConfig(
 		context.Background(),
 		internalRegionSdk.API,
 		internalRegionSdk.APISpecification,
 		internalRegionSdk.APIPublication,
 		internalRegionSdk.APIImplementation,
+		internalRegionSdk.APIDocumentation,
 		*apiName,
 		serviceConfig,
-		serviceSpec,
+		serviceSpecs,
+		specDoc,
 		portalID,
 		cpID,
-		serviceID,
+		serviceIDs,
+		versioning,
 		labels)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	switch {
+	case !apiResult.Newest:
+		fmt.Printf("--API %s is not the newest version, gateway services are linked to the newest version\n", *apiName)
+	case apiResult.PendingLinks:
+		fmt.Printf("--Pending: no gateway services tagged `ko-api-name=%s` on control plane %s yet. "+
+			"The API will be linked on a later apply once the APIOps workflows have synced the decK configuration.\n",
+			*apiName, cpID)
+	default:
+		fmt.Printf("--API %s linked to %d gateway service(s)\n", *apiName, len(apiResult.LinkedServices))
+	}
+
+	return result, nil
 }
 
 func applyPortal(
 	accessToken string,
+	portalName string,
 	portalDisplayName string,
 	region string,
 	envName string,
@@ -337,11 +654,12 @@ func applyPortal(
 		kkInternal.WithSecurity(kkInternalComps.Security{
 			PersonalAccessToken: kkInternal.String(accessToken),
 		}),
-		kkInternal.WithServerURL(fmt.Sprintf("https://%s.api.konghq.com", region)),
+		kkInternal.WithServerURL(konnectServerURL(region)),
 	)
 
 	// Apply the Developer Portal configuration for the environment
 	portalID, err := portal.ApplyPortalConfig(context.Background(),
+		portalName,
 		portalDisplayName,
 		envName,
 		envType,
@@ -355,6 +673,88 @@ func applyPortal(
 	return portalID, nil
 }
 
+// Pull request groupings of the platform repository changes
+const (
+	pullRequestsPerEnvironment = "environment"
+	pullRequestsPerTeam        = "team"
+	pullRequestsPerService     = "service"
+)
+
+// pullRequestGrouping returns the pull request grouping of an environment, which overrides the grouping
+// of the organization
+func pullRequestGrouping(org, env *manifest.PullRequests) (string, error) {
+	grouping := pullRequestsPerEnvironment
+	for _, p := range []*manifest.PullRequests{org, env} {
+		if p != nil && p.Grouping != nil && *p.Grouping != "" {
+			grouping = *p.Grouping
+		}
+	}
+	switch grouping {
+	case pullRequestsPerEnvironment, pullRequestsPerTeam, pullRequestsPerService:
+		return grouping, nil
+	}
+	return "", fmt.Errorf("invalid pull request grouping %s, expected environment, team or service", grouping)
+}
+
+// Modes of proposing the platform repository changes of an environment
+const (
+	platformChangesPR     = "pr"
+	platformChangesDirect = "direct"
+	platformChangesNone   = "none"
+)
+
+// platformChangesMode returns how the platform repository changes of an environment are proposed: in pull
+// requests, committed to the base branch or only written to a local checkout
+func platformChangesMode(mode *string) (string, error) {
+	if mode == nil || *mode == "" {
+		return platformChangesPR, nil
+	}
+	switch *mode {
+	case platformChangesPR, platformChangesDirect, platformChangesNone:
+		return *mode, nil
+	}
+	return "", fmt.Errorf("invalid platform changes mode %s, expected pr, direct or none", *mode)
+}
+
+// teamService is a service of a team with the configuration of the environment being applied
+type teamService struct {
+	name      string
+	config    manifest.Service
+	envConfig manifest.EnvironmentService
+}
+
+// teamServices returns the services of a team applied in an environment, sorted by name
+func teamServices(
+	teamName string,
+	teamConfig manifest.Team,
+	teamEnvironmentConfig *manifest.TeamEnvironment,
+	envType string,
+) ([]teamService, error) {
+	var services []teamService
+	if teamEnvironmentConfig != nil {
+		for serviceName, serviceEnvConfig := range teamEnvironmentConfig.Services {
+			serviceConfig, exists := teamConfig.Services[serviceName]
+			if !exists {
+				return nil, fmt.Errorf("service %s referenced in team %s not found in team configuration",
+					serviceName, teamName)
+			}
+			services = append(services, teamService{serviceName, *serviceConfig, *serviceEnvConfig})
+		}
+	} else {
+		for serviceName, serviceConfig := range teamConfig.Services {
+			serviceEnvConfig := manifest.EnvironmentService{}
+			if envType == "PROD" {
+				serviceEnvConfig.Branch = serviceConfig.ProdBranch
+			} else {
+				serviceEnvConfig.Branch = serviceConfig.DevBranch
+			}
+			services = append(services, teamService{serviceName, *serviceConfig, serviceEnvConfig})
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].name < services[j].name })
+	return services, nil
+}
+
 func applyTeam(teamName string,
 	accessToken string,
 	envConfig manifest.Environment,
@@ -365,20 +765,51 @@ func applyTeam(teamName string,
 	platformGit manifest.GitConfig,
 	teamEnvironmentConfig *manifest.TeamEnvironment,
 	portalID string,
+	names *naming.Templates,
+	versioning *manifest.APIVersioning,
+	policies []manifest.PluginPolicy,
+	grouping string,
 	labels map[string]string,
 ) error {
 	fmt.Printf("-Processing team %s\n", teamName)
 
+	nameVars := naming.Vars{
+		Org:     orgName,
+		Env:     envName,
+		EnvType: envConfig.Type,
+		Team:    teamName,
+	}
+	konnectTeamName, err := names.Team(nameVars)
+	if err != nil {
+		return fmt.Errorf("failed to name team %s in organization %s: %w", teamName, orgName, err)
+	}
+
+	services, err := teamServices(teamName, teamConfig, teamEnvironmentConfig, envConfig.Type)
+	if err != nil {
+		return fmt.Errorf("invalid services in organization %s environment %s: %w", orgName, envName, err)
+	}
+
+	// The services are applied in groups, each group is proposed in its own pull request
+	groups := [][]teamService{services}
+	if grouping == pullRequestsPerService && len(services) > 0 {
+		groups = nil
+		for _, s := range services {
+			groups = append(groups, []teamService{s})
+		}
+	}
+
 	regionSpecificSDK := kk.New(
 		kk.WithSecurity(kkComps.Security{
 			PersonalAccessToken: kk.String(accessToken),
 		}),
-		kk.WithServerURL(fmt.Sprintf("https://%s.api.konghq.com", envConfig.Region)),
+		kk.WithServerURL(konnectServerURL(envConfig.Region)),
 	)
 
 	cpID, err := gateway.ApplyControlPlane(
 		context.Background(),
 		regionSpecificSDK.ControlPlanes,
+		names,
+		orgName,
 		envName,
 		envConfig,
 		teamName)
@@ -395,7 +826,7 @@ func applyTeam(teamName string,
 		sdk.TeamMembership,
 		sdk.Users,
 		sdk.Invites,
-		teamName,
+		konnectTeamName,
 		teamConfig,
 	)
 	if err != nil || teamID == "" {
@@ -413,83 +844,239 @@ func applyTeam(teamName string,
 		return fmt.Errorf("failed to apply team roles: %w", err)
 	}
 
+	// Assign the system account of the team the roles of the team
+	if teamConfig.SystemAccount != nil {
+		accountID, err := systemaccount.ApplyTeamAccount(
+			context.Background(),
+			sdk.SystemAccounts,
+			teamName,
+			*teamConfig.SystemAccount)
+		if err != nil {
+			return fmt.Errorf("failed to apply system account of team %s in organization %s: %w",
+				teamName, orgName, err)
+		}
+		if err := role.ApplySystemAccountRoles(
+			context.Background(),
+			sdk.SystemAccountsRoles,
+			accountID,
+			cpID,
+			envConfig); err != nil {
+			return fmt.Errorf("failed to apply system account roles of team %s: %w", teamName, err)
+		}
+	}
+
+	for _, group := range groups {
+		// The changes are applied again on top of the platform repository when a concurrent apply, e.g. of
+		// another team sharing the branch, changed the same files
+		for attempt := 1; ; attempt++ {
+			err := applyTeamChanges(teamName, accessToken, envConfig, envName, orgName, teamConfig, platformGit,
+				group, portalID, cpID, names, versioning, policies, grouping, labels)
+			if errors.Is(err, git.ErrConflict) && attempt < applyAttempts {
+				fmt.Printf("-The platform repository changed the same files while applying team %s, "+
+					"applying the changes again\n", teamName)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// applyAttempts is the number of times the changes of a team are applied when the platform repository
+// branch changed the same files concurrently
+const applyAttempts = 3
+
+// applyTeamChanges applies a group of services of a team to the platform repository and proposes the
+// changes in the pull request of the group
+func applyTeamChanges(teamName string,
+	accessToken string,
+	envConfig manifest.Environment,
+	envName string,
+	orgName string,
+	teamConfig manifest.Team,
+	platformGit manifest.GitConfig,
+	services []teamService,
+	portalID string,
+	cpID string,
+	names *naming.Templates,
+	versioning *manifest.APIVersioning,
+	policies []manifest.PluginPolicy,
+	grouping string,
+	labels map[string]string,
+) error {
+	branchVars := naming.Vars{
+		Org:     orgName,
+		Env:     envName,
+		EnvType: envConfig.Type,
+	}
+	title := fmt.Sprintf("[Konnect Orchestrator] - Changes for [%s] environment", envName)
+	prLabels := []string{"konnect-orchestrator", "org:" + orgName, "env:" + envName, "team:" + teamName}
+	switch grouping {
+	case pullRequestsPerTeam:
+		branchVars.Team = teamName
+		title = fmt.Sprintf("[Konnect Orchestrator] - Changes for team [%s] in [%s] environment", teamName, envName)
+	case pullRequestsPerService:
+		branchVars.Team = teamName
+		branchVars.Service = services[0].name
+		title = fmt.Sprintf("[Konnect Orchestrator] - Changes for service [%s] of team [%s] in [%s] environment",
+			services[0].name, teamName, envName)
+		prLabels = append(prLabels, "service:"+services[0].name)
+		// the service repository is only read ahead of the apply when the branch is named by its version
+		if names.BranchUsesVersion() {
+			version, err := serviceSpecVersion(platformGit, services[0])
+			if err != nil {
+				return err
+			}
+			branchVars.Version = version
+		}
+	}
+	branchName, err := names.Branch(branchVars)
+	if err != nil {
+		return fmt.Errorf("failed to name branch for team %s in organization %s environment %s: %w",
+			teamName, orgName, envName, err)
+	}
+	mode, err := platformChangesMode(envConfig.PlatformChanges)
+	if err != nil {
+		return fmt.Errorf("invalid platform changes for environment %s: %w", envName, err)
+	}
+	if mode != platformChangesPR {
+		branchName = platformGit.BaseBranchName()
+	}
+
 	platformRepoDir, err := git.Clone(platformGit)
 	if err != nil {
 		return fmt.Errorf("failed to clone platform repository: %w", err)
 	}
+	// The checkout is kept for inspection when the changes are only written locally
+	if mode != platformChangesNone {
+		defer os.RemoveAll(platformRepoDir)
+	}
 
 	// create / checkout branch
-	branchName := fmt.Sprintf("%s-konnect-orchestrator-apply", envName)
 	err = git.CheckoutBranch(platformRepoDir, branchName, platformGit)
 	if err != nil {
 		return fmt.Errorf("failed to checkout branch: %w", err)
 	}
 
-	if teamEnvironmentConfig != nil {
-		for serviceName, serviceEnvConfig := range teamEnvironmentConfig.Services {
-
-			fmt.Printf("--Processing service %s\n", serviceName)
+	remote, err := git.ParseRemote(*platformGit.Remote)
+	if err != nil {
+		return err
+	}
+	var hosting provider.Provider
+	if mode == platformChangesPR {
+		if hosting, err = provider.New(platformGit); err != nil {
+			return fmt.Errorf("failed to configure the git provider of the platform repository: %w", err)
+		}
+	}
 
-			serviceConfig, exists := teamConfig.Services[serviceName]
-			if !exists {
-				return fmt.Errorf(
-					"service %s referenced in team %s in organization "+
-						"%s environment %s not found in team configuration",
-					serviceName, teamName, orgName, envName)
+	// Commits added to the branch by someone else, e.g. a fixup of the pull request, aren't overwritten:
+	// the changes are skipped until the pull request is merged or closed
+	if mode == platformChangesPR && !forcePush {
+		if platformGit.Author == nil || platformGit.Author.Email == nil {
+			return fmt.Errorf("the platform git author email is required to check the commits of branch %s",
+				branchName)
+		}
+		foreign, err := git.ForeignCommits(platformRepoDir, platformGit.BaseBranchName(), *platformGit.Author.Email)
+		if err != nil {
+			return fmt.Errorf("failed to check the commits of branch %s: %w", branchName, err)
+		}
+		if len(foreign) > 0 {
+			fmt.Printf("-!! Branch %s has commits which weren't made by Konnect Orchestrator, "+
+				"skipping the changes for team %s in environment %s\n", branchName, teamName, envName)
+			head, err := git.HeadCommit(platformRepoDir)
+			if err != nil {
+				return fmt.Errorf("failed to read the commit of branch %s: %w", branchName, err)
 			}
-
-			if err := applyService(
-				platformRepoDir,
-				platformGit,
-				orgName,
-				envName,
-				envConfig.Type,
-				teamName,
-				serviceName,
-				*serviceConfig,
-				*serviceEnvConfig,
-				portalID,
-				envConfig.Region,
-				accessToken,
-				cpID,
-				labels); err != nil {
-				return fmt.Errorf("failed to process service %s in team %s in organization %s environment %s: %w",
-					serviceName, teamName, orgName, envName, err)
+			err = hosting.CommentOnPullRequest(context.Background(),
+				branchName,
+				platformGit.BaseBranchName(),
+				fmt.Sprintf("<!-- konnect-orchestrator:foreign-commits:%s -->", head),
+				fmt.Sprintf("Konnect Orchestrator stopped updating this branch because it has commits which "+
+					"weren't made by the orchestrator:\n\n- %s\n\nMerge or close this pull request to resume "+
+					"the updates, or run `koctl apply --force-push` to apply the changes on top of these commits.",
+					strings.Join(foreign, "\n- ")))
+			if err != nil {
+				fmt.Printf("Warning: failed to comment on the pull request of branch %s: %v\n", branchName, err)
 			}
+			return nil
 		}
-	} else {
-		for serviceName, serviceConfig := range teamConfig.Services {
+	}
 
-			fmt.Printf("--Processing service %s\n", serviceName)
+	// The decK sync workflow reads the control plane name of the team from the platform repository,
+	// as it can't be derived from the directory names when a naming template is used
+	cpName, err := gateway.ControlPlaneName(names, orgName, envName, envConfig, teamName)
+	if err != nil {
+		return fmt.Errorf("failed to name control plane for team %s: %w", teamName, err)
+	}
+	teamPath := filepath.Join(platformRepoDir, "konnect", orgName, "envs", envName, "teams", teamName)
+	if err := os.MkdirAll(teamPath, 0o755); err != nil {
+		return fmt.Errorf("failed to create team directory for %s: %w", teamName, err)
+	}
+	if err := os.WriteFile(filepath.Join(teamPath, "control-plane-name"), []byte(cpName+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write control plane name for team %s: %w", teamName, err)
+	}
 
-			serviceEnvConfig := manifest.EnvironmentService{}
-			if envConfig.Type == "PROD" {
-				serviceEnvConfig.Branch = serviceConfig.ProdBranch
-			} else {
-				serviceEnvConfig.Branch = serviceConfig.DevBranch
+	// The reviewers of the team own its directory in the platform repository, unless the provider has no
+	// code owners
+	codeOwnersFile := provider.CodeOwnersPath(platformGit)
+	if teamConfig.Reviewers != nil && codeOwnersFile != "" {
+		codeOwnersPath := filepath.Join(platformRepoDir, filepath.FromSlash(codeOwnersFile))
+		codeOwners, err := os.ReadFile(codeOwnersPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", codeOwnersFile, err)
+		}
+		content := github.SetCodeOwners(string(codeOwners),
+			fmt.Sprintf("/konnect/%s/envs/%s/teams/%s/", orgName, envName, teamName),
+			github.CodeOwners(remote.Owner(), teamConfig.Reviewers.Users, teamConfig.Reviewers.Teams))
+		if content != string(codeOwners) {
+			if err := os.MkdirAll(filepath.Dir(codeOwnersPath), 0o755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", codeOwnersFile, err)
 			}
-
-			if err := applyService(
-				platformRepoDir,
-				platformGit,
-				orgName,
-				envName,
-				envConfig.Type,
-				teamName,
-				serviceName,
-				*serviceConfig,
-				serviceEnvConfig,
-				portalID,
-				envConfig.Region,
-				accessToken,
-				cpID,
-				labels); err != nil {
-				return fmt.Errorf("failed to process service %s in team %s in organization %s environment %s: %w",
-					serviceName, teamName, orgName, envName, err)
+			if err := os.WriteFile(codeOwnersPath, []byte(content), 0o600); err != nil {
+				return fmt.Errorf("failed to write %s: %w", codeOwnersFile, err)
 			}
 		}
 	}
 
+	var results []*serviceResult
+	for _, s := range services {
+
+		fmt.Printf("--Processing service %s\n", s.name)
+
+		result, err := applyService(
+			platformRepoDir,
+			platformGit,
+			orgName,
+			envName,
+			envConfig.Type,
+			teamName,
+			s.name,
+			s.config,
+			s.envConfig,
+			portalID,
+			envConfig.Region,
+			accessToken,
+			cpID,
+			names,
+			versioning,
+			policies,
+			labels)
+		if err != nil {
+			return fmt.Errorf("failed to process service %s in team %s in organization %s environment %s: %w",
+				s.name, teamName, orgName, envName, err)
+		}
+		results = append(results, result)
+	}
+
+	if generateDeckFiles {
+		if _, err := generate.Team(teamPath); err != nil {
+			return fmt.Errorf("failed to generate decK file for team %s: %w", teamName, err)
+		}
+	}
+
 	isClean, err := git.IsClean(platformRepoDir)
 	if err != nil {
 		return fmt.Errorf("failed to check if platform repository is clean: %w", err)
@@ -499,6 +1086,20 @@ func applyTeam(teamName string,
 
 		fmt.Printf("-!! Changes detected for team %s in environment %s\n", teamName, envName)
 
+		files, err := git.ChangedFiles(platformRepoDir)
+		if err != nil {
+			return fmt.Errorf("failed to list changed files: %w", err)
+		}
+
+		if mode == platformChangesNone {
+			fmt.Printf("-Changes for team %s in environment %s written to %s:\n", teamName, envName, platformRepoDir)
+			for _, f := range files {
+				fmt.Printf("--%s\n", f)
+			}
+			printBlocked(results, teamName, envName)
+			return nil
+		}
+
 		err = git.Add(platformRepoDir, ".")
 		if err != nil {
 			return fmt.Errorf("failed to add files to commit: %w", err)
@@ -508,44 +1109,62 @@ func applyTeam(teamName string,
 		if err != nil {
 			return fmt.Errorf("failed to commit changes: %w", err)
 		}
-		// push changes
-		err = git.Push(platformRepoDir, platformGit)
+		// push changes, the base branch is never force pushed
+		if forcePush && mode == platformChangesPR {
+			err = git.ForcePush(platformRepoDir, platformGit)
+		} else {
+			err = git.Push(platformRepoDir, platformGit)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to push changes: %w", err)
 		}
 
-		gitURL, err := giturl.NewGitURL(*platformGit.Remote)
-		if err != nil {
-			return fmt.Errorf("failed to parse Git URL: %w", err)
+		if mode == platformChangesDirect {
+			fmt.Printf("-Changes for team %s in environment %s committed to branch %s\n", teamName, envName, branchName)
+			printBlocked(results, teamName, envName)
+			return nil
 		}
 
-		_, err = github.CreateOrUpdatePullRequest(
+		pr, err := hosting.CreateOrUpdatePullRequest(
 			context.Background(),
-			gitURL.GetOwnerName(),
-			gitURL.GetRepoName(),
 			branchName,
-			fmt.Sprintf("[Konnect Orchestrator] - Changes for [%s] environment", envName),
-			fmt.Sprintf(
-				`**Environment:** %s 
-				
-				Konnect Orchestrator has detected changes in upstream service repositories 
-				and has generated the appropriate updates.
-				
-				Review and merge this PR to create or update the deck file (s).`, envName,
-			),
-			*platformGit.GitHub,
-			nil,
+			platformGit.BaseBranchName(),
+			title,
+			// the body is the report of the changes, in a section of the team so the teams sharing the
+			// pull request of an environment each update their own part
+			github.Section(orgName+"/"+envName+"/"+teamName, teamReport(orgName, envName, teamName, results, files)),
+			prLabels,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to create or update pull request: %w", err)
 		}
+		if teamConfig.Reviewers != nil {
+			err = hosting.RequestReviewers(context.Background(),
+				pr.Number,
+				teamConfig.Reviewers.Users,
+				teamConfig.Reviewers.Teams)
+			if err != nil {
+				// Reviewers can't be requested from the pull request author, don't fail the apply
+				fmt.Printf("Warning: failed to request reviewers for team %s: %v\n", teamName, err)
+			}
+		}
 	} else {
 		fmt.Printf("-No changes for team %s in environment %s\n", teamName, envName)
 	}
 
+	printBlocked(results, teamName, envName)
 	return nil
 }
 
+func printBlocked(results []*serviceResult, teamName, envName string) {
+	for _, r := range results {
+		if r.Blocked != "" {
+			fmt.Printf("-!! Service %s of team %s in environment %s was not updated: %s\n",
+				r.Name, teamName, envName, r.Blocked)
+		}
+	}
+}
+
 func applyEnvironment(
 	envName string,
 	orgName string,
@@ -554,9 +1173,19 @@ func applyEnvironment(
 	teams map[string]*manifest.Team,
 	platformGit manifest.GitConfig,
 	sdk *kk.SDK,
+	names *naming.Templates,
+	versioning *manifest.APIVersioning,
+	orgPolicies *manifest.Policies,
+	orgPullRequests *manifest.PullRequests,
 ) error {
 	fmt.Printf("Processing environment %s in organization %s\n", envName, orgName)
 
+	policies := policy.ForEnvironment(orgPolicies, envConfig.Policies, envConfig.Type)
+	grouping, err := pullRequestGrouping(orgPullRequests, envConfig.PullRequests)
+	if err != nil {
+		return fmt.Errorf("invalid pull requests configuration for environment %s: %w", envName, err)
+	}
+
 	labels := map[string]string{
 		// 'konnect' is a reserved prefix for labels
 		"ko-konnect-orchestrator": "true",
@@ -564,8 +1193,18 @@ func applyEnvironment(
 		"env-type":                envConfig.Type,
 	}
 
+	portalName, err := names.Portal(naming.Vars{
+		Org:     orgName,
+		Env:     envName,
+		EnvType: envConfig.Type,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to name portal for environment %s: %w", envName, err)
+	}
+
 	portalID, err := applyPortal(
 		accessToken,
+		portalName,
 		orgName,
 		envConfig.Region,
 		envName,
@@ -588,6 +1227,10 @@ func applyEnvironment(
 				platformGit,
 				nil, // nil because we use the default config in the teamConfig
 				portalID,
+				names,
+				versioning,
+				policies,
+				grouping,
 				labels)
 			if err != nil {
 				return err
@@ -608,6 +1251,10 @@ func applyEnvironment(
 				platformGit,
 				teamEnvironmentConfig,
 				portalID,
+				names,
+				versioning,
+				policies,
+				grouping,
 				labels)
 			if err != nil {
 				return err
@@ -630,13 +1277,43 @@ func applyOrganization(
 		return fmt.Errorf("failed to resolve access token for organization %s: %w", orgName, err)
 	}
 
+	names, err := naming.New(orgConfig.Naming)
+	if err != nil {
+		return fmt.Errorf("invalid naming configuration for organization %s: %w", orgName, err)
+	}
+
+	if err := policy.Validate(orgConfig.Policies); err != nil {
+		return fmt.Errorf("invalid policies for organization %s: %w", orgName, err)
+	}
+	for envName, envConfig := range orgConfig.Environments {
+		if err := policy.Validate(envConfig.Policies); err != nil {
+			return fmt.Errorf("invalid policies for environment %s in organization %s: %w", envName, orgName, err)
+		}
+		if _, err := pullRequestGrouping(orgConfig.PullRequests, envConfig.PullRequests); err != nil {
+			return fmt.Errorf("invalid pull requests configuration for environment %s in organization %s: %w",
+				envName, orgName, err)
+		}
+		if _, err := platformChangesMode(envConfig.PlatformChanges); err != nil {
+			return fmt.Errorf("invalid platform changes for environment %s in organization %s: %w",
+				envName, orgName, err)
+		}
+	}
+
 	// Initialize SDK client for this organization
 	sdk := kk.New(
 		kk.WithSecurity(kkComps.Security{
 			PersonalAccessToken: kk.String(accessToken),
 		}),
+		kk.WithServerURL(konnectServerURL("global")),
 	)
 
+	if orgConfig.SystemAccount != nil {
+		err = applySystemAccount(orgName, platformGit, *orgConfig.SystemAccount, sdk, false)
+		if err != nil {
+			return err
+		}
+	}
+
 	if orgConfig.Authorization != nil {
 		fmt.Printf("Applying authorization settings to organization %s\n", orgName)
 		err = auth.ApplyAuthSettings(
@@ -658,13 +1335,24 @@ func applyOrganization(
 		err := applyEnvironment(
 			envName, orgName,
 			accessToken,
-			*envConfig, teams, platformGit, sdk)
+			*envConfig, teams, platformGit, sdk, names, orgConfig.APIVersioning, orgConfig.Policies,
+			orgConfig.PullRequests)
 		if err != nil {
 			return err
 		}
 		regions[envConfig.Region] = struct{}{}
 	}
 
+	for _, teamName := range organizationTeams(orgConfig, teams) {
+		teamConfig := teams[teamName]
+		if teamConfig.SystemAccount == nil {
+			continue
+		}
+		if err := applyTeamSystemAccount(orgName, teamName, *teamConfig, sdk, false); err != nil {
+			return err
+		}
+	}
+
 	// Default is true, so create if it's missing or truthy
 	if orgConfig.EnableCustomReports == nil || *orgConfig.EnableCustomReports {
 		for region := range regions {
@@ -672,7 +1360,7 @@ func applyOrganization(
 				reports.WithSecurity(kkInternalComps.Security{
 					PersonalAccessToken: kk.String(accessToken),
 				}),
-				reports.WithServerURL(fmt.Sprintf("https://%s.api.konghq.com", region)),
+				reports.WithServerURL(konnectServerURL(region)),
 			)
 			fmt.Printf("Creating default custom reports for organization %s in region %s\n", orgName, region)
 			err = reports.ApplyReports(
@@ -688,6 +1376,7 @@ func applyOrganization(
 		kkInternal.WithSecurity(kkInternalComps.Security{
 			PersonalAccessToken: kk.String(accessToken),
 		}),
+		kkInternal.WithServerURL(konnectServerURL("global")),
 	)
 
 	fmt.Printf("Applying notification configuration settings to organization %s\n", orgName)
@@ -703,6 +1392,15 @@ func applyOrganization(
 	return nil
 }
 
+// konnectServerURL returns the Konnect API of a region, or the API set by KONNECT_API_URL, e.g. the fake
+// Konnect API of the local demo
+func konnectServerURL(region string) string {
+	if u := os.Getenv("KONNECT_API_URL"); u != "" {
+		return u
+	}
+	return fmt.Sprintf("https://%s.api.konghq.com", region)
+}
+
 func loadPlatformGitCfgFromConfig(config *config.Config) manifest.GitConfig {
 	var gitCfg manifest.GitConfig
 	gitCfg.Remote = &config.PlatformRepoURL
@@ -710,18 +1408,51 @@ func loadPlatformGitCfgFromConfig(config *config.Config) manifest.GitConfig {
 		Name:  &config.PlatformRepoOwnerName,
 		Email: &config.PlatformRepoOwnerEmail,
 	}
-	gitCfg.GitHub = &manifest.GitHubConfig{
-		Token: &manifest.Secret{
-			Value: config.PlatformRepoGHToken,
-			Type:  "literal",
-		},
+	if config.PlatformRepoProvider != "" {
+		gitCfg.Provider = &config.PlatformRepoProvider
+	}
+	if config.PlatformRepoBaseBranch != "" {
+		gitCfg.BaseBranch = &config.PlatformRepoBaseBranch
+	}
+	token := &manifest.Secret{
+		Value: config.PlatformRepoGHToken,
+		Type:  "literal",
+	}
+	var apiURL, username *string
+	if config.PlatformRepoAPIURL != "" {
+		apiURL = &config.PlatformRepoAPIURL
+	}
+	if config.PlatformRepoUsername != "" {
+		username = &config.PlatformRepoUsername
+	}
+	switch git.ProviderName(gitCfg) {
+	case git.ProviderGitLab:
+		gitCfg.GitLab = &manifest.GitLabConfig{Token: token, APIURL: apiURL}
+	case git.ProviderBitbucket:
+		gitCfg.Bitbucket = &manifest.BitbucketConfig{Token: token, Username: username, APIURL: apiURL}
+	case git.ProviderAzureDevOps:
+		gitCfg.AzureDevOps = &manifest.AzureDevOpsConfig{Token: token, APIURL: apiURL}
+	case git.ProviderGitea:
+		gitCfg.Gitea = &manifest.GiteaConfig{Token: token, Username: username, APIURL: apiURL}
+	default:
+		gitCfg.GitHub = &manifest.GitHubConfig{Token: token, APIURL: apiURL}
+		if config.PlatformRepoGitHubAppID != 0 {
+			gitCfg.GitHub = &manifest.GitHubConfig{APIURL: apiURL, App: &manifest.GitHubAppConfig{
+				ID:             int64(config.PlatformRepoGitHubAppID),
+				InstallationID: int64(config.PlatformRepoGitHubAppInstallationID),
+				PrivateKey: &manifest.Secret{
+					Value: config.PlatformRepoGitHubAppPrivateKey,
+					Type:  "literal",
+				},
+			}}
+		}
 	}
 	return gitCfg
 }
 
 func loadConfigManifest() (*manifest.Orchestrator, error) {
 	var man manifest.Orchestrator
-	var wholeFilePath, teamsFilePath, organizationsFilePath string
+	var wholeFilePath, teamsFilePath, organizationsFilePath, platformFilePath string
 	if wholeFileArg != "" {
 		var err error
 		wholeFilePath, err = filepath.Abs(wholeFileArg)
@@ -743,6 +1474,18 @@ func loadConfigManifest() (*manifest.Orchestrator, error) {
 			}
 		}
 
+		if platformFileArg != "" {
+			platformFilePath, err = filepath.Abs(platformFileArg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve platform file path: %w", err)
+			}
+			// The platform configuration is optional, CI runners configure the platform repository with
+			// environment variables
+			if _, err := os.Stat(platformFilePath); os.IsNotExist(err) {
+				platformFilePath = ""
+			}
+		}
+
 		if organizationsFileArg != "" {
 			organizationsFilePath, err = filepath.Abs(organizationsFileArg)
 			if err != nil {
@@ -759,6 +1502,12 @@ func loadConfigManifest() (*manifest.Orchestrator, error) {
 			return nil, fmt.Errorf("failed to read whole configuration: %w", err)
 		}
 	} else {
+		if platformFilePath != "" {
+			if err := util.ReadConfigFile(platformFilePath, &man); err != nil {
+				return nil, fmt.Errorf("failed to read platform configuration: %w", err)
+			}
+		}
+
 		if teamsFilePath != "" {
 			if err := util.ReadConfigFile(teamsFilePath, &man); err != nil {
 				return nil, fmt.Errorf("failed to read teams configuration: %w", err)
@@ -798,11 +1547,66 @@ func loadConfigManifest() (*manifest.Orchestrator, error) {
 				},
 			},
 		}
+		// the GitHub App of the orchestrator supersedes the token
+		if appID := os.Getenv(github.AppIDEnvVar); appID != "" {
+			app, err := githubAppFromEnv(appID)
+			if err != nil {
+				return nil, err
+			}
+			man.Platform.Git.GitHub = &manifest.GitHubConfig{App: app}
+		}
+		// runners of GitHub Enterprise Server call its API
+		if apiURL := os.Getenv("GITHUB_API_URL"); apiURL != "" {
+			man.Platform.Git.GitHub.APIURL = &apiURL
+		}
+		// or the predefined variables of GitLab CI/CD jobs
+		if os.Getenv("GITLAB_CI") == "true" {
+			remote = os.Getenv("CI_PROJECT_URL") + ".git"
+			providerName := git.ProviderGitLab
+			apiURL := os.Getenv("CI_API_V4_URL")
+			man.Platform.Git.Provider = &providerName
+			man.Platform.Git.GitHub = nil
+			man.Platform.Git.GitLab = &manifest.GitLabConfig{
+				Token: &manifest.Secret{
+					Value: "GITLAB_TOKEN",
+					Type:  "env",
+				},
+				APIURL: &apiURL,
+			}
+			if base := os.Getenv("CI_DEFAULT_BRANCH"); base != "" {
+				man.Platform.Git.BaseBranch = &base
+			}
+		}
+		// the base branch set for the runner takes precedence over the default branch of the project
+		if base := os.Getenv("PLATFORM_REPO_BASE_BRANCH"); base != "" {
+			man.Platform.Git.BaseBranch = &base
+		}
 	}
 
 	return &man, nil
 }
 
+// githubAppFromEnv configures the GitHub App set in the repository secrets of the platform repository
+func githubAppFromEnv(appID string) (*manifest.GitHubAppConfig, error) {
+	id, err := strconv.ParseInt(appID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %w", github.AppIDEnvVar, appID, err)
+	}
+	app := &manifest.GitHubAppConfig{
+		ID: id,
+		PrivateKey: &manifest.Secret{
+			Value: github.AppPrivateKeyEnvVar,
+			Type:  "env",
+		},
+	}
+	if installationID := os.Getenv(github.AppInstallationIDEnvVar); installationID != "" {
+		if app.InstallationID, err = strconv.ParseInt(installationID, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", github.AppInstallationIDEnvVar, installationID, err)
+		}
+	}
+	return app, nil
+}
+
 func apply(man *manifest.Orchestrator) error {
 	for orgName, orgConfig := range man.Organizations {
 		if err := applyOrganization(orgName, *man.Platform.Git, *orgConfig, man.Teams); err != nil {
@@ -817,8 +1621,8 @@ func apply(man *manifest.Orchestrator) error {
 
 func validateConfig(c *config.Config) error {
 	// Validate critical configuration
-	if c.PlatformRepoGHToken == "" {
-		return fmt.Errorf("missing platform repository token")
+	if !c.HasPlatformRepoCredentials() {
+		return fmt.Errorf("missing platform repository token or GitHub App")
 	}
 	if c.PlatformRepoURL == "" {
 		return fmt.Errorf("missing platform repository URL")
@@ -865,7 +1669,7 @@ func runAddOrganization(_ *cobra.Command, _ []string) error {
 		cfg.KonnectToken = orgKonnectTokenArg
 	}
 
-	if cfg.PlatformRepoGHToken != "" && cfg.PlatformRepoURL != "" && cfg.KonnectToken != "" && cfg.OrgName != "" {
+	if cfg.HasPlatformRepoCredentials() && cfg.PlatformRepoURL != "" && cfg.KonnectToken != "" && cfg.OrgName != "" {
 		return runAddOrganizationDirect(cfg)
 	}
 	return addorgprogram.Execute(*cfg)
@@ -901,7 +1705,7 @@ func runInit(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
-	if cfg.PlatformRepoGHToken != "" && cfg.PlatformRepoURL != "" {
+	if cfg.HasPlatformRepoCredentials() && cfg.PlatformRepoURL != "" {
 		return runInitDirect(cfg)
 	}
 	return initprogram.Execute(resourceFiles, *cfg, createNewRepo)
@@ -948,7 +1752,11 @@ func runRun(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	if cfg.PlatformRepoGHToken != "" && cfg.PlatformRepoURL != "" &&
+	if runLocal {
+		return runRunLocal()
+	}
+
+	if cfg.HasPlatformRepoCredentials() && cfg.PlatformRepoURL != "" &&
 		cfg.GitHubClientID != "" && cfg.GitHubClientSecret != "" {
 		return runRunDirect()
 	}
@@ -956,6 +1764,72 @@ func runRun(_ *cobra.Command, _ []string) error {
 	return runprogram.Execute(*cfg)
 }
 
+func runRunLocal() error {
+	instance, err := docker.ComposeUp(context.Background(), docker.KoctlRunComposeFile, "koctl-run", docker.LocalEnvVars)
+	if err != nil {
+		return fmt.Errorf("failed to run docker: %w", err)
+	}
+
+	fmt.Println("")
+	fmt.Printf("Gitea:             http://localhost:3000 (user %s, password %s)\n",
+		docker.LocalGitUsername, docker.LocalGitPassword)
+	fmt.Println("Fake Konnect API:  http://localhost:8082")
+	fmt.Println("Self service UI:   http://localhost:8081 (signing in requires GITHUB_CLIENT_ID and GITHUB_CLIENT_SECRET)")
+	fmt.Println("")
+	fmt.Println("Apply the seeded platform repository to the fake Konnect API with:")
+	fmt.Printf("git clone http://localhost:3000/%s/platform.git && cd platform\n", platform.LocalOrganization)
+	fmt.Printf("GITEA_TOKEN=%s KONNECT_API_URL=http://localhost:8082 koctl apply\n", docker.LocalGitPassword)
+	fmt.Println("")
+	fmt.Println("To stop the project and delete its data, run:")
+	fmt.Printf("docker compose --project-name %s --profile local down --volumes\n", instance.ProjectName)
+	return nil
+}
+
+func runRunKonnect(_ *cobra.Command, _ []string) error {
+	addr := fmt.Sprintf(":%d", konnectPortArg)
+	fmt.Printf("Fake Konnect API listening on %s\n", addr)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           fakekonnect.New(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+func runRunSeed(_ *cobra.Command, _ []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.PlatformRepoAPIURL == "" || cfg.PlatformRepoGHToken == "" {
+		return fmt.Errorf("PLATFORM_REPO_API_URL and PLATFORM_REPO_GITHUB_TOKEN are required to seed the Gitea server")
+	}
+	client := gitea.NewClient(cfg.PlatformRepoAPIURL, cfg.PlatformRepoUsername, cfg.PlatformRepoGHToken)
+
+	statusChan := make(chan string)
+	errChan := make(chan error)
+	go func() {
+		errChan <- platform.SeedLocal(context.Background(), client, resourceFiles, seedWebURLArg,
+			cfg.PlatformRepoUsername, statusChan)
+		close(statusChan)
+	}()
+
+	for {
+		select {
+		case status, ok := <-statusChan:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("%s", status)
+		case err := <-errChan:
+			if err != nil {
+				return fmt.Errorf("failed to seed the local demo: %w", err)
+			}
+			return nil
+		}
+	}
+}
+
 func runRunUI(_ *cobra.Command, _ []string) error {
 	_, err := config.LoadConfig()
 	if err != nil {
@@ -993,6 +1867,43 @@ func runRunExport(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+func runGenerate(_ *cobra.Command, args []string) error {
+	dir := defaultOrchestratorPath
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	written, err := generate.Platform(dir)
+	if err != nil {
+		return fmt.Errorf("failed to generate decK files: %w", err)
+	}
+	for _, f := range written {
+		fmt.Printf("Wrote %s\n", f)
+	}
+	return nil
+}
+
+func runGenerateService(_ *cobra.Command, args []string) error {
+	written, err := generate.Service(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to generate decK file: %w", err)
+	}
+	fmt.Printf("Wrote %s\n", written)
+	return nil
+}
+
+func runGenerateTeam(_ *cobra.Command, args []string) error {
+	written, err := generate.Team(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to generate decK file: %w", err)
+	}
+	if written == "" {
+		fmt.Printf("No %s files found in %s\n", generate.ServiceDeckFileName, args[0])
+		return nil
+	}
+	fmt.Printf("Wrote %s\n", written)
+	return nil
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Kong/konnect-orchestrator/internal/doctor"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the secrets and credentials of the configuration",
+	Long: `Resolves every secret of the configuration applied by koctl apply and verifies the credentials they
+hold: the Konnect access tokens of the organizations, the scopes of the GitHub tokens and the git access to
+the platform and service repositories. Secret values are never printed.`,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().StringVar(&wholeFileArg,
+		"file",
+		"",
+		"Path to the configuration file. This is a convenience flag to check the whole configuration in one file")
+	doctorCmd.Flags().StringVar(&teamsFileArg,
+		"teams",
+		"./"+defaultTeamsFilePath,
+		"Path to the teams configuration file. Superseded by --file")
+	doctorCmd.Flags().StringVar(&organizationsFileArg,
+		"orgs",
+		"./"+defaultOrgsFilePath,
+		"Path to the organizations configuration file. Superseded by --file")
+	doctorCmd.Flags().StringVar(&platformFileArg,
+		"platform",
+		"./"+defaultPlatformFilePath,
+		"Path to the platform configuration file, ignored when missing. Superseded by --file")
+
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(_ *cobra.Command, _ []string) error {
+	man, err := loadConfigManifest()
+	if err != nil {
+		return err
+	}
+
+	results := doctor.Run(context.Background(), man, doctor.Options{KonnectServerURL: konnectServerURL("global")})
+	for _, r := range results {
+		symbol := "✔"
+		switch r.Status {
+		case doctor.StatusWarning:
+			symbol = "!"
+		case doctor.StatusFailure:
+			symbol = "✘"
+		}
+		fmt.Printf("%s %s: %s\n", symbol, r.Name, r.Message)
+	}
+	if doctor.Failed(results) {
+		return errors.New("some checks failed")
+	}
+	return nil
+}
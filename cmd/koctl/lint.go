@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Kong/konnect-orchestrator/internal/deck/generate"
+	"github.com/Kong/konnect-orchestrator/internal/lint"
+)
+
+var (
+	lintRulesetArg string
+	lintFormatArg  string
+	lintFailOnArg  string
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file...]",
+	Short: "Lint OpenAPI specs and decK files against Spectral style rulesets",
+	Long: `Checks files against a ruleset supporting JSONPath given expressions and the enumeration,
+pattern and truthy functions. Without files, every openapi.yaml of the platform repository is checked
+against konnect/oas-file-rules.yaml and every kong-from-oas.yaml against konnect/deck-file-rules.yaml.
+The command fails when a finding is at least as severe as --fail-severity.`,
+	RunE: runLint,
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintRulesetArg,
+		"ruleset",
+		"",
+		"Path to the ruleset, required when files are given")
+	lintCmd.Flags().StringVar(&lintFormatArg,
+		"format",
+		"text",
+		"Output format, text or github to annotate pull requests from GitHub Actions")
+	lintCmd.Flags().StringVar(&lintFailOnArg,
+		"fail-severity",
+		"error",
+		"Lowest severity failing the command: error, warn, info or hint")
+
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(_ *cobra.Command, args []string) error {
+	failOn, err := lint.ParseSeverity(lintFailOnArg)
+	if err != nil {
+		return fmt.Errorf("invalid --fail-severity: %w", err)
+	}
+	if lintFormatArg != "text" && lintFormatArg != "github" {
+		return fmt.Errorf("invalid --format %s, expected text or github", lintFormatArg)
+	}
+
+	type target struct {
+		ruleset string
+		files   []string
+	}
+	var targets []target
+	if len(args) > 0 {
+		if lintRulesetArg == "" {
+			return fmt.Errorf("--ruleset is required when files are given")
+		}
+		targets = append(targets, target{ruleset: lintRulesetArg, files: args})
+	} else {
+		specs, decks, err := platformLintFiles(defaultOrchestratorPath)
+		if err != nil {
+			return err
+		}
+		targets = append(targets,
+			target{ruleset: filepath.Join(defaultOrchestratorPath, lint.OASRulesFileName), files: specs},
+			target{ruleset: filepath.Join(defaultOrchestratorPath, lint.DeckRulesFileName), files: decks})
+	}
+
+	failed := false
+	for _, t := range targets {
+		if len(t.files) == 0 {
+			continue
+		}
+		rs, err := lint.Load(t.ruleset)
+		if err != nil {
+			return err
+		}
+		for _, file := range t.files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", file, err)
+			}
+			findings, err := rs.Lint(content)
+			if err != nil {
+				return fmt.Errorf("failed to lint %s: %w", file, err)
+			}
+			for _, f := range findings {
+				if lintFormatArg == "github" {
+					fmt.Println(githubAnnotation(file, f))
+				} else {
+					fmt.Printf("%s: %s\n", file, f)
+				}
+			}
+			if lint.AtLeast(findings, failOn) {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		return fmt.Errorf("lint findings of severity %s or higher", failOn)
+	}
+	return nil
+}
+
+// platformLintFiles finds the primary specs and the generated decK files of a platform repository
+func platformLintFiles(root string) ([]string, []string, error) {
+	var specs, decks []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+		case d.Name() == generate.SpecFileName:
+			specs = append(specs, path)
+		case d.Name() == generate.ServiceDeckFileName:
+			decks = append(decks, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find files to lint in %s: %w", root, err)
+	}
+	return specs, decks, nil
+}
+
+// githubAnnotation formats a finding as a GitHub Actions workflow command, shown on the pull request
+func githubAnnotation(file string, f lint.Finding) string {
+	level := "notice"
+	switch f.Severity {
+	case lint.Error:
+		level = "error"
+	case lint.Warn:
+		level = "warning"
+	}
+	return fmt.Sprintf("::%s file=%s,title=%s::%s %s", level, file, f.Rule, f.Path, f.Message)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/lint"
+	"github.com/Kong/konnect-orchestrator/internal/spec"
+)
+
+// serviceResult is the outcome of applying a service, reported in the pull request of its team
+type serviceResult struct {
+	Name string
+	// Dir is the service directory, relative to the platform repository root
+	Dir string
+	// Repository, Branch and Commit identify the revision of the service repository the specs come from
+	Repository string
+	Branch     string
+	Commit     string
+	// Version is the info.version of the primary spec
+	Version string
+	// Findings of the lint rules, by spec path or decK file name
+	Findings map[string][]lint.Finding
+	// Changelog of the primary spec compared with the revision in the platform repository
+	Changelog *spec.Changelog
+	// Blocked is the reason the specs weren't written to the platform repository
+	Blocked string
+}
+
+func printFindings(findings map[string][]lint.Finding) {
+	for _, file := range sortedKeys(findings) {
+		for _, f := range findings[file] {
+			fmt.Printf("---%s: %s\n", file, f)
+		}
+	}
+}
+
+// teamReport renders the changes applied for a team as a section of the environment pull request body:
+// the source of every service spec, the version change, the platform repository files changed, the
+// API changelog and the lint findings
+func teamReport(orgName, envName, teamName string, results []*serviceResult, files []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Team %s\n\n**Organization:** %s | **Environment:** %s\n", teamName, orgName, envName)
+
+	claimed := map[string]bool{}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		var serviceFiles []string
+		for _, f := range files {
+			if r.Dir != "" && strings.HasPrefix(f, r.Dir+"/") {
+				serviceFiles = append(serviceFiles, f)
+				claimed[f] = true
+			}
+		}
+		if len(serviceFiles) == 0 && r.Blocked == "" && len(r.Findings) == 0 {
+			continue
+		}
+		b.WriteString(serviceReport(r, serviceFiles))
+	}
+
+	var other []string
+	for _, f := range files {
+		if !claimed[f] {
+			other = append(other, f)
+		}
+	}
+	if len(other) > 0 {
+		b.WriteString("\n### Other files\n\n")
+		writeFileList(&b, other)
+	}
+	return b.String()
+}
+
+func serviceReport(r *serviceResult, files []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n### %s\n\n", r.Name)
+
+	repo := r.Repository
+	if u := repositoryURL(r.Repository); u != "" {
+		repo = fmt.Sprintf("[%s](%s)", r.Repository, u)
+	}
+	commit := shortCommit(r.Commit)
+	if u := commitURL(r.Repository, r.Commit); u != "" {
+		commit = fmt.Sprintf("[%s](%s)", commit, u)
+	}
+	fmt.Fprintf(&b, "- **Source:** %s, branch `%s`, commit %s\n", repo, r.Branch, commit)
+
+	switch {
+	case r.Changelog == nil && r.Version != "":
+		fmt.Fprintf(&b, "- **Spec version:** %s (new)\n", r.Version)
+	case r.Changelog != nil && r.Changelog.OldVersion != r.Changelog.NewVersion:
+		fmt.Fprintf(&b, "- **Spec version:** %s → %s\n", r.Changelog.OldVersion, r.Changelog.NewVersion)
+	case r.Changelog != nil:
+		fmt.Fprintf(&b, "- **Spec version:** %s (unchanged)\n", r.Changelog.NewVersion)
+	}
+	if r.Blocked != "" {
+		fmt.Fprintf(&b, "- **Not updated:** %s\n", r.Blocked)
+	}
+
+	if len(files) > 0 {
+		fmt.Fprintf(&b, "\n<details><summary>Platform repository files (%d)</summary>\n\n", len(files))
+		writeFileList(&b, files)
+		b.WriteString("\n</details>\n")
+	}
+
+	if r.Changelog != nil && len(r.Changelog.Changes) > 0 {
+		b.WriteString("\n#### API changes\n\n")
+		for _, c := range r.Changelog.Changes {
+			marker := ""
+			if c.Breaking {
+				marker = "**Breaking:** "
+			}
+			fmt.Fprintf(&b, "- %s`%s` %s\n", marker, c.Location, c.Message)
+		}
+	}
+
+	if len(r.Findings) > 0 {
+		b.WriteString("\n#### Lint findings\n\n| Severity | File | Rule | Location | Message |\n|---|---|---|---|---|\n")
+		for _, file := range sortedKeys(r.Findings) {
+			for _, f := range r.Findings[file] {
+				fmt.Fprintf(&b, "| %s | %s | %s | `%s` | %s |\n",
+					f.Severity, file, f.Rule, f.Path, strings.ReplaceAll(f.Message, "|", "\\|"))
+			}
+		}
+	}
+	return b.String()
+}
+
+func writeFileList(b *strings.Builder, files []string) {
+	for _, f := range files {
+		fmt.Fprintf(b, "- `%s`\n", f)
+	}
+}
+
+func sortedKeys(findings map[string][]lint.Finding) []string {
+	keys := make([]string, 0, len(findings))
+	for k := range findings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// repositoryURL returns the web page of a git remote, or an empty string for unsupported remotes
+func repositoryURL(remote string) string {
+	r, err := git.ParseRemote(remote)
+	if err != nil {
+		return ""
+	}
+	return r.WebURL()
+}
+
+func commitURL(remote, commit string) string {
+	repo := repositoryURL(remote)
+	if repo == "" || commit == "" {
+		return ""
+	}
+	return repo + "/commit/" + commit
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
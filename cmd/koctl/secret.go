@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kong/konnect-orchestrator/internal/age"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/secrets"
+)
+
+var (
+	secretFileArg       string
+	secretRecipientsArg []string
+	secretTypesArg      []string
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Encrypt, decrypt and rotate the secrets of the organizations configuration with age",
+	Long: `Edits the secrets of the organizations configuration in place, keeping its comments. Secrets are
+encrypted with age to the recipients of --recipient or SOPS_AGE_RECIPIENTS, and decrypted with the
+identities of SOPS_AGE_KEY or of the file SOPS_AGE_KEY_FILE, ~/.config/sops/age/keys.txt by default.`,
+}
+
+var secretEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Replace secrets of the types of --types by inline age encrypted values",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		recipients, err := secrets.ParseAgeRecipients(secretRecipientsArg...)
+		if err != nil {
+			return err
+		}
+		types := map[string]bool{}
+		for _, t := range secretTypesArg {
+			types[t] = true
+		}
+		return transformSecretsFile("Encrypted", func(secret *manifest.Secret) (bool, error) {
+			if secret.Type == secrets.TypeAge || !types[secret.Type] {
+				return false, nil
+			}
+			// the key and the encoding apply to the encrypted value
+			value, err := secrets.Resolve(context.Background(),
+				manifest.Secret{Type: secret.Type, Value: secret.Value, Trim: secret.Trim})
+			if err != nil {
+				return false, err
+			}
+			secret.Type = secrets.TypeAge
+			secret.Value, err = secrets.EncryptAge(value, recipients)
+			return true, err
+		})
+	},
+}
+
+var secretDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Replace inline age encrypted secrets by literal values",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return transformSecretsFile("Decrypted", func(secret *manifest.Secret) (bool, error) {
+			value, ok, err := decryptInlineSecret(*secret)
+			if !ok || err != nil {
+				return false, err
+			}
+			secret.Type, secret.Value = secrets.TypeLiteral, value
+			return true, nil
+		})
+	},
+}
+
+var secretRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt inline age encrypted secrets to the recipients of --recipient",
+	Long: `Decrypts the inline age encrypted secrets and encrypts them again with a new file key, to the
+recipients of --recipient or SOPS_AGE_RECIPIENTS, e.g. to add or remove a recipient.`,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		recipients, err := secrets.ParseAgeRecipients(secretRecipientsArg...)
+		if err != nil {
+			return err
+		}
+		return transformSecretsFile("Rotated", func(secret *manifest.Secret) (bool, error) {
+			value, ok, err := decryptInlineSecret(*secret)
+			if !ok || err != nil {
+				return false, err
+			}
+			secret.Value, err = secrets.EncryptAge(value, recipients)
+			return true, err
+		})
+	},
+}
+
+func init() {
+	secretCmd.PersistentFlags().StringVar(&secretFileArg,
+		"orgs",
+		"./"+defaultOrgsFilePath,
+		"Path to the organizations configuration file")
+	for _, cmd := range []*cobra.Command{secretEncryptCmd, secretRotateCmd} {
+		cmd.Flags().StringSliceVar(&secretRecipientsArg,
+			"recipient",
+			nil,
+			"age recipient (age1...) to encrypt to, repeatable. Defaults to SOPS_AGE_RECIPIENTS")
+	}
+	secretEncryptCmd.Flags().StringSliceVar(&secretTypesArg,
+		"types",
+		[]string{secrets.TypeLiteral},
+		"Types of the secrets to encrypt, e.g. literal,file,env. Their values are resolved and stored inline")
+
+	secretCmd.AddCommand(secretEncryptCmd, secretDecryptCmd, secretRotateCmd)
+	rootCmd.AddCommand(secretCmd)
+}
+
+// decryptInlineSecret returns the value of an inline age secret, ok is false for other secrets
+func decryptInlineSecret(secret manifest.Secret) (string, bool, error) {
+	if secret.Type != secrets.TypeAge || !age.IsArmored(secret.Value) {
+		return "", false, nil
+	}
+	value, err := secrets.Resolve(context.Background(),
+		manifest.Secret{Type: secret.Type, Value: secret.Value, Trim: secret.Trim})
+	return value, true, err
+}
+
+// transformSecretsFile applies transform to the secrets of the organizations configuration and writes it back
+func transformSecretsFile(verb string, transform secrets.Transform) error {
+	info, err := os.Stat(secretFileArg)
+	if err != nil {
+		return fmt.Errorf("failed to read organizations file: %w", err)
+	}
+	content, err := os.ReadFile(secretFileArg)
+	if err != nil {
+		return fmt.Errorf("failed to read organizations file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("failed to parse organizations file: %w", err)
+	}
+
+	n, err := secrets.TransformYAML(&doc, transform)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Printf("No secrets to change in %s\n", secretFileArg)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode organizations file: %w", err)
+	}
+	if err := os.WriteFile(secretFileArg, buf.Bytes(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write organizations file: %w", err)
+	}
+	fmt.Printf("%s %d secrets in %s\n", verb, n, secretFileArg)
+	return nil
+}
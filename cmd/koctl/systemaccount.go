@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/provider"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/organization/systemaccount"
+	"github.com/Kong/konnect-orchestrator/internal/platform"
+	"github.com/Kong/konnect-orchestrator/internal/util"
+	kk "github.com/Kong/sdk-konnect-go"
+	kkComps "github.com/Kong/sdk-konnect-go/models/components"
+)
+
+var (
+	rotateOrgArg  string
+	rotateTeamArg string
+)
+
+var rotateTokensCmd = &cobra.Command{
+	Use:   "rotate-tokens",
+	Short: "Issue new access tokens to the system accounts of the organizations and teams",
+	Long: `Issues a new access token to the system account of every organization configuring a system-account,
+whether or not its current token nears its expiry, and writes it to the CI secret of the platform repository.
+The system accounts of the teams configuring a system-account are issued a new token written to the CI secret
+of their service repositories. koctl apply rotates the tokens on its own when they enter their rotation
+window, this command rotates them on demand, e.g. after a token leaked. The previous tokens stay valid until
+they expire.`,
+	RunE: runRotateTokens,
+}
+
+func init() {
+	rotateTokensCmd.Flags().StringVar(&rotateOrgArg,
+		"org",
+		"",
+		"Rotate the tokens of this organization only")
+	rotateTokensCmd.Flags().StringVar(&rotateTeamArg,
+		"team",
+		"",
+		"Rotate the token of the system account of this team only")
+	rotateTokensCmd.Flags().StringVar(&wholeFileArg,
+		"file",
+		"",
+		"Path to the configuration file. This is a convenience flag to load the whole configuration from one file")
+	rotateTokensCmd.Flags().StringVar(&teamsFileArg,
+		"teams",
+		"./"+defaultTeamsFilePath,
+		"Path to the teams configuration file. Superseded by --file")
+	rotateTokensCmd.Flags().StringVar(&organizationsFileArg,
+		"orgs",
+		"./"+defaultOrgsFilePath,
+		"Path to the organizations configuration file. Superseded by --file")
+	rotateTokensCmd.Flags().StringVar(&platformFileArg,
+		"platform",
+		"./"+defaultPlatformFilePath,
+		"Path to the platform configuration file, ignored when missing. Superseded by --file")
+
+	rootCmd.AddCommand(rotateTokensCmd)
+}
+
+func runRotateTokens(_ *cobra.Command, _ []string) error {
+	man, err := loadConfigManifest()
+	if err != nil {
+		return err
+	}
+	orgNames := make([]string, 0, len(man.Organizations))
+	for orgName, orgConfig := range man.Organizations {
+		if orgConfig == nil || rotateOrgArg != "" && orgName != rotateOrgArg {
+			continue
+		}
+		orgNames = append(orgNames, orgName)
+	}
+	if rotateOrgArg != "" && len(orgNames) == 0 {
+		return fmt.Errorf("organization %s not found", rotateOrgArg)
+	}
+	sort.Strings(orgNames)
+
+	if rotateTeamArg != "" {
+		if teamConfig, ok := man.Teams[rotateTeamArg]; !ok || teamConfig == nil {
+			return fmt.Errorf("team %s not found", rotateTeamArg)
+		} else if teamConfig.SystemAccount == nil {
+			return fmt.Errorf("team %s has no system account", rotateTeamArg)
+		}
+	}
+
+	for _, orgName := range orgNames {
+		orgConfig := man.Organizations[orgName]
+		accessToken, err := util.ResolveSecretValue(orgConfig.AccessToken)
+		if err != nil {
+			return fmt.Errorf("failed to resolve access token for organization %s: %w", orgName, err)
+		}
+		sdk := kk.New(
+			kk.WithSecurity(kkComps.Security{
+				PersonalAccessToken: kk.String(accessToken),
+			}),
+			kk.WithServerURL(konnectServerURL("global")),
+		)
+
+		if rotateTeamArg == "" {
+			if orgConfig.SystemAccount == nil {
+				fmt.Printf("Organization %s has no system account, skipping\n", orgName)
+			} else if man.Platform == nil || man.Platform.Git == nil {
+				return fmt.Errorf("missing platform repository configuration")
+			} else {
+				err = applySystemAccount(orgName, *man.Platform.Git, *orgConfig.SystemAccount, sdk, true)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, teamName := range organizationTeams(*orgConfig, man.Teams) {
+			teamConfig := man.Teams[teamName]
+			if teamConfig.SystemAccount == nil || rotateTeamArg != "" && teamName != rotateTeamArg {
+				continue
+			}
+			if err := applyTeamSystemAccount(orgName, teamName, *teamConfig, sdk, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applySystemAccount applies the system account of an organization and writes the token it was issued to
+// the CI secret of the platform repository. The token is revoked when it can't be written.
+func applySystemAccount(
+	orgName string,
+	platformGit manifest.GitConfig,
+	cfg manifest.SystemAccount,
+	sdk *kk.SDK,
+	force bool,
+) error {
+	ctx := context.Background()
+	svc := systemaccount.NewServices(sdk)
+
+	fmt.Printf("Applying system account %s to organization %s\n", systemaccount.Name(cfg), orgName)
+	token, err := systemaccount.Apply(ctx, svc, cfg, time.Now(), force)
+	if err != nil {
+		return fmt.Errorf("failed to apply system account for organization %s: %w", orgName, err)
+	}
+	if token == nil {
+		return nil
+	}
+
+	secretName := platform.KonnectTokenSecretName(orgName)
+	if cfg.Secret != nil && *cfg.Secret != "" {
+		secretName = *cfg.Secret
+	}
+	hosting, err := provider.New(platformGit)
+	if err == nil {
+		err = hosting.SetSecret(ctx, secretName, token.Value)
+	}
+	if err != nil {
+		if revokeErr := systemaccount.Revoke(ctx, svc.AccessTokens, *token); revokeErr != nil {
+			fmt.Printf("Failed to revoke the unstored access token of organization %s: %v\n", orgName, revokeErr)
+		}
+		return fmt.Errorf("failed to write the system account token of organization %s to %s: %w",
+			orgName, secretName, err)
+	}
+	fmt.Printf("Rotated the system account token of organization %s into %s, it expires %s\n",
+		orgName, secretName, token.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// organizationTeams returns the sorted names of the teams of an organization, the teams of its environments
+// or every team when an environment doesn't list its teams
+func organizationTeams(orgConfig manifest.Organization, teams map[string]*manifest.Team) []string {
+	names := map[string]struct{}{}
+	for _, envConfig := range orgConfig.Environments {
+		if envConfig == nil {
+			continue
+		}
+		if envConfig.Teams == nil {
+			for teamName := range teams {
+				names[teamName] = struct{}{}
+			}
+			continue
+		}
+		for teamName := range envConfig.Teams {
+			names[teamName] = struct{}{}
+		}
+	}
+
+	var sorted []string
+	for teamName := range names {
+		if teams[teamName] != nil {
+			sorted = append(sorted, teamName)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// applyTeamSystemAccount applies the system account of a team and writes the token it was issued to the CI
+// secret of each service repository of the team. The token is revoked when no repository stored it, the
+// repositories which did keep it when others failed, and the failed ones are written by koctl rotate-tokens.
+func applyTeamSystemAccount(
+	orgName string,
+	teamName string,
+	teamConfig manifest.Team,
+	sdk *kk.SDK,
+	force bool,
+) error {
+	ctx := context.Background()
+	svc := systemaccount.NewServices(sdk)
+	cfg := *teamConfig.SystemAccount
+
+	var serviceNames []string
+	for serviceName, service := range teamConfig.Services {
+		if service != nil && service.Git != nil {
+			serviceNames = append(serviceNames, serviceName)
+		}
+	}
+	if len(serviceNames) == 0 {
+		fmt.Printf("Team %s has no service repository, skipping its system account token\n", teamName)
+		return nil
+	}
+	sort.Strings(serviceNames)
+
+	fmt.Printf("Applying system account %s of team %s to organization %s\n",
+		systemaccount.TeamName(teamName, cfg), teamName, orgName)
+	token, err := systemaccount.ApplyTeamTokens(ctx, svc, teamName, cfg, time.Now(), force)
+	if err != nil {
+		return fmt.Errorf("failed to apply system account of team %s in organization %s: %w", teamName, orgName, err)
+	}
+	if token == nil {
+		return nil
+	}
+
+	secretName := platform.KonnectTokenSecretName(orgName)
+	if cfg.Secret != nil && *cfg.Secret != "" {
+		secretName = *cfg.Secret
+	}
+
+	var failed []string
+	stored := 0
+	for _, serviceName := range serviceNames {
+		hosting, err := provider.New(*teamConfig.Services[serviceName].Git)
+		if err == nil {
+			err = hosting.SetSecret(ctx, secretName, token.Value)
+		}
+		if err != nil {
+			fmt.Printf("Failed to write %s to the %s repository: %v\n", secretName, serviceName, err)
+			failed = append(failed, serviceName)
+			continue
+		}
+		stored++
+		fmt.Printf("✔ Added %s to %s repository secrets\n", secretName, serviceName)
+	}
+
+	if len(failed) > 0 {
+		if stored == 0 {
+			if revokeErr := systemaccount.Revoke(ctx, svc.AccessTokens, *token); revokeErr != nil {
+				fmt.Printf("Failed to revoke the unstored access token of team %s: %v\n", teamName, revokeErr)
+			}
+		}
+		return fmt.Errorf("failed to write the system account token of team %s in organization %s to %s of %s",
+			teamName, orgName, secretName, strings.Join(failed, ", "))
+	}
+	fmt.Printf("Rotated the system account token of team %s in organization %s, it expires %s\n",
+		teamName, orgName, token.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
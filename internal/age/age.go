@@ -0,0 +1,137 @@
+// Package age encrypts and decrypts files of the age format (https://age-encryption.org/v1) with X25519
+// recipients, compatible with the age CLI and with the age keys of sops. It wraps filippo.io/age, the
+// reference implementation used by both.
+package age
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// ErrNoIdentity is returned when none of the identities decrypts a file
+var ErrNoIdentity = errors.New("no identity matched any of the recipients")
+
+// Identity is the secret key of an X25519 recipient, AGE-SECRET-KEY-1...
+type Identity = age.X25519Identity
+
+// Recipient is the public key files are encrypted to, age1...
+type Recipient = age.X25519Recipient
+
+// GenerateIdentity returns a new random identity
+func GenerateIdentity() (*Identity, error) {
+	return age.GenerateX25519Identity()
+}
+
+// ParseIdentity parses an AGE-SECRET-KEY-1... identity
+func ParseIdentity(s string) (*Identity, error) {
+	id, err := age.ParseX25519Identity(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("malformed age identity: %w", err)
+	}
+	return id, nil
+}
+
+// ParseIdentities parses the identities of an identity file, one per line, ignoring empty lines and # comments
+func ParseIdentities(text string) ([]*Identity, error) {
+	var ids []*Identity
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := ParseIdentity(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no age identities found")
+	}
+	return ids, nil
+}
+
+// ParseRecipient parses an age1... recipient
+func ParseRecipient(s string) (*Recipient, error) {
+	r, err := age.ParseX25519Recipient(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("malformed age recipient %s: %w", s, err)
+	}
+	return r, nil
+}
+
+// Encrypt encrypts plaintext to recipients
+func Encrypt(plaintext []byte, recipients ...*Recipient) ([]byte, error) {
+	rs := make([]age.Recipient, 0, len(recipients))
+	for _, r := range recipients {
+		rs = append(rs, r)
+	}
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, rs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt age file: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("failed to encrypt age file: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt age file: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// Decrypt decrypts a binary or armored file with the first identity matching one of its recipients
+func Decrypt(ciphertext []byte, identities ...*Identity) ([]byte, error) {
+	var src io.Reader = bytes.NewReader(ciphertext)
+	if IsArmored(string(ciphertext)) {
+		src = armor.NewReader(strings.NewReader(strings.TrimSpace(string(ciphertext))))
+	}
+	ids := make([]age.Identity, 0, len(identities))
+	for _, id := range identities {
+		ids = append(ids, id)
+	}
+
+	r, err := age.Decrypt(src, ids...)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, ErrNoIdentity
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt age file: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt age payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// IsArmored reports whether s is an ASCII armored age file
+func IsArmored(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), armor.Header)
+}
+
+// Armor encodes a binary age file as ASCII, e.g. to inline it in YAML
+func Armor(data []byte) string {
+	var sb strings.Builder
+	w := armor.NewWriter(&sb)
+	// writes to a strings.Builder don't fail
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return sb.String()
+}
+
+// Dearmor decodes an ASCII armored age file
+func Dearmor(s string) ([]byte, error) {
+	data, err := io.ReadAll(armor.NewReader(strings.NewReader(strings.TrimSpace(s))))
+	if err != nil {
+		return nil, fmt.Errorf("malformed armored age file: %w", err)
+	}
+	return data, nil
+}
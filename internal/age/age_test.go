@@ -0,0 +1,117 @@
+package age
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	id, err := GenerateIdentity()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(id.String(), "AGE-SECRET-KEY-1"))
+	assert.True(t, strings.HasPrefix(id.Recipient().String(), "age1"))
+
+	parsed, err := ParseIdentity(id.String())
+	require.NoError(t, err)
+	assert.Equal(t, id.Recipient().String(), parsed.Recipient().String())
+
+	recipient, err := ParseRecipient(id.Recipient().String())
+	require.NoError(t, err)
+	assert.Equal(t, id.Recipient().String(), recipient.String())
+
+	ids, err := ParseIdentities("# created: 2024-01-01\n# public key: " + recipient.String() + "\n" + id.String() + "\n\n")
+	require.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	_, err = ParseRecipient(id.String())
+	assert.Error(t, err)
+	_, err = ParseIdentity(recipient.String())
+	assert.Error(t, err)
+	_, err = ParseIdentities("# no keys\n")
+	assert.Error(t, err)
+}
+
+// chunkSize is the size of the payload chunks of the age format
+const chunkSize = 64 * 1024
+
+// TestDecryptVector decrypts the armored file of the filippo.io/age armor example
+func TestDecryptVector(t *testing.T) {
+	id, err := ParseIdentity("AGE-SECRET-KEY-184JMZMVQH3E6U0PSL869004Y3U2NYV7R30EU99CSEDNPH02YUVFSZW44VU")
+	require.NoError(t, err)
+	plaintext, err := Decrypt([]byte(`
+-----BEGIN AGE ENCRYPTED FILE-----
+YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSB4YWdhZHZ0WG1PZldDT1hD
+K3RPRzFkUlJnWlFBQlUwemtjeXFRMFp6V1VFCnRzZFV3a3Vkd1dSUWw2eEtrRkVv
+SHcvZnp6Q3lqLy9HMkM4ZjUyUGdDZjQKLS0tIDlpVUpuVUQ5YUJyUENFZ0lNSTB2
+ekUvS3E5WjVUN0F5ZWR1ejhpeU5rZUUKsvPGYt7vf0o1kyJ1eVFMz1e4JnYYk1y1
+kB/RRusYjn+KVJ+KTioxj0THtzZPXcjFKuQ1
+-----END AGE ENCRYPTED FILE-----
+`), id)
+	require.NoError(t, err)
+	assert.Equal(t, "Black lives matter.", string(plaintext))
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	alice, err := GenerateIdentity()
+	require.NoError(t, err)
+	bob, err := GenerateIdentity()
+	require.NoError(t, err)
+	eve, err := GenerateIdentity()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{name: "empty", plaintext: []byte{}},
+		{name: "token", plaintext: []byte("kpat_123")},
+		{name: "one chunk", plaintext: bytes.Repeat([]byte("a"), chunkSize)},
+		{name: "several chunks", plaintext: bytes.Repeat([]byte("b"), 2*chunkSize+7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := Encrypt(tt.plaintext, alice.Recipient(), bob.Recipient())
+			require.NoError(t, err)
+
+			for _, id := range []*Identity{alice, bob} {
+				plaintext, err := Decrypt(ciphertext, eve, id)
+				require.NoError(t, err)
+				assert.Equal(t, len(tt.plaintext), len(plaintext))
+				assert.True(t, bytes.Equal(tt.plaintext, plaintext))
+			}
+
+			armored := Armor(ciphertext)
+			assert.True(t, IsArmored(armored))
+			plaintext, err := Decrypt([]byte(armored), bob)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(tt.plaintext, plaintext))
+
+			_, err = Decrypt(ciphertext, eve)
+			assert.ErrorIs(t, err, ErrNoIdentity)
+		})
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	id, err := GenerateIdentity()
+	require.NoError(t, err)
+	ciphertext, err := Encrypt([]byte("kpat_123"), id.Recipient())
+	require.NoError(t, err)
+
+	payload := append([]byte{}, ciphertext...)
+	payload[len(payload)-1] ^= 1
+	_, err = Decrypt(payload, id)
+	assert.ErrorContains(t, err, "failed to decrypt age payload")
+
+	intro := "age-encryption.org/v1\n"
+	header := append([]byte(intro+"-> other arg\n\n"), ciphertext[len(intro):]...)
+	_, err = Decrypt(header, id)
+	assert.ErrorContains(t, err, "failed to decrypt age file")
+
+	_, err = Decrypt([]byte("plaintext"), id)
+	assert.ErrorContains(t, err, "failed to decrypt age file")
+}
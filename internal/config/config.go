@@ -22,6 +22,11 @@ type Config struct {
 	GitHubClientSecret string
 	GitHubRedirectURI  string
 	GitHubScopes       []string
+	// GitHubURL is the web URL of a GitHub Enterprise Server used for OAuth login, github.com when empty
+	GitHubURL string
+	// GitHubAPIURL and GitHubUploadURL are derived from GitHubURL when empty
+	GitHubAPIURL    string
+	GitHubUploadURL string
 
 	// JWT configuration
 	JWTSecret     string
@@ -35,6 +40,21 @@ type Config struct {
 	PlatformRepoOwnerEmail string
 	PlatformRepoURL        string
 	PlatformRepoGHToken    string
+	// PlatformRepoProvider is the git provider of the platform repository, detected from the URL when empty
+	PlatformRepoProvider string
+	// PlatformRepoAPIURL is the API of the git provider, for self-hosted providers like Gitea
+	PlatformRepoAPIURL string
+	// PlatformRepoUsername authenticates with the platform repository token as password
+	PlatformRepoUsername string
+	// PlatformRepoGitHubAppID authenticates with the platform repository as a GitHub App instead of the token
+	PlatformRepoGitHubAppID int
+	// PlatformRepoGitHubAppInstallationID is discovered from the owner of the repository when zero
+	PlatformRepoGitHubAppInstallationID int
+	// PlatformRepoGitHubAppPrivateKey is the PEM encoded private key of the GitHub App
+	PlatformRepoGitHubAppPrivateKey string
+	// PlatformRepoBaseBranch is the branch of the platform repository pull requests are opened against,
+	// main when empty
+	PlatformRepoBaseBranch string
 
 	KonnectToken string
 	OrgName      string
@@ -67,6 +87,9 @@ func LoadConfig() (*Config, error) {
 		GitHubClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
 		GitHubRedirectURI:  getEnv("GITHUB_REDIRECT_URI", "http://localhost:8080/auth/github/callback"),
 		GitHubScopes:       defaultScopes,
+		GitHubURL:          getEnv("GITHUB_URL", ""),
+		GitHubAPIURL:       getEnv("GITHUB_API_URL", ""),
+		GitHubUploadURL:    getEnv("GITHUB_UPLOAD_URL", ""),
 
 		// JWT configuration
 		JWTSecret:     getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
@@ -80,6 +103,14 @@ func LoadConfig() (*Config, error) {
 		PlatformRepoOwnerEmail: getEnv("PLATFORM_REPO_OWNER_EMAIL", "ko@konghq.com"),
 		PlatformRepoURL:        getEnv("PLATFORM_REPO_URL", ""),
 		PlatformRepoGHToken:    getEnv("PLATFORM_REPO_GITHUB_TOKEN", ""),
+		PlatformRepoProvider:   getEnv("PLATFORM_REPO_PROVIDER", ""),
+		PlatformRepoAPIURL:     getEnv("PLATFORM_REPO_API_URL", ""),
+		PlatformRepoUsername:   getEnv("PLATFORM_REPO_USERNAME", ""),
+
+		PlatformRepoGitHubAppID:             getEnvAsInt("PLATFORM_REPO_GITHUB_APP_ID", 0),
+		PlatformRepoGitHubAppInstallationID: getEnvAsInt("PLATFORM_REPO_GITHUB_APP_INSTALLATION_ID", 0),
+		PlatformRepoGitHubAppPrivateKey:     getEnv("PLATFORM_REPO_GITHUB_APP_PRIVATE_KEY", ""),
+		PlatformRepoBaseBranch:              getEnv("PLATFORM_REPO_BASE_BRANCH", ""),
 
 		KonnectToken: getEnv("KONNECT_TOKEN", ""),
 		OrgName:      getEnv("ORG_NAME", ""),
@@ -88,6 +119,11 @@ func LoadConfig() (*Config, error) {
 	return config, nil
 }
 
+// HasPlatformRepoCredentials reports whether a token or a GitHub App authenticates with the platform repository
+func (c *Config) HasPlatformRepoCredentials() bool {
+	return c.PlatformRepoGHToken != "" || c.PlatformRepoGitHubAppID != 0
+}
+
 // getEnv retrieves an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
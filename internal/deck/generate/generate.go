@@ -0,0 +1,279 @@
+// Package generate produces the decK configuration of a platform repository from the service specs,
+// replacing the `deck file openapi2kong | deck file patch` and `deck file merge` steps of the
+// platform workflows.
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kong/konnect-orchestrator/internal/deck/patch"
+	"github.com/Kong/konnect-orchestrator/internal/policy"
+	"github.com/Kong/konnect-orchestrator/internal/spec"
+)
+
+const (
+	SpecFileName        = spec.DeckFileName
+	PatchFileName       = "ko-patch.yaml"
+	PatchesDirName      = "patches"
+	ServiceDeckFileName = "kong-from-oas.yaml"
+	TeamDeckFileName    = "kong.yaml"
+
+	// rootDirName is the directory of the platform repository holding the orchestrator files
+	rootDirName = "konnect"
+)
+
+// Service converts the openapi.yaml of a service directory in the platform repository to
+// kong-from-oas.yaml and applies the patches of the service (see Overlays). The patch overlays are
+// discovered up to the konnect directory containing the service. It returns the path of the written file.
+func Service(serviceDir string) (string, error) {
+	return service(findRoot(serviceDir), serviceDir)
+}
+
+// Overlays returns the patch files applying to a service directory, in the order they are applied:
+// the *.yaml files of the patches directory at every level from root down to the service (konnect/,
+// <org>/, envs/<env>/, teams/<team>/ and services/<svc>/, including the intermediate directories of
+// hierarchical service names), sorted by name within a level, followed by the ko-patch.yaml
+// generated by the orchestrator, so more specific patches win and orchestrator metadata is kept.
+func Overlays(root, serviceDir string) ([]string, error) {
+	rel, err := filepath.Rel(root, serviceDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("service directory %s is not below %s", serviceDir, root)
+	}
+
+	levels := []string{root}
+	if rel != "." {
+		dir := root
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			dir = filepath.Join(dir, part)
+			levels = append(levels, dir)
+		}
+	}
+
+	var overlays []string
+	for _, level := range levels {
+		entries, err := os.ReadDir(filepath.Join(level, PatchesDirName))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list patches of %s: %w", level, err)
+		}
+		for _, e := range entries {
+			if e.IsDir() || (filepath.Ext(e.Name()) != ".yaml" && filepath.Ext(e.Name()) != ".yml") {
+				continue
+			}
+			overlays = append(overlays, filepath.Join(level, PatchesDirName, e.Name()))
+		}
+	}
+
+	koPatch := filepath.Join(serviceDir, PatchFileName)
+	if _, err := os.Stat(koPatch); err == nil {
+		overlays = append(overlays, koPatch)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read patch file %s: %w", koPatch, err)
+	}
+	return overlays, nil
+}
+
+// findRoot returns the closest konnect directory containing dir, or dir itself when there is none
+func findRoot(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if filepath.Base(d) == rootDirName {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return abs
+		}
+	}
+}
+
+func service(root, serviceDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(serviceDir, SpecFileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to read spec of %s: %w", serviceDir, err)
+	}
+
+	doc, err := OpenAPI2Kong(content, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert spec of %s: %w", serviceDir, err)
+	}
+
+	serviceDir, err = filepath.Abs(serviceDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", serviceDir, err)
+	}
+	overlays, err := Overlays(root, serviceDir)
+	if err != nil {
+		return "", err
+	}
+	for _, patchPath := range overlays {
+		patchFile, err := patch.Load(patchPath)
+		if err != nil {
+			return "", err
+		}
+		if err := patchFile.Apply(doc); err != nil {
+			return "", fmt.Errorf("failed to apply %s: %w", patchPath, err)
+		}
+	}
+	if err := policy.CheckRoutes(doc); err != nil {
+		return "", fmt.Errorf("invalid decK file of %s: %w", serviceDir, err)
+	}
+
+	outPath := filepath.Join(serviceDir, ServiceDeckFileName)
+	if err := writeFile(outPath, doc); err != nil {
+		return "", err
+	}
+	return outPath, nil
+}
+
+// Team merges the kong-from-oas.yaml files of the services below a team directory into kong.yaml.
+// It returns the path of the written file, or an empty path if the team has no generated services.
+func Team(teamDir string) (string, error) {
+	var paths []string
+	err := filepath.WalkDir(teamDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == ServiceDeckFileName {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list decK files of %s: %w", teamDir, err)
+	}
+	if len(paths) == 0 {
+		return "", nil
+	}
+	sort.Strings(paths)
+
+	files := make([]map[string]interface{}, 0, len(paths))
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read decK file %s: %w", p, err)
+		}
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return "", fmt.Errorf("failed to parse decK file %s: %w", p, err)
+		}
+		files = append(files, doc)
+	}
+
+	merged, err := Merge(files...)
+	if err != nil {
+		return "", fmt.Errorf("failed to merge decK files of %s: %w", teamDir, err)
+	}
+
+	outPath := filepath.Join(teamDir, TeamDeckFileName)
+	if err := writeFile(outPath, merged); err != nil {
+		return "", err
+	}
+	return outPath, nil
+}
+
+// Platform generates the decK files of every service and team below root, which is usually the
+// konnect directory of a platform repository laid out as
+// <org>/envs/<env>/teams/<team>/services/<service>/openapi.yaml. It returns the written files.
+func Platform(root string) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+
+	teams := map[string]struct{}{}
+	var written []string
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != SpecFileName {
+			return nil
+		}
+		serviceDir := filepath.Dir(path)
+		teamDir, ok := teamOf(root, serviceDir)
+		if !ok {
+			return nil
+		}
+		// the converter only reads OpenAPI 3, e.g. a Swagger 2 spec of an older apply is skipped
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read spec of %s: %w", serviceDir, err)
+		}
+		if t, err := spec.Detect(path, content); err != nil || t != spec.OAS3 {
+			fmt.Printf("Warn: skipping %s, it isn't an OpenAPI 3 spec\n", path)
+			return nil
+		}
+		out, err := service(root, serviceDir)
+		if err != nil {
+			return err
+		}
+		written = append(written, out)
+		teams[teamDir] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	teamDirs := make([]string, 0, len(teams))
+	for t := range teams {
+		teamDirs = append(teamDirs, t)
+	}
+	sort.Strings(teamDirs)
+	for _, t := range teamDirs {
+		out, err := Team(t)
+		if err != nil {
+			return nil, err
+		}
+		if out != "" {
+			written = append(written, out)
+		}
+	}
+	return written, nil
+}
+
+// teamOf returns the team directory of a service directory laid out as .../teams/<team>/services/<service>,
+// where the service name may span several directories
+func teamOf(root, serviceDir string) (string, bool) {
+	rel, err := filepath.Rel(root, serviceDir)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i := 2; i < len(parts)-1; i++ {
+		if parts[i] == "services" && parts[i-2] == "teams" {
+			return filepath.Join(root, filepath.Join(parts[:i]...)), true
+		}
+	}
+	return "", false
+}
+
+func writeFile(path string, doc map[string]interface{}) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode decK file %s: %w", path, err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode decK file %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write decK file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const flightsSpec = `openapi: 3.0.3
+info:
+  title: Flights Service
+  version: 1.0.0
+servers:
+  - url: https://api.kong-air.com/v1
+x-kong-plugin-cors:
+  config:
+    origins: ["*"]
+x-kong-route-defaults:
+  strip_path: true
+paths:
+  /flights:
+    get:
+      operationId: get-flights
+    post:
+      x-kong-name: create flight
+      x-kong-plugin-rate-limiting:
+        config:
+          minute: 10
+  /flights/{flightNumber}/details:
+    x-kong-route-defaults:
+      preserve_host: true
+    get:
+      summary: no operation id
+`
+
+func TestOpenAPI2Kong(t *testing.T) {
+	doc, err := OpenAPI2Kong([]byte(flightsSpec), []string{"team-a"})
+	require.NoError(t, err)
+
+	services := doc["services"].([]interface{})
+	require.Len(t, services, 1)
+	service := services[0].(map[string]interface{})
+	assert.Equal(t, "flights-service", service["name"])
+	assert.Equal(t, "api.kong-air.com", service["host"])
+	assert.Equal(t, "/v1", service["path"])
+	assert.Equal(t, []interface{}{"team-a"}, service["tags"])
+
+	type route struct {
+		name          string
+		path          string
+		method        string
+		regexPriority int
+		stripPath     bool
+		plugins       []string
+	}
+	var routes []route
+	for _, r := range service["routes"].([]interface{}) {
+		r := r.(map[string]interface{})
+		got := route{
+			name:          r["name"].(string),
+			path:          r["paths"].([]interface{})[0].(string),
+			method:        r["methods"].([]interface{})[0].(string),
+			regexPriority: r["regex_priority"].(int),
+			stripPath:     r["strip_path"].(bool),
+		}
+		for _, p := range r["plugins"].([]interface{}) {
+			got.plugins = append(got.plugins, p.(map[string]interface{})["name"].(string))
+		}
+		routes = append(routes, got)
+	}
+	// like deck, paths with fewer parameters get a higher regex priority and path level route
+	// defaults replace the document level ones
+	assert.Equal(t, []route{
+		{name: "flights-service_get-flights", path: "~/flights$", method: "GET", regexPriority: 200, stripPath: true},
+		{
+			name: "flights-service_flights_create-flight", path: "~/flights$", method: "POST", regexPriority: 200,
+			stripPath: true, plugins: []string{"rate-limiting"},
+		},
+		{
+			name: "flights-service_flights-flightnumber-details_get", path: "~/flights/(?<flightnumber>[^#?/]+)/details$",
+			method: "GET", regexPriority: 100,
+		},
+	}, routes)
+}
+
+func TestOpenAPI2KongServers(t *testing.T) {
+	doc, err := OpenAPI2Kong([]byte(`openapi: 3.1.0
+info:
+  title: routes
+servers:
+  - url: http://{region}.routes.internal:8080/
+    variables:
+      region:
+        default: eu
+  - url: http://us.routes.internal:8080/
+paths:
+  /routes:
+    get:
+      operationId: list-routes
+  /legacy:
+    servers:
+      - url: https://legacy.routes.internal/v0
+    get:
+      operationId: list-legacy
+`), nil)
+	require.NoError(t, err)
+
+	services := doc["services"].([]interface{})
+	require.Len(t, services, 2)
+	service := services[0].(map[string]interface{})
+	assert.Equal(t, "routes.upstream", service["host"])
+	assert.Equal(t, 8080, service["port"])
+	upstream := doc["upstreams"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "routes.upstream", upstream["name"])
+	var targets []interface{}
+	for _, target := range upstream["targets"].([]interface{}) {
+		targets = append(targets, target.(map[string]interface{})["target"])
+	}
+	assert.Equal(t, []interface{}{"eu.routes.internal:8080", "us.routes.internal:8080"}, targets)
+
+	// a path with its own servers gets its own service
+	legacy := services[1].(map[string]interface{})
+	assert.Equal(t, "legacy.routes.internal", legacy["host"])
+	assert.Equal(t, "/v0", legacy["path"])
+	assert.Equal(t, "routes_list-legacy", legacy["routes"].([]interface{})[0].(map[string]interface{})["name"])
+}
+
+func TestOpenAPI2KongRejectsSwagger(t *testing.T) {
+	_, err := OpenAPI2Kong([]byte(`{"swagger": "2.0", "info": {"title": "x"}}`), nil)
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	merged, err := Merge(
+		map[string]interface{}{"_format_version": "3.0", "services": []interface{}{"a"}},
+		map[string]interface{}{"_format_version": "3.0", "services": []interface{}{"b"}, "upstreams": []interface{}{"u"}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"_format_version": "3.0",
+		"services":        []interface{}{"a", "b"},
+		"upstreams":       []interface{}{"u"},
+	}, merged)
+
+	_, err = Merge(
+		map[string]interface{}{"_format_version": "3.0"},
+		map[string]interface{}{"_format_version": "1.1"},
+	)
+	assert.Error(t, err)
+}
+
+func TestPlatform(t *testing.T) {
+	root := t.TempDir()
+	teamDir := filepath.Join(root, "kongair", "envs", "dev", "teams", "flight-data")
+	for _, svc := range []string{"flights", "routes"} {
+		dir := filepath.Join(teamDir, "services", svc)
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		spec := "openapi: 3.0.3\ninfo:\n  title: " + svc + "\npaths:\n  /" + svc + ":\n    get: {}\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, SpecFileName), []byte(spec), 0o600))
+	}
+	// a Swagger 2 spec can't be converted, the service is skipped
+	legacyDir := filepath.Join(teamDir, "services", "legacy")
+	require.NoError(t, os.MkdirAll(legacyDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(legacyDir, SpecFileName),
+		[]byte("swagger: \"2.0\"\ninfo:\n  title: legacy\npaths: {}\n"), 0o600))
+	patchFile := "_format_version: \"1.0\"\npatches:\n  - selectors: [\"$..services[*]\"]\n    values:\n      tags: [ko-api-name=flights-dev]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(teamDir, "services", "flights", PatchFileName), []byte(patchFile), 0o600))
+
+	written, err := Platform(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(teamDir, "services", "flights", ServiceDeckFileName),
+		filepath.Join(teamDir, "services", "routes", ServiceDeckFileName),
+		filepath.Join(teamDir, TeamDeckFileName),
+	}, written)
+
+	content, err := os.ReadFile(filepath.Join(teamDir, TeamDeckFileName))
+	require.NoError(t, err)
+	var merged map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(content, &merged))
+
+	services := merged["services"].([]interface{})
+	require.Len(t, services, 2)
+	assert.Equal(t, "flights", services[0].(map[string]interface{})["name"])
+	assert.Equal(t, []interface{}{"ko-api-name=flights-dev"}, services[0].(map[string]interface{})["tags"])
+	assert.Equal(t, "routes", services[1].(map[string]interface{})["name"])
+	assert.Empty(t, services[1].(map[string]interface{})["tags"])
+}
+
+func TestServiceOverlays(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "konnect")
+	envDir := filepath.Join(root, "kongair", "envs", "prod")
+	teamDir := filepath.Join(envDir, "teams", "flight-data")
+	serviceDir := filepath.Join(teamDir, "services", "KongAirlines", "flights")
+	require.NoError(t, os.MkdirAll(serviceDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDir, SpecFileName),
+		[]byte("openapi: 3.0.3\ninfo:\n  title: flights\npaths:\n  /flights:\n    get: {}\n"), 0o600))
+
+	writePatch := func(dir, name, values string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, PatchesDirName), 0o755))
+		content := "_format_version: \"1.0\"\npatches:\n  - selectors: [\"$..services[*]\"]\n    values:\n" + values
+		require.NoError(t, os.WriteFile(filepath.Join(dir, PatchesDirName, name), []byte(content), 0o600))
+	}
+	writePatch(root, "defaults.yaml", "      retries: 1\n      read_timeout: 1000\n      tags: [platform]\n")
+	writePatch(envDir, "prod.yaml", "      retries: 3\n")
+	writePatch(teamDir, "a.yaml", "      read_timeout: 2000\n")
+	writePatch(teamDir, "b.yaml", "      read_timeout: 3000\n")
+	writePatch(filepath.Join(teamDir, "services", "KongAirlines"), "owner.yaml", "      connect_timeout: 500\n")
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDir, PatchFileName),
+		[]byte("_format_version: \"1.0\"\npatches:\n  - selectors: [\"$..services[*]\"]\n    values:\n      tags: [ko-api-name=flights]\n"), 0o600))
+
+	overlays, err := Overlays(root, serviceDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, PatchesDirName, "defaults.yaml"),
+		filepath.Join(envDir, PatchesDirName, "prod.yaml"),
+		filepath.Join(teamDir, PatchesDirName, "a.yaml"),
+		filepath.Join(teamDir, PatchesDirName, "b.yaml"),
+		filepath.Join(teamDir, "services", "KongAirlines", PatchesDirName, "owner.yaml"),
+		filepath.Join(serviceDir, PatchFileName),
+	}, overlays)
+
+	written, err := Platform(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(serviceDir, ServiceDeckFileName),
+		filepath.Join(teamDir, TeamDeckFileName),
+	}, written)
+
+	content, err := os.ReadFile(filepath.Join(serviceDir, ServiceDeckFileName))
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(content, &doc))
+	svc := doc["services"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, 3, svc["retries"])
+	assert.Equal(t, 3000, svc["read_timeout"])
+	assert.Equal(t, 500, svc["connect_timeout"])
+	assert.Equal(t, []interface{}{"ko-api-name=flights"}, svc["tags"])
+}
+
+func TestServiceInvalidOverlay(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "konnect")
+	serviceDir := filepath.Join(root, "org", "envs", "dev", "teams", "t", "services", "s")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, PatchesDirName), 0o755))
+	require.NoError(t, os.MkdirAll(serviceDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDir, SpecFileName),
+		[]byte("openapi: 3.0.3\ninfo:\n  title: s\npaths: {}\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, PatchesDirName, "bad.yaml"),
+		[]byte("_format_version: \"1.0\"\npatches:\n  - selector: [\"$..services[*]\"]\n"), 0o600))
+
+	_, err := Service(serviceDir)
+	assert.ErrorContains(t, err, "bad.yaml")
+}
+
+func TestServiceRejectsRoutePluginShadowingPolicy(t *testing.T) {
+	serviceDir := filepath.Join(t.TempDir(), "konnect", "org", "envs", "prod", "teams", "t", "services", "s")
+	require.NoError(t, os.MkdirAll(serviceDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDir, SpecFileName), []byte(`openapi: 3.0.3
+info:
+  title: s
+paths:
+  /s:
+    get:
+      x-kong-plugin-rate-limiting:
+        config:
+          minute: 100000
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(serviceDir, PatchFileName), []byte(`_format_version: "1.0"
+patches:
+  - selectors: ["$..services[*][?(!@.plugins)]"]
+    values:
+      plugins: []
+  - selectors: ["$..services[*].plugins"]
+    values:
+      - name: rate-limiting
+        config:
+          minute: 100
+        tags: [ko-policy=rate-limiting]
+`), 0o600))
+
+	_, err := Service(serviceDir)
+	assert.ErrorContains(t, err, "configures the plugin rate-limiting enforced by a policy")
+}
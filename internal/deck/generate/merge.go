@@ -0,0 +1,30 @@
+package generate
+
+import (
+	"fmt"
+)
+
+// Merge combines decK files like `deck file merge`: the entity arrays of every file are concatenated in
+// order and other top level values are taken from the last file defining them. All files must use the
+// same _format_version.
+func Merge(files ...map[string]interface{}) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for i, f := range files {
+		for k, v := range f {
+			if k == "_format_version" {
+				if existing, ok := out[k]; ok && fmt.Sprint(existing) != fmt.Sprint(v) {
+					return nil, fmt.Errorf("file %d has _format_version %v, expected %v", i, v, existing)
+				}
+				out[k] = v
+				continue
+			}
+			if entities, ok := v.([]interface{}); ok {
+				existing, _ := out[k].([]interface{})
+				out[k] = append(existing, entities...)
+				continue
+			}
+			out[k] = v
+		}
+	}
+	return out, nil
+}
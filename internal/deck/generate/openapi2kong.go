@@ -0,0 +1,29 @@
+package generate
+
+import (
+	"fmt"
+
+	"github.com/kong/go-apiops/openapi2kong"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPI2Kong converts an OpenAPI 3 spec to a decK file with the converter of `deck file openapi2kong`,
+// using its defaults. The tags mark every generated entity, the spec's x-kong-tags are used when empty.
+func OpenAPI2Kong(content []byte, tags []string) (map[string]interface{}, error) {
+	converted, err := openapi2kong.Convert(content, openapi2kong.O2kOptions{Tags: tags})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert OpenAPI spec: %w", err)
+	}
+
+	// the converter builds the file from Go types (e.g. []string), patches and merges work on the generic
+	// values of a decoded decK file
+	b, err := yaml.Marshal(converted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode decK file: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode decK file: %w", err)
+	}
+	return doc, nil
+}
@@ -1,11 +1,260 @@
 package patch
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
 type Patch struct {
-	Selectors []string               `yaml:"selectors"`
-	Values    map[string]interface{} `yaml:"values"`
+	Selectors []string `yaml:"selectors"`
+	// Values is either an object merged into the selected objects or an array appended to the selected arrays
+	Values interface{} `yaml:"values,omitempty"`
+	// Remove lists the fields removed from the selected objects
+	Remove []string `yaml:"remove,omitempty"`
 }
 
 type File struct {
 	FormatVersion string  `yaml:"_format_version"`
 	Patches       []Patch `yaml:"patches"`
 }
+
+// FormatVersion is the decK patch file format supported by Apply
+const FormatVersion = "1.0"
+
+// Load reads and validates a decK patch file
+func Load(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch file %s: %w", path, err)
+	}
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse patch file %s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid patch file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Validate checks the file version and that every patch has selectors which compile and values or
+// fields to remove
+func (f *File) Validate() error {
+	if f.FormatVersion != FormatVersion {
+		return fmt.Errorf("unsupported _format_version %q, expected %q", f.FormatVersion, FormatVersion)
+	}
+	for i, p := range f.Patches {
+		if err := validateValues(p.Values, len(p.Remove) > 0); err != nil {
+			return fmt.Errorf("patch %d %w", i, err)
+		}
+		if object, ok := p.Values.(map[string]interface{}); ok {
+			for _, field := range p.Remove {
+				if _, ok := object[field]; ok {
+					return fmt.Errorf("patch %d both sets and removes %q", i, field)
+				}
+			}
+		}
+		if len(p.Selectors) == 0 {
+			return fmt.Errorf("patch %d has no selectors", i)
+		}
+		for _, s := range p.Selectors {
+			if _, err := yamlpath.NewPath(s); err != nil {
+				return fmt.Errorf("patch %d: invalid selector %q: %w", i, s, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateValues checks that values is a non-empty object or array, values may be omitted when the
+// patch removes fields
+func validateValues(values interface{}, removes bool) error {
+	switch v := values.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			return nil
+		}
+	case []map[string]interface{}:
+		if len(v) > 0 {
+			return nil
+		}
+	case nil:
+		if removes {
+			return nil
+		}
+	default:
+		return fmt.Errorf("values must be an object or an array")
+	}
+	return fmt.Errorf("has no values")
+}
+
+// Apply applies the patches in order to a decoded decK file, like `deck file patch`, evaluating the
+// selectors with the JSONPath implementation of decK. Object values of a patch are merged into every
+// object its selectors match following JSON merge patch rules: nested objects are merged, other values
+// replace the existing ones and null values remove the field. Array values are appended to every array
+// the selectors match, an object with a name replaces the element of the array with the same name so
+// patches can enforce a plugin once per entity. The fields listed in remove are deleted from every
+// selected object.
+func (f *File) Apply(doc map[string]interface{}) error {
+	var root yaml.Node
+	if err := root.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode decK file: %w", err)
+	}
+	for i, p := range f.Patches {
+		var values yaml.Node
+		if err := values.Encode(p.Values); err != nil {
+			return fmt.Errorf("invalid values in patch %d: %w", i, err)
+		}
+		for _, s := range p.Selectors {
+			path, err := yamlpath.NewPath(s)
+			if err != nil {
+				return fmt.Errorf("patch %d: invalid selector %q: %w", i, s, err)
+			}
+			nodes, err := path.Find(&root)
+			if err != nil {
+				return fmt.Errorf("patch %d: failed to evaluate selector %q: %w", i, s, err)
+			}
+			for _, n := range nodes {
+				switch n.Kind {
+				case yaml.MappingNode:
+					if values.Kind == yaml.MappingNode {
+						merge(n, &values)
+					}
+					for _, field := range p.Remove {
+						removeField(n, field)
+					}
+				case yaml.SequenceNode:
+					if values.Kind == yaml.SequenceNode {
+						appendItems(n, &values)
+					}
+				}
+			}
+		}
+	}
+
+	patched := map[string]interface{}{}
+	if err := root.Decode(&patched); err != nil {
+		return fmt.Errorf("failed to decode patched decK file: %w", err)
+	}
+	for k := range doc {
+		delete(doc, k)
+	}
+	for k, v := range patched {
+		doc[k] = v
+	}
+	return nil
+}
+
+// appendItems appends the items to the target sequence, replacing the elements with the same name
+func appendItems(target, items *yaml.Node) {
+	content := make([]*yaml.Node, 0, len(target.Content)+len(items.Content))
+	for _, t := range target.Content {
+		if name := nameOf(t); name != "" && containsName(items, name) {
+			continue
+		}
+		content = append(content, t)
+	}
+	for _, item := range items.Content {
+		content = append(content, deepCopy(item))
+	}
+	target.Content = content
+}
+
+func nameOf(n *yaml.Node) string {
+	if v := field(n, "name"); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+func containsName(items *yaml.Node, name string) bool {
+	for _, item := range items.Content {
+		if nameOf(item) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns the value of a key of a mapping node, nil when the node isn't a mapping or lacks the key
+func field(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeField deletes a key of a mapping node
+func removeField(n *yaml.Node, key string) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			n.Content = append(n.Content[:i], n.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// deepCopy copies a node so the same patch value isn't shared between entities
+func deepCopy(n *yaml.Node) *yaml.Node {
+	out := *n
+	out.Content = make([]*yaml.Node, 0, len(n.Content))
+	for _, c := range n.Content {
+		out.Content = append(out.Content, deepCopy(c))
+	}
+	return &out
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// merge applies a JSON merge patch to the target mapping node
+func merge(target, values *yaml.Node) {
+	for i := 0; i+1 < len(values.Content); i += 2 {
+		key, v := values.Content[i], values.Content[i+1]
+		index := -1
+		for j := 0; j+1 < len(target.Content); j += 2 {
+			if target.Content[j].Value == key.Value {
+				index = j
+				break
+			}
+		}
+
+		if isNull(v) {
+			removeField(target, key.Value)
+			continue
+		}
+		if v.Kind == yaml.MappingNode {
+			if index >= 0 && target.Content[index+1].Kind == yaml.MappingNode {
+				merge(target.Content[index+1], v)
+				continue
+			}
+			obj := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			merge(obj, v)
+			v = obj
+		} else {
+			v = deepCopy(v)
+		}
+		if index >= 0 {
+			target.Content[index+1] = v
+			continue
+		}
+		target.Content = append(target.Content, deepCopy(key), v)
+	}
+}
@@ -0,0 +1,218 @@
+package patch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    File
+		doc     map[string]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "sets values on every selected object",
+			file: File{Patches: []Patch{{
+				Selectors: []string{"$..services[*]"},
+				Values:    map[string]interface{}{"tags": []string{"ko-api-name=flights"}},
+			}}},
+			doc: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{"name": "a", "tags": []interface{}{"old"}},
+					map[string]interface{}{"name": "b"},
+				},
+			},
+			want: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{"name": "a", "tags": []interface{}{"ko-api-name=flights"}},
+					map[string]interface{}{"name": "b", "tags": []interface{}{"ko-api-name=flights"}},
+				},
+			},
+		},
+		{
+			name: "merges objects and removes null values",
+			file: File{Patches: []Patch{{
+				Selectors: []string{"$.services[?(@.name == 'a')]"},
+				Values: map[string]interface{}{
+					"retries": nil,
+					"client_certificate": map[string]interface{}{
+						"id": "cert",
+					},
+					"config": map[string]interface{}{"minute": 5},
+				},
+			}}},
+			doc: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":    "a",
+						"retries": 5,
+						"config":  map[string]interface{}{"hour": 100, "minute": 1},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":               "a",
+						"client_certificate": map[string]interface{}{"id": "cert"},
+						"config":             map[string]interface{}{"hour": 100, "minute": 5},
+					},
+				},
+			},
+		},
+		{
+			name: "appends to arrays and replaces elements with the same name",
+			file: File{Patches: []Patch{{
+				Selectors: []string{"$..services[*].plugins"},
+				Values: []interface{}{
+					map[string]interface{}{"name": "rate-limiting", "config": map[string]interface{}{"minute": 10}},
+					map[string]interface{}{"name": "prometheus"},
+				},
+			}}},
+			doc: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name": "a",
+						"plugins": []interface{}{
+							map[string]interface{}{"name": "cors"},
+							map[string]interface{}{"name": "rate-limiting", "config": map[string]interface{}{"minute": 1000}},
+						},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name": "a",
+						"plugins": []interface{}{
+							map[string]interface{}{"name": "cors"},
+							map[string]interface{}{"name": "rate-limiting", "config": map[string]interface{}{"minute": 10}},
+							map[string]interface{}{"name": "prometheus"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "removes fields without values",
+			file: File{Patches: []Patch{
+				{
+					Selectors: []string{"$..routes[*]"},
+					Remove:    []string{"regex_priority", "missing"},
+				},
+				{
+					Selectors: []string{"$..services[*]"},
+					Values:    map[string]interface{}{"retries": 1},
+					Remove:    []string{"read_timeout"},
+				},
+			}},
+			doc: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":         "a",
+						"read_timeout": 1000,
+						"routes": []interface{}{
+							map[string]interface{}{"name": "r", "regex_priority": 200},
+						},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":    "a",
+						"retries": 1,
+						"routes": []interface{}{
+							map[string]interface{}{"name": "r"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid selector",
+			file: File{Patches: []Patch{{
+				Selectors: []string{"$.services[?(@.name =="},
+				Values:    map[string]interface{}{"a": 1},
+			}}},
+			doc:     map[string]interface{}{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.file.Apply(tt.doc)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tt.doc)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid file",
+			content: "_format_version: \"1.0\"\npatches:\n  - selectors: [\"$..services[*]\"]\n    values:\n      retries: 3\n",
+		},
+		{
+			name:    "unsupported format version",
+			content: "_format_version: \"3.0\"\npatches: []\n",
+			wantErr: "unsupported _format_version",
+		},
+		{
+			name:    "unknown field",
+			content: "_format_version: \"1.0\"\npatches:\n  - selector: [\"$..services[*]\"]\n",
+			wantErr: "field selector not found",
+		},
+		{
+			name:    "missing values",
+			content: "_format_version: \"1.0\"\npatches:\n  - selectors: [\"$..services[*]\"]\n",
+			wantErr: "patch 0 has no values",
+		},
+		{
+			name:    "remove without values",
+			content: "_format_version: \"1.0\"\npatches:\n  - selectors: [\"$..routes[*]\"]\n    remove: [regex_priority]\n",
+		},
+		{
+			name:    "sets and removes the same field",
+			content: "_format_version: \"1.0\"\npatches:\n  - selectors: [\"$..services[*]\"]\n    values:\n      retries: 3\n    remove: [retries]\n",
+			wantErr: "both sets and removes \"retries\"",
+		},
+		{
+			name:    "invalid selector",
+			content: "_format_version: \"1.0\"\npatches:\n  - selectors: [\"$.services[?(@.name ==\"]\n    values:\n      retries: 3\n",
+			wantErr: "invalid selector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "patch.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			f, err := Load(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, f.Patches, 1)
+		})
+	}
+}
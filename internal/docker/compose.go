@@ -12,6 +12,28 @@ import (
 	"github.com/creack/pty"
 )
 
+// Credentials of the Gitea user of the local demo
+const (
+	LocalGitUsername = "koctl"
+	LocalGitPassword = "koctl-local"
+)
+
+// LocalEnvVars start the local profile of KoctlRunComposeFile, the API server manages the platform
+// repository seeded in the local Gitea server
+var LocalEnvVars = map[string]string{
+	"COMPOSE_PROFILES":           "local",
+	"PLATFORM_REPO_URL":          "http://gitea:3000/kong-air/platform.git",
+	"PLATFORM_REPO_PROVIDER":     "gitea",
+	"PLATFORM_REPO_API_URL":      "http://gitea:3000/api/v1",
+	"PLATFORM_REPO_USERNAME":     LocalGitUsername,
+	"PLATFORM_REPO_GITHUB_TOKEN": LocalGitPassword,
+	"LOCAL_GIT_USERNAME":         LocalGitUsername,
+	"LOCAL_GIT_PASSWORD":         LocalGitPassword,
+}
+
+// KoctlRunComposeFile runs the API server and the self service UI. The services of the local profile make up
+// an offline demo: a Gitea server seeded with an example platform repository and services, and a fake
+// Konnect API.
 const KoctlRunComposeFile = `services:
   koctl-api:
     image: ghcr.io/kong/koctl:latest
@@ -20,8 +42,18 @@ const KoctlRunComposeFile = `services:
     environment:
       - GITHUB_CLIENT_ID=${GITHUB_CLIENT_ID}
       - GITHUB_CLIENT_SECRET=${GITHUB_CLIENT_SECRET}
+      - GITHUB_URL=${GITHUB_URL:-}
+      - GITHUB_API_URL=${GITHUB_API_URL:-}
+      - GITHUB_UPLOAD_URL=${GITHUB_UPLOAD_URL:-}
       - PLATFORM_REPO_URL=${PLATFORM_REPO_URL}
       - PLATFORM_REPO_GITHUB_TOKEN=${PLATFORM_REPO_GITHUB_TOKEN}
+      - PLATFORM_REPO_PROVIDER=${PLATFORM_REPO_PROVIDER:-}
+      - PLATFORM_REPO_API_URL=${PLATFORM_REPO_API_URL:-}
+      - PLATFORM_REPO_USERNAME=${PLATFORM_REPO_USERNAME:-}
+      - PLATFORM_REPO_GITHUB_APP_ID=${PLATFORM_REPO_GITHUB_APP_ID:-}
+      - PLATFORM_REPO_GITHUB_APP_INSTALLATION_ID=${PLATFORM_REPO_GITHUB_APP_INSTALLATION_ID:-}
+      - PLATFORM_REPO_GITHUB_APP_PRIVATE_KEY=${PLATFORM_REPO_GITHUB_APP_PRIVATE_KEY:-}
+      - PLATFORM_REPO_BASE_BRANCH=${PLATFORM_REPO_BASE_BRANCH:-}
       - FRONTEND_URL=http://localhost:8081
       - GITHUB_REDIRECT_URI=http://localhost:8080/auth/github/callback
     command: ["run", "api"]
@@ -33,6 +65,60 @@ const KoctlRunComposeFile = `services:
       - VITE_API_BASE_URL=http://koctl-api:8080
     depends_on:
       - koctl-api
+  gitea:
+    image: gitea/gitea:1.22
+    profiles: ["local"]
+    ports:
+      - "3000:3000"
+    environment:
+      - GITEA__security__INSTALL_LOCK=true
+      - GITEA__server__ROOT_URL=http://localhost:3000/
+      - GITEA__service__DISABLE_REGISTRATION=true
+      - GITEA__actions__ENABLED=true
+    volumes:
+      - gitea-data:/data
+    healthcheck:
+      test: ["CMD", "curl", "-fsS", "http://localhost:3000/api/healthz"]
+      interval: 5s
+      retries: 24
+  gitea-admin:
+    image: gitea/gitea:1.22
+    profiles: ["local"]
+    user: git
+    environment:
+      - LOCAL_GIT_USERNAME=${LOCAL_GIT_USERNAME:-koctl}
+      - LOCAL_GIT_PASSWORD=${LOCAL_GIT_PASSWORD:-koctl-local}
+    volumes:
+      - gitea-data:/data
+    entrypoint:
+      - sh
+      - -c
+      - >-
+        gitea admin user list --config /data/gitea/conf/app.ini | grep -qw "$$LOCAL_GIT_USERNAME" ||
+        gitea admin user create --config /data/gitea/conf/app.ini --admin --must-change-password=false
+        --username "$$LOCAL_GIT_USERNAME" --password "$$LOCAL_GIT_PASSWORD" --email koctl@kong-air.local
+    depends_on:
+      gitea:
+        condition: service_healthy
+  konnect:
+    image: ghcr.io/kong/koctl:latest
+    profiles: ["local"]
+    ports:
+      - "8082:8082"
+    command: ["run", "konnect"]
+  koctl-seed:
+    image: ghcr.io/kong/koctl:latest
+    profiles: ["local"]
+    environment:
+      - PLATFORM_REPO_API_URL=http://gitea:3000/api/v1
+      - PLATFORM_REPO_USERNAME=${LOCAL_GIT_USERNAME:-koctl}
+      - PLATFORM_REPO_GITHUB_TOKEN=${LOCAL_GIT_PASSWORD:-koctl-local}
+    command: ["run", "seed", "--web-url", "http://localhost:3000"]
+    depends_on:
+      gitea-admin:
+        condition: service_completed_successfully
+volumes:
+  gitea-data:
 `
 
 type ComposeInstance struct {
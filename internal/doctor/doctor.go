@@ -0,0 +1,280 @@
+// Package doctor checks that the secrets of an orchestrator configuration resolve and that the credentials
+// they hold are accepted by Konnect and the git providers, without revealing their values.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/util"
+)
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	StatusOK Status = iota
+	StatusWarning
+	StatusFailure
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusWarning:
+		return "warning"
+	default:
+		return "failure"
+	}
+}
+
+// Result is the outcome of a check, its message never holds a secret value
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+}
+
+// GitHubScopes are the scopes of a classic GitHub token the orchestrator needs, repo to push to the
+// repositories and workflow to push the workflows of the platform repository
+var GitHubScopes = []string{"repo", "workflow"}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Options configure the checks
+type Options struct {
+	// KonnectServerURL is the global Konnect API
+	KonnectServerURL string
+}
+
+// Run checks the secrets of the platform repository, the service repositories and the organizations of a
+// configuration
+func Run(ctx context.Context, man *manifest.Orchestrator, opts Options) []Result {
+	var results []Result
+	if man.Platform != nil && man.Platform.Git != nil {
+		results = append(results, checkGitConfig(ctx, "platform repository", *man.Platform.Git)...)
+	}
+
+	for _, teamName := range sortedKeys(man.Teams) {
+		team := man.Teams[teamName]
+		if team == nil {
+			continue
+		}
+		for _, serviceName := range sortedKeys(team.Services) {
+			service := team.Services[serviceName]
+			if service == nil || service.Git == nil {
+				continue
+			}
+			results = append(results,
+				checkGitConfig(ctx, fmt.Sprintf("service %s/%s repository", teamName, serviceName), *service.Git)...)
+		}
+	}
+
+	for _, orgName := range sortedKeys(man.Organizations) {
+		org := man.Organizations[orgName]
+		if org == nil {
+			continue
+		}
+		name := fmt.Sprintf("organization %s access token", orgName)
+		token, result := checkSecret(name, org.AccessToken, false)
+		results = append(results, result)
+		if result.Status != StatusFailure {
+			results = append(results, checkKonnectToken(ctx, name, opts.KonnectServerURL, token))
+		}
+		if org.Authorization != nil && org.Authorization.OIDC != nil && org.Authorization.OIDC.Enabled {
+			_, result := checkSecret(fmt.Sprintf("organization %s OIDC client secret", orgName),
+				org.Authorization.OIDC.ClientSecret, false)
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Failed reports whether a check failed
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFailure {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSecret resolves a secret, warning when a single line secret like a token holds whitespace
+func checkSecret(name string, secret manifest.Secret, multiline bool) (string, Result) {
+	value, err := util.ResolveSecretValue(secret)
+	if err != nil {
+		return "", Result{Name: name, Status: StatusFailure, Message: err.Error()}
+	}
+	if value == "" {
+		return "", Result{Name: name, Status: StatusFailure, Message: fmt.Sprintf("%s secret is empty", secret.Type)}
+	}
+	if !multiline && strings.ContainsAny(value, " \t\r\n") {
+		return value, Result{Name: name, Status: StatusWarning,
+			Message: fmt.Sprintf("%s secret of %d characters holds whitespace", secret.Type, len(value))}
+	}
+	return value, Result{Name: name, Status: StatusOK,
+		Message: fmt.Sprintf("%s secret of %d characters resolved", secret.Type, len(value))}
+}
+
+// checkKonnectToken gets the organization of a Konnect token. The organization endpoint of the SDK always
+// calls the production API, the request is sent to the server URL instead, e.g. the fake Konnect API.
+func checkKonnectToken(ctx context.Context, name, serverURL, token string) Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(serverURL, "/")+"/v3/organizations/me", nil)
+	if err != nil {
+		return Result{Name: name, Status: StatusFailure, Message: err.Error()}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Result{Name: name, Status: StatusFailure, Message: fmt.Sprintf("failed to call Konnect: %v", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{Name: name, Status: StatusFailure,
+			Message: fmt.Sprintf("Konnect rejected the token with status %d", resp.StatusCode)}
+	}
+	var org struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&org); err != nil {
+		return Result{Name: name, Status: StatusFailure, Message: fmt.Sprintf("failed to decode the organization: %v", err)}
+	}
+	return Result{Name: name, Status: StatusOK, Message: fmt.Sprintf("valid Konnect token of organization %s", org.Name)}
+}
+
+// gitSecrets returns the configured secrets of a git configuration, by their configuration path
+func gitSecrets(gitConfig manifest.GitConfig) ([]string, map[string]*manifest.Secret) {
+	secrets := map[string]*manifest.Secret{}
+	if gitConfig.GitHub != nil {
+		secrets["github.token"] = gitConfig.GitHub.Token
+		if gitConfig.GitHub.App != nil {
+			secrets["github.app.private-key"] = gitConfig.GitHub.App.PrivateKey
+		}
+	}
+	if gitConfig.GitLab != nil {
+		secrets["gitlab.token"] = gitConfig.GitLab.Token
+	}
+	if gitConfig.Bitbucket != nil {
+		secrets["bitbucket.token"] = gitConfig.Bitbucket.Token
+	}
+	if gitConfig.AzureDevOps != nil {
+		secrets["azure-devops.token"] = gitConfig.AzureDevOps.Token
+	}
+	if gitConfig.Gitea != nil {
+		secrets["gitea.token"] = gitConfig.Gitea.Token
+	}
+	if gitConfig.Auth != nil {
+		secrets["auth.token"] = gitConfig.Auth.Token
+		if gitConfig.Auth.SSH != nil {
+			secrets["auth.ssh.key"] = gitConfig.Auth.SSH.Key
+			secrets["auth.ssh.passphrase"] = gitConfig.Auth.SSH.Passphrase
+			secrets["auth.ssh.known-hosts"] = gitConfig.Auth.SSH.KnownHosts
+		}
+	}
+	var paths []string
+	for path, secret := range secrets {
+		// the CI fallback configures an empty literal token when the token variable isn't set
+		if secret == nil || secret.Type == "literal" && secret.Value == "" {
+			delete(secrets, path)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, secrets
+}
+
+// checkGitConfig resolves the secrets of a git configuration, verifies the GitHub token and lists the
+// references of the remote
+func checkGitConfig(ctx context.Context, name string, gitConfig manifest.GitConfig) []Result {
+	var results []Result
+	failed := false
+	paths, secrets := gitSecrets(gitConfig)
+	for _, path := range paths {
+		multiline := path == "auth.ssh.key" || path == "auth.ssh.known-hosts" ||
+			path == "github.app.private-key"
+		_, result := checkSecret(name+" "+path, *secrets[path], multiline)
+		results = append(results, result)
+		failed = failed || result.Status == StatusFailure
+	}
+	if gitConfig.Remote == nil || *gitConfig.Remote == "" {
+		return append(results, Result{Name: name, Status: StatusWarning, Message: "no remote configured"})
+	}
+	if failed {
+		return results
+	}
+
+	creds := git.ProviderCredentials(gitConfig)
+	if git.ProviderName(gitConfig) == git.ProviderGitHub && creds.Configured() {
+		token, err := creds.Resolve()
+		switch {
+		case err != nil:
+			return append(results, Result{Name: name + " GitHub credentials", Status: StatusFailure, Message: err.Error()})
+		case creds.GitHubApp != nil:
+			results = append(results, Result{Name: name + " GitHub credentials", Status: StatusOK,
+				Message: "GitHub App installation token minted"})
+		default:
+			endpoints := github.ConfigEndpoints(git.GitHubConfig(gitConfig))
+			results = append(results, checkGitHubToken(ctx, name+" GitHub token", endpoints, token))
+		}
+	}
+
+	if err := git.CheckAccess(gitConfig); err != nil {
+		return append(results, Result{Name: name + " git access", Status: StatusFailure, Message: err.Error()})
+	}
+	return append(results, Result{Name: name + " git access", Status: StatusOK,
+		Message: fmt.Sprintf("listed the references of %s", *gitConfig.Remote)})
+}
+
+// checkGitHubToken gets the user of a GitHub token and verifies the scopes of classic tokens
+func checkGitHubToken(ctx context.Context, name string, endpoints github.Endpoints, token string) Result {
+	client, err := endpoints.TokenClient(ctx, token)
+	if err != nil {
+		return Result{Name: name, Status: StatusFailure, Message: err.Error()}
+	}
+	user, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return Result{Name: name, Status: StatusFailure, Message: fmt.Sprintf("GitHub rejected the token: %v", err)}
+	}
+
+	scopesHeader, classic := resp.Header["X-Oauth-Scopes"]
+	if !classic {
+		return Result{Name: name, Status: StatusOK,
+			Message: fmt.Sprintf("valid fine-grained token of %s, its permissions aren't verified", user.GetLogin())}
+	}
+	scopes := map[string]bool{}
+	for _, scope := range strings.Split(strings.Join(scopesHeader, ","), ",") {
+		scopes[strings.TrimSpace(scope)] = true
+	}
+	var missing []string
+	for _, scope := range GitHubScopes {
+		if !scopes[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return Result{Name: name, Status: StatusWarning,
+			Message: fmt.Sprintf("token of %s lacks the scopes %s", user.GetLogin(), strings.Join(missing, ", "))}
+	}
+	return Result{Name: name, Status: StatusOK, Message: fmt.Sprintf("valid token of %s", user.GetLogin())}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
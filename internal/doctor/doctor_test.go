@@ -0,0 +1,105 @@
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSecret(t *testing.T) {
+	t.Setenv("DOCTOR_TEST_TOKEN", "kpat_123")
+	verbatim := false
+	tests := []struct {
+		name      string
+		secret    manifest.Secret
+		multiline bool
+		want      Status
+	}{
+		{name: "token", secret: manifest.Secret{Type: "env", Value: "DOCTOR_TEST_TOKEN"}, want: StatusOK},
+		{name: "unset", secret: manifest.Secret{Type: "env", Value: "DOCTOR_TEST_UNSET"}, want: StatusFailure},
+		{name: "empty", secret: manifest.Secret{Type: "literal", Value: " \n"}, want: StatusFailure},
+		{name: "newline", secret: manifest.Secret{Type: "literal", Value: "kpat_123\n", Trim: &verbatim}, want: StatusWarning},
+		{
+			name:      "key",
+			secret:    manifest.Secret{Type: "literal", Value: "-----BEGIN KEY-----\nabc\n-----END KEY-----"},
+			multiline: true,
+			want:      StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, result := checkSecret(tt.name, tt.secret, tt.multiline)
+			assert.Equal(t, tt.want, result.Status, result.Message)
+			assert.NotContains(t, result.Message, "kpat_123")
+		})
+	}
+}
+
+func TestCheckKonnectToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/organizations/me" || r.Header.Get("Authorization") != "Bearer kpat_123" {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":401,"title":"Unauthorized","detail":"Invalid credentials"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"d4b7bb09-8c2f-4a5b-9d4a-4c3c1f4c2b1a","name":"Kong Air"}`))
+	}))
+	defer server.Close()
+
+	man := &manifest.Orchestrator{Organizations: map[string]*manifest.Organization{
+		"kong-air": {AccessToken: manifest.Secret{Type: "literal", Value: "kpat_123"}},
+		"revoked":  {AccessToken: manifest.Secret{Type: "literal", Value: "kpat_456"}},
+	}}
+	results := Run(context.Background(), man, Options{KonnectServerURL: server.URL})
+	require.Len(t, results, 4)
+	assert.Equal(t, StatusOK, results[1].Status)
+	assert.Equal(t, "valid Konnect token of organization Kong Air", results[1].Message)
+	assert.Equal(t, StatusFailure, results[3].Status)
+	assert.Equal(t, "organization revoked access token", results[3].Name)
+	assert.True(t, Failed(results))
+}
+
+func TestCheckGitHubToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer ghp_full":
+			w.Header().Set("X-OAuth-Scopes", "repo, workflow, read:org")
+		case "Bearer ghp_repo":
+			w.Header().Set("X-OAuth-Scopes", "repo")
+		case "Bearer github_pat_fine":
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"login":"platform-bot"}`))
+	}))
+	defer server.Close()
+	endpoints := github.NewEndpoints("", server.URL, "")
+
+	tests := []struct {
+		token   string
+		want    Status
+		message string
+	}{
+		{token: "ghp_full", want: StatusOK, message: "valid token of platform-bot"},
+		{token: "ghp_repo", want: StatusWarning, message: "token of platform-bot lacks the scopes workflow"},
+		{token: "github_pat_fine", want: StatusOK, message: "valid fine-grained token of platform-bot"},
+		{token: "ghp_revoked", want: StatusFailure, message: "GitHub rejected the token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			result := checkGitHubToken(context.Background(), "platform repository GitHub token", endpoints, tt.token)
+			assert.Equal(t, tt.want, result.Status)
+			assert.Contains(t, result.Message, tt.message)
+		})
+	}
+}
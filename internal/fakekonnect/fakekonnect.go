@@ -0,0 +1,219 @@
+// Package fakekonnect is an in-memory stand-in of the Konnect API for local demos and tests. Every path is a
+// collection of JSON objects: POST creates an object with a generated id, GET lists the objects, filtered with
+// filter[field] or filter[field][eq] query parameters and paged with page[size] and page[number], and GET, PUT, PATCH and DELETE of <collection>/<id>
+// read, replace, update and delete an object. Creating an object of an access-tokens collection returns a
+// generated token.
+package fakekonnect
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OrganizationName is the name of the organization returned by the /organizations/me endpoints
+const OrganizationName = "local"
+
+// Server is the fake Konnect API, it is safe for concurrent use
+type Server struct {
+	mu sync.Mutex
+	// collections maps the path of a collection to its objects by id
+	collections map[string]map[string]map[string]interface{}
+	// created numbers the objects by path in creation order
+	created map[string]int
+}
+
+// New creates a fake Konnect API holding a single organization and user
+func New() *Server {
+	s := &Server{collections: map[string]map[string]map[string]interface{}{}, created: map[string]int{}}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, version := range []string{"/v2", "/v3"} {
+		s.put(version+"/organizations", "me", map[string]interface{}{
+			"id": newID(), "name": OrganizationName, "state": "active", "created_at": now, "updated_at": now,
+		})
+		s.put(version+"/users", "me", map[string]interface{}{
+			"id": newID(), "email": "admin@konnect.local", "full_name": "Local Admin", "active": true,
+			"created_at": now, "updated_at": now,
+		})
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimRight(r.URL.Path, "/")
+	parent, id := path, ""
+	if i := strings.LastIndex(path, "/"); i > 0 {
+		parent, id = path[:i], path[i+1:]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		if obj, ok := s.collections[parent][id]; ok {
+			writeJSON(w, http.StatusOK, obj)
+			return
+		}
+		s.list(w, r, path)
+	case http.MethodPost:
+		obj, ok := readObject(w, r)
+		if !ok {
+			return
+		}
+		objID, _ := obj["id"].(string)
+		if objID == "" {
+			objID = newID()
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		obj["id"], obj["created_at"], obj["updated_at"] = objID, now, now
+		s.put(path, objID, obj)
+		// the value of an access token is only returned on creation
+		if strings.HasSuffix(path, "/access-tokens") {
+			created := map[string]interface{}{"token": "spat_" + strings.ReplaceAll(newID(), "-", "")}
+			for k, v := range obj {
+				created[k] = v
+			}
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+		writeJSON(w, http.StatusCreated, obj)
+	case http.MethodPut, http.MethodPatch:
+		obj, ok := readObject(w, r)
+		if !ok {
+			return
+		}
+		existing, found := s.collections[parent][id]
+		if !found && r.Method == http.MethodPatch {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		if r.Method == http.MethodPatch {
+			for k, v := range obj {
+				existing[k] = v
+			}
+			obj = existing
+		} else if found {
+			obj["created_at"] = existing["created_at"]
+		} else {
+			obj["created_at"] = now
+		}
+		obj["id"], obj["updated_at"] = id, now
+		s.put(parent, id, obj)
+		writeJSON(w, http.StatusOK, obj)
+	case http.MethodDelete:
+		if _, ok := s.collections[parent][id]; !ok {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		delete(s.collections[parent], id)
+		// the sub-collections of the object are deleted with it
+		for p := range s.collections {
+			if strings.HasPrefix(p, path+"/") {
+				delete(s.collections, p)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) put(collection, id string, obj map[string]interface{}) {
+	if s.collections[collection] == nil {
+		s.collections[collection] = map[string]map[string]interface{}{}
+	}
+	if _, ok := s.collections[collection][id]; !ok {
+		s.created[collection+"/"+id] = len(s.created)
+	}
+	s.collections[collection][id] = obj
+}
+
+// list writes a page of the objects of a collection matching the filters, in creation order. Without
+// page[size] all the objects are returned in a single page.
+func (s *Server) list(w http.ResponseWriter, r *http.Request, collection string) {
+	filters := map[string]string{}
+	for key, values := range r.URL.Query() {
+		if !strings.HasPrefix(key, "filter[") || len(values) == 0 {
+			continue
+		}
+		field := strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]")
+		field = strings.TrimSuffix(field, "][eq")
+		filters[field] = values[0]
+	}
+
+	data := []map[string]interface{}{}
+	for _, obj := range s.collections[collection] {
+		if matches(obj, filters) {
+			data = append(data, obj)
+		}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		return s.created[collection+"/"+fmt.Sprint(data[i]["id"])] < s.created[collection+"/"+fmt.Sprint(data[j]["id"])]
+	})
+	total, size, number := len(data), len(data), 1
+	if v, err := strconv.Atoi(r.URL.Query().Get("page[size]")); err == nil && v > 0 {
+		size = v
+		if v, err := strconv.Atoi(r.URL.Query().Get("page[number]")); err == nil && v > 0 {
+			number = v
+		}
+		start := min((number-1)*size, total)
+		data = data[start:min(start+size, total)]
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"meta": map[string]interface{}{
+			"page": map[string]int{"number": number, "size": size, "total": total},
+		},
+	})
+}
+
+func matches(obj map[string]interface{}, filters map[string]string) bool {
+	for field, value := range filters {
+		if fmt.Sprint(obj[field]) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func readObject(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	obj := map[string]interface{}{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return nil, false
+		}
+	}
+	return obj, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]interface{}{
+		"status": status,
+		"title":  http.StatusText(status),
+		"detail": detail,
+	})
+}
+
+// newID returns a random UUID
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
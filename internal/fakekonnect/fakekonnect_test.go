@@ -0,0 +1,83 @@
+package fakekonnect
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func call(t *testing.T, server *httptest.Server, method, path string, body interface{}) (int, map[string]interface{}) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var out map[string]interface{}
+	if resp.StatusCode != http.StatusNoContent {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	}
+	return resp.StatusCode, out
+}
+
+func TestServer(t *testing.T) {
+	server := httptest.NewServer(New())
+	defer server.Close()
+
+	status, cp := call(t, server, http.MethodPost, "/v2/control-planes", map[string]string{"name": "dev"})
+	require.Equal(t, http.StatusCreated, status)
+	id := cp["id"].(string)
+	assert.NotEmpty(t, id)
+	_, _ = call(t, server, http.MethodPost, "/v2/control-planes", map[string]string{"name": "prod"})
+
+	status, list := call(t, server, http.MethodGet, "/v2/control-planes?filter[name][eq]=dev", nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, list["data"], 1)
+	assert.Equal(t, id, list["data"].([]interface{})[0].(map[string]interface{})["id"])
+
+	var names []interface{}
+	for _, page := range []string{"1", "2", "3"} {
+		_, list = call(t, server, http.MethodGet, "/v2/control-planes?page[size]=1&page[number]="+page, nil)
+		for _, cp := range list["data"].([]interface{}) {
+			names = append(names, cp.(map[string]interface{})["name"])
+		}
+	}
+	assert.Equal(t, []interface{}{"dev", "prod"}, names)
+
+	status, updated := call(t, server, http.MethodPatch, "/v2/control-planes/"+id, map[string]string{"description": "d"})
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "dev", updated["name"])
+	assert.Equal(t, "d", updated["description"])
+
+	_, _ = call(t, server, http.MethodPost, "/v2/control-planes/"+id+"/core-entities/services", map[string]string{"name": "s"})
+	status, _ = call(t, server, http.MethodDelete, "/v2/control-planes/"+id, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	_, list = call(t, server, http.MethodGet, "/v2/control-planes/"+id+"/core-entities/services", nil)
+	assert.Empty(t, list["data"])
+
+	status, _ = call(t, server, http.MethodPatch, "/v2/control-planes/"+id, map[string]string{})
+	assert.Equal(t, http.StatusNotFound, status)
+
+	_, me := call(t, server, http.MethodGet, "/v3/organizations/me", nil)
+	assert.Equal(t, OrganizationName, me["name"])
+
+	status, token := call(t, server, http.MethodPost, "/v3/system-accounts/sa/access-tokens", map[string]string{"name": "t"})
+	require.Equal(t, http.StatusCreated, status)
+	assert.Contains(t, token["token"], "spat_")
+	_, list = call(t, server, http.MethodGet, "/v3/system-accounts/sa/access-tokens", nil)
+	require.Len(t, list["data"], 1)
+	assert.NotContains(t, list["data"].([]interface{})[0], "token")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/naming"
 	kk "github.com/Kong/sdk-konnect-go"
 	"github.com/Kong/sdk-konnect-go/models/components"
 	"github.com/Kong/sdk-konnect-go/models/operations"
@@ -28,14 +29,15 @@ type ControlPlaneService interface {
 func ApplyControlPlane(
 	ctx context.Context,
 	cpSvc ControlPlaneService,
+	names *naming.Templates,
+	orgName string,
 	envName string,
 	env manifest.Environment,
 	teamName string,
 ) (string, error) {
-	var cpName string
-	if env.Teams == nil || env.Teams[teamName].ControlPlaneName == nil {
-		// Control plane name follows convention: team-name-environment-name
-		cpName = fmt.Sprintf("%s-%s", teamName, envName)
+	cpName, err := ControlPlaneName(names, orgName, envName, env, teamName)
+	if err != nil {
+		return "", err
 	}
 
 	// Create labels map with both env and team labels
@@ -96,6 +98,26 @@ func ApplyControlPlane(
 	return cp.ID, nil
 }
 
+// ControlPlaneName returns the name of a team's control plane in an environment. An explicit control
+// plane name for the team in the environment wins over the naming template.
+func ControlPlaneName(
+	names *naming.Templates,
+	orgName string,
+	envName string,
+	env manifest.Environment,
+	teamName string,
+) (string, error) {
+	if teamEnv, ok := env.Teams[teamName]; ok && teamEnv != nil && teamEnv.ControlPlaneName != nil {
+		return *teamEnv.ControlPlaneName, nil
+	}
+	return names.ControlPlane(naming.Vars{
+		Org:     orgName,
+		Env:     envName,
+		EnvType: env.Type,
+		Team:    teamName,
+	})
+}
+
 // findControlPlane returns the control plane if it exists, nil if it doesn't
 func findControlPlane(ctx context.Context, cpSvc ControlPlaneService, name string) (*components.ControlPlane, error) {
 	resp, err := cpSvc.ListControlPlanes(ctx, operations.ListControlPlanesRequest{})
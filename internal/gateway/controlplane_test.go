@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/naming"
 	kk "github.com/Kong/sdk-konnect-go"
 	"github.com/Kong/sdk-konnect-go/models/components"
 	"github.com/Kong/sdk-konnect-go/models/operations"
@@ -16,6 +17,7 @@ import (
 func TestApplyControlPlane(t *testing.T) {
 	tests := []struct {
 		name       string
+		names      *naming.Templates
 		envName    string
 		env        manifest.Environment
 		teamName   string
@@ -25,6 +27,7 @@ func TestApplyControlPlane(t *testing.T) {
 	}{
 		{
 			name:    "creates new control plane with labels",
+			names:   naming.Default(),
 			envName: "DEV",
 			env: manifest.Environment{
 				Type:   "DEV",
@@ -35,7 +38,6 @@ func TestApplyControlPlane(t *testing.T) {
 				m.On("ListControlPlanes",
 					mock.Anything,
 					mock.Anything,
-					mock.Anything,
 				).Return(&operations.ListControlPlanesResponse{
 					ListControlPlanesResponse: &components.ListControlPlanesResponse{
 						Data: []components.ControlPlane{},
@@ -47,11 +49,10 @@ func TestApplyControlPlane(t *testing.T) {
 					mock.MatchedBy(func(req components.CreateControlPlaneRequest) bool {
 						return req.Name == "team1-DEV" &&
 							*req.Description == "Control plane for team team1 in environment DEV" &&
-							*req.ClusterType == components.CreateControlPlaneRequestClusterType("CLUSTER_TYPE_CONTROL_PLANE") &&
+							*req.ClusterType == components.CreateControlPlaneRequestClusterType("CLUSTER_TYPE_SERVERLESS") &&
 							req.Labels["env"] == "DEV" &&
 							req.Labels["team"] == "team1"
 					}),
-					mock.Anything,
 				).Return(
 					&operations.CreateControlPlaneResponse{
 						ControlPlane: &components.ControlPlane{
@@ -64,6 +65,7 @@ func TestApplyControlPlane(t *testing.T) {
 		},
 		{
 			name:    "updates existing control plane with new labels",
+			names:   naming.Default(),
 			envName: "PROD",
 			env: manifest.Environment{
 				Type:   "PROD",
@@ -74,7 +76,6 @@ func TestApplyControlPlane(t *testing.T) {
 				m.On("ListControlPlanes",
 					mock.Anything,
 					mock.Anything,
-					mock.Anything,
 				).Return(&operations.ListControlPlanesResponse{
 					ListControlPlanesResponse: &components.ListControlPlanesResponse{
 						Data: []components.ControlPlane{
@@ -96,7 +97,6 @@ func TestApplyControlPlane(t *testing.T) {
 							req.Labels["env"] == "PROD" &&
 							req.Labels["team"] == "team1"
 					}),
-					mock.Anything,
 				).Return(&operations.UpdateControlPlaneResponse{}, nil)
 			},
 			wantErr:    false,
@@ -104,6 +104,7 @@ func TestApplyControlPlane(t *testing.T) {
 		},
 		{
 			name:    "handles list error",
+			names:   naming.Default(),
 			envName: "DEV",
 			env: manifest.Environment{
 				Type:   "DEV",
@@ -111,11 +112,85 @@ func TestApplyControlPlane(t *testing.T) {
 			},
 			teamName: "team1",
 			setup: func(m *MockControlPlaneService) {
-				m.On("ListControlPlanes", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("list error"))
+				m.On("ListControlPlanes", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("list error"))
 			},
 			wantErr:    true,
 			expectedID: "",
 		},
+		{
+			name:    "uses the control plane name configured for the team",
+			names:   naming.Default(),
+			envName: "PROD",
+			env: manifest.Environment{
+				Type:   "PROD",
+				Region: "us",
+				Teams: map[string]*manifest.TeamEnvironment{
+					"team1": {ControlPlaneName: kk.String("flights-prod")},
+				},
+			},
+			teamName: "team1",
+			setup: func(m *MockControlPlaneService) {
+				m.On("ListControlPlanes",
+					mock.Anything,
+					mock.Anything,
+				).Return(&operations.ListControlPlanesResponse{
+					ListControlPlanesResponse: &components.ListControlPlanesResponse{
+						Data: []components.ControlPlane{},
+					},
+				}, nil)
+
+				m.On("CreateControlPlane",
+					mock.Anything,
+					mock.MatchedBy(func(req components.CreateControlPlaneRequest) bool {
+						return req.Name == "flights-prod"
+					}),
+				).Return(
+					&operations.CreateControlPlaneResponse{
+						ControlPlane: &components.ControlPlane{
+							ID: "new-cp-456",
+						},
+					}, nil)
+			},
+			wantErr:    false,
+			expectedID: "new-cp-456",
+		},
+		{
+			name: "uses the naming template",
+			names: func() *naming.Templates {
+				n, _ := naming.New(&manifest.Naming{ControlPlane: kk.String("{{.Org}}-{{lower .Env}}-{{.Team}}")})
+				return n
+			}(),
+			envName: "PROD",
+			env: manifest.Environment{
+				Type:   "PROD",
+				Region: "us",
+			},
+			teamName: "team1",
+			setup: func(m *MockControlPlaneService) {
+				m.On("ListControlPlanes",
+					mock.Anything,
+					mock.Anything,
+				).Return(&operations.ListControlPlanesResponse{
+					ListControlPlanesResponse: &components.ListControlPlanesResponse{
+						Data: []components.ControlPlane{},
+					},
+				}, nil)
+
+				m.On("CreateControlPlane",
+					mock.Anything,
+					mock.MatchedBy(func(req components.CreateControlPlaneRequest) bool {
+						return req.Name == "org1-prod-team1"
+					}),
+				).Return(
+					&operations.CreateControlPlaneResponse{
+						ControlPlane: &components.ControlPlane{
+							ID: "new-cp-789",
+						},
+					}, nil)
+			},
+			wantErr:    false,
+			expectedID: "new-cp-789",
+		},
 	}
 
 	for _, tt := range tests {
@@ -123,8 +198,7 @@ func TestApplyControlPlane(t *testing.T) {
 			mockCPSvc := &MockControlPlaneService{}
 			tt.setup(mockCPSvc)
 
-			fmt.Println(mockCPSvc, tt.envName, tt.env, tt.teamName)
-			id, err := ApplyControlPlane(context.Background(), mockCPSvc, tt.envName, tt.env, tt.teamName)
+			id, err := ApplyControlPlane(context.Background(), mockCPSvc, tt.names, "org1", tt.envName, tt.env, tt.teamName)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -0,0 +1,290 @@
+package azuredevops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the Azure DevOps Services REST API
+const DefaultAPIURL = "https://dev.azure.com"
+
+const (
+	apiVersion = "7.1"
+	// variable groups are a preview API
+	variableGroupsAPIVersion = "7.1-preview.2"
+)
+
+// ErrNotFound is returned when the Azure DevOps API responds with 404 Not Found
+var ErrNotFound = errors.New("not found")
+
+// Client calls the REST API of an Azure DevOps organization with a personal access token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the API of an organization, e.g. https://dev.azure.com/KongAirlines
+func NewClient(baseURL, organization, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/") + "/" + url.PathEscape(organization),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type Project struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+type Repository struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	DefaultBranch string  `json:"defaultBranch,omitempty"`
+	RemoteURL     string  `json:"remoteUrl,omitempty"`
+	WebURL        string  `json:"webUrl,omitempty"`
+	Project       Project `json:"project"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+type PullRequest struct {
+	ID            int     `json:"pullRequestId,omitempty"`
+	SourceRefName string  `json:"sourceRefName,omitempty"`
+	TargetRefName string  `json:"targetRefName,omitempty"`
+	Title         string  `json:"title,omitempty"`
+	Description   string  `json:"description,omitempty"`
+	Labels        []Label `json:"labels,omitempty"`
+}
+
+type Comment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     int    `json:"commentType"`
+}
+
+type Thread struct {
+	Comments []Comment `json:"comments"`
+	Status   int       `json:"status,omitempty"`
+}
+
+type Variable struct {
+	Value    *string `json:"value"`
+	IsSecret bool    `json:"isSecret"`
+}
+
+type ProjectReference struct {
+	Name             string  `json:"name"`
+	ProjectReference Project `json:"projectReference"`
+}
+
+// VariableGroup is a library variable group of Azure Pipelines
+type VariableGroup struct {
+	ID                             int                  `json:"id,omitempty"`
+	Name                           string               `json:"name"`
+	Type                           string               `json:"type"`
+	Variables                      map[string]*Variable `json:"variables"`
+	VariableGroupProjectReferences []ProjectReference   `json:"variableGroupProjectReferences"`
+}
+
+type list[T any] struct {
+	Value []T `json:"value"`
+}
+
+func (c *Client) do(ctx context.Context, method, path, version string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path+sep+"api-version="+version, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("", c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func repository(project, repo string) string {
+	return "/" + url.PathEscape(project) + "/_apis/git/repositories/" + url.PathEscape(repo)
+}
+
+// GetProject returns a project by name
+func (c *Client) GetProject(ctx context.Context, project string) (*Project, error) {
+	var p Project
+	if err := c.do(ctx, http.MethodGet, "/_apis/projects/"+url.PathEscape(project), apiVersion, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateRepository creates a repository without commits in a project
+func (c *Client) CreateRepository(ctx context.Context, project Project, name string) (*Repository, error) {
+	var r Repository
+	err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(project.Name)+"/_apis/git/repositories", apiVersion,
+		map[string]interface{}{"name": name, "project": map[string]string{"id": project.ID}}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// PushFile commits a file to a new branch of a repository, e.g. to initialize a new repository
+func (c *Client) PushFile(ctx context.Context, project, repo, branch, path, content, message string) error {
+	return c.do(ctx, http.MethodPost, repository(project, repo)+"/pushes", apiVersion, map[string]interface{}{
+		"refUpdates": []map[string]string{{
+			"name":        "refs/heads/" + branch,
+			"oldObjectId": "0000000000000000000000000000000000000000",
+		}},
+		"commits": []map[string]interface{}{{
+			"comment": message,
+			"changes": []map[string]interface{}{{
+				"changeType": "add",
+				"item":       map[string]string{"path": "/" + path},
+				"newContent": map[string]string{"content": content, "contentType": "rawtext"},
+			}},
+		}},
+	}, nil)
+}
+
+// ListRepositories returns the repositories of a project
+func (c *Client) ListRepositories(ctx context.Context, project string) ([]Repository, error) {
+	var l list[Repository]
+	if err := c.do(ctx, http.MethodGet, "/"+url.PathEscape(project)+"/_apis/git/repositories", apiVersion, nil, &l); err != nil {
+		return nil, err
+	}
+	return l.Value, nil
+}
+
+// FindPullRequest returns the active pull request of a source branch into a target branch, or nil
+func (c *Client) FindPullRequest(ctx context.Context, project, repo, source, target string) (*PullRequest, error) {
+	q := url.Values{}
+	q.Set("searchCriteria.sourceRefName", "refs/heads/"+source)
+	q.Set("searchCriteria.targetRefName", "refs/heads/"+target)
+	q.Set("searchCriteria.status", "active")
+	var l list[PullRequest]
+	if err := c.do(ctx, http.MethodGet, repository(project, repo)+"/pullrequests?"+q.Encode(), apiVersion, nil, &l); err != nil {
+		return nil, err
+	}
+	if len(l.Value) == 0 {
+		return nil, nil
+	}
+	return &l.Value[0], nil
+}
+
+// CreatePullRequest opens a pull request
+func (c *Client) CreatePullRequest(ctx context.Context, project, repo string, pr PullRequest) (*PullRequest, error) {
+	var created PullRequest
+	if err := c.do(ctx, http.MethodPost, repository(project, repo)+"/pullrequests", apiVersion, pr, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdatePullRequest sets the title and description of a pull request
+func (c *Client) UpdatePullRequest(ctx context.Context, project, repo string, pr PullRequest) (*PullRequest, error) {
+	var updated PullRequest
+	path := fmt.Sprintf("%s/pullrequests/%d", repository(project, repo), pr.ID)
+	body := PullRequest{Title: pr.Title, Description: pr.Description}
+	if err := c.do(ctx, http.MethodPatch, path, apiVersion, body, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// AddLabel adds a label, or tag, to a pull request
+func (c *Client) AddLabel(ctx context.Context, project, repo string, id int, label string) error {
+	path := fmt.Sprintf("%s/pullrequests/%d/labels", repository(project, repo), id)
+	return c.do(ctx, http.MethodPost, path, apiVersion, Label{Name: label}, nil)
+}
+
+// AddReviewer adds a user or group identity to the reviewers of a pull request
+func (c *Client) AddReviewer(ctx context.Context, project, repo string, id int, reviewerID string) error {
+	path := fmt.Sprintf("%s/pullrequests/%d/reviewers/%s", repository(project, repo), id, url.PathEscape(reviewerID))
+	return c.do(ctx, http.MethodPut, path, apiVersion, map[string]int{"vote": 0}, nil)
+}
+
+// ListThreads returns the comment threads of a pull request
+func (c *Client) ListThreads(ctx context.Context, project, repo string, id int) ([]Thread, error) {
+	var l list[Thread]
+	path := fmt.Sprintf("%s/pullrequests/%d/threads", repository(project, repo), id)
+	if err := c.do(ctx, http.MethodGet, path, apiVersion, nil, &l); err != nil {
+		return nil, err
+	}
+	return l.Value, nil
+}
+
+// CreateThread comments on a pull request
+func (c *Client) CreateThread(ctx context.Context, project, repo string, id int, content string) error {
+	path := fmt.Sprintf("%s/pullrequests/%d/threads", repository(project, repo), id)
+	return c.do(ctx, http.MethodPost, path, apiVersion, Thread{
+		Comments: []Comment{{Content: content, CommentType: 1}},
+		// active
+		Status: 1,
+	}, nil)
+}
+
+// FindVariableGroup returns a variable group of a project by name, or nil
+func (c *Client) FindVariableGroup(ctx context.Context, project, name string) (*VariableGroup, error) {
+	var l list[VariableGroup]
+	path := "/" + url.PathEscape(project) + "/_apis/distributedtask/variablegroups?groupName=" + url.QueryEscape(name)
+	if err := c.do(ctx, http.MethodGet, path, variableGroupsAPIVersion, nil, &l); err != nil {
+		return nil, err
+	}
+	if len(l.Value) == 0 {
+		return nil, nil
+	}
+	return &l.Value[0], nil
+}
+
+// CreateVariableGroup creates a variable group
+func (c *Client) CreateVariableGroup(ctx context.Context, group VariableGroup) error {
+	return c.do(ctx, http.MethodPost, "/_apis/distributedtask/variablegroups", variableGroupsAPIVersion, group, nil)
+}
+
+// UpdateVariableGroup replaces the variables of a variable group. Secret variables without value keep
+// their value.
+func (c *Client) UpdateVariableGroup(ctx context.Context, group VariableGroup) error {
+	path := fmt.Sprintf("/_apis/distributedtask/variablegroups/%d", group.ID)
+	return c.do(ctx, http.MethodPut, path, variableGroupsAPIVersion, group, nil)
+}
@@ -0,0 +1,179 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+)
+
+// MaxDescriptionLength is the longest pull request description accepted by Azure Repos
+const MaxDescriptionLength = 4000
+
+const truncatedSuffix = "\n\n_The description was truncated to the Azure Repos limit._"
+
+// ParsePath splits the path of an Azure Repos remote, org/project/_git/repo, into its organization,
+// project and repository
+func ParsePath(path string) (string, string, string, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || parts[2] != "_git" {
+		return "", "", "", fmt.Errorf("azure repos path %s isn't of the form organization/project/_git/repository", path)
+	}
+	return parts[0], parts[1], parts[3], nil
+}
+
+// CreateRepository creates a repository in a project with an initial commit on its main branch
+func CreateRepository(ctx context.Context, client *Client, project, repo, mainBranch string) error {
+	p, err := client.GetProject(ctx, project)
+	if err != nil {
+		return fmt.Errorf("failed to get project %s: %w", project, err)
+	}
+	if _, err := client.CreateRepository(ctx, *p, repo); err != nil {
+		return fmt.Errorf("failed to create repository %s/%s: %w", project, repo, err)
+	}
+	if err := client.PushFile(ctx, project, repo, mainBranch, "README.md", "# "+repo+"\n", "Initial commit"); err != nil {
+		return fmt.Errorf("failed to initialize repository %s/%s: %w", project, repo, err)
+	}
+	return nil
+}
+
+// CreateOrUpdatePullRequest opens a pull request of a branch or updates the active one, keeping the sections
+// of the description written by other runs and adding the missing labels
+func CreateOrUpdatePullRequest(ctx context.Context,
+	client *Client,
+	project, repo, branch, base, title, description string,
+	labels []string,
+) (*PullRequest, error) {
+	pr, err := client.FindPullRequest(ctx, project, repo, branch, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if pr == nil {
+		newPR := PullRequest{
+			SourceRefName: "refs/heads/" + branch,
+			TargetRefName: "refs/heads/" + base,
+			Title:         title,
+			Description:   truncate(description),
+		}
+		for _, l := range labels {
+			newPR.Labels = append(newPR.Labels, Label{Name: l})
+		}
+		pr, err = client.CreatePullRequest(ctx, project, repo, newPR)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pull request: %w", err)
+		}
+		return pr, nil
+	}
+
+	description = truncate(github.MergeBody(pr.Description, description))
+	if pr.Title != title || pr.Description != description {
+		existing := pr.Labels
+		pr, err = client.UpdatePullRequest(ctx, project, repo, PullRequest{ID: pr.ID, Title: title, Description: description})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update pull request: %w", err)
+		}
+		pr.Labels = existing
+	}
+	has := map[string]bool{}
+	for _, l := range pr.Labels {
+		has[l.Name] = true
+	}
+	for _, l := range labels {
+		if has[l] {
+			continue
+		}
+		if err := client.AddLabel(ctx, project, repo, pr.ID, l); err != nil {
+			// Just log the error but don't fail the pull request update
+			fmt.Printf("Warning: failed to add label to PR: %v\n", err)
+		}
+	}
+	return pr, nil
+}
+
+// CommentOnPullRequest comments on the active pull request of a branch, unless a comment containing the
+// marker already exists. Nothing is commented when the branch has no active pull request.
+func CommentOnPullRequest(ctx context.Context, client *Client, project, repo, branch, base, marker, body string) error {
+	pr, err := client.FindPullRequest(ctx, project, repo, branch, base)
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if pr == nil {
+		return nil
+	}
+
+	threads, err := client.ListThreads(ctx, project, repo, pr.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+	for _, t := range threads {
+		for _, c := range t.Comments {
+			if strings.Contains(c.Content, marker) {
+				return nil
+			}
+		}
+	}
+	if err := client.CreateThread(ctx, project, repo, pr.ID, marker+"\n"+body); err != nil {
+		return fmt.Errorf("failed to comment on pull request: %w", err)
+	}
+	return nil
+}
+
+// RequestReviewers adds user and group identities, given by id, to the reviewers of a pull request
+func RequestReviewers(ctx context.Context, client *Client, project, repo string, id int, users, teams []string) error {
+	for _, reviewer := range append(append([]string{}, users...), teams...) {
+		if err := client.AddReviewer(ctx, project, repo, id, reviewer); err != nil {
+			return fmt.Errorf("failed to request review from %s: %w", reviewer, err)
+		}
+	}
+	return nil
+}
+
+// SetVariable creates or updates a secret variable of a variable group of the project, creating the group
+// when it doesn't exist. Pipelines read the variables by linking the group.
+func SetVariable(ctx context.Context, client *Client, project, group, name, value string) error {
+	g, err := client.FindVariableGroup(ctx, project, group)
+	if err != nil {
+		return fmt.Errorf("failed to get variable group %s: %w", group, err)
+	}
+	if g == nil {
+		p, err := client.GetProject(ctx, project)
+		if err != nil {
+			return fmt.Errorf("failed to get project %s: %w", project, err)
+		}
+		err = client.CreateVariableGroup(ctx, VariableGroup{
+			Name:      group,
+			Type:      "Vsts",
+			Variables: map[string]*Variable{name: {Value: &value, IsSecret: true}},
+			VariableGroupProjectReferences: []ProjectReference{{
+				Name:             group,
+				ProjectReference: Project{ID: p.ID, Name: p.Name},
+			}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create variable group %s: %w", group, err)
+		}
+		return nil
+	}
+
+	if g.Variables == nil {
+		g.Variables = map[string]*Variable{}
+	}
+	g.Variables[name] = &Variable{Value: &value, IsSecret: true}
+	if err := client.UpdateVariableGroup(ctx, *g); err != nil {
+		return fmt.Errorf("failed to update variable group %s: %w", group, err)
+	}
+	return nil
+}
+
+func truncate(description string) string {
+	if len(description) <= MaxDescriptionLength {
+		return description
+	}
+	end := MaxDescriptionLength - len(truncatedSuffix)
+	for end > 0 && !utf8.RuneStart(description[end]) {
+		end--
+	}
+	return description[:end] + truncatedSuffix
+}
@@ -0,0 +1,148 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+// newServer records the requests and answers them with the responses keyed by method and path
+func newServer(t *testing.T, responses map[string]interface{}) (*Client, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, token, _ := r.BasicAuth()
+		assert.Equal(t, "token", token)
+		assert.NotEmpty(t, r.URL.Query().Get("api-version"))
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: string(body)})
+
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "org", "token"), &requests
+}
+
+func decode(t *testing.T, body string) map[string]interface{} {
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &m))
+	return m
+}
+
+func TestParsePath(t *testing.T) {
+	org, project, repo, err := ParsePath("KongAirlines/apis/_git/platform")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"KongAirlines", "apis", "platform"}, []string{org, project, repo})
+
+	_, _, _, err = ParsePath("KongAirlines/platform")
+	assert.Error(t, err)
+}
+
+func TestCreateOrUpdatePullRequest(t *testing.T) {
+	prs := "/org/apis/_apis/git/repositories/platform/pullrequests"
+
+	t.Run("creates a pull request with labels", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + prs:  list[PullRequest]{},
+			"POST " + prs: PullRequest{ID: 9},
+		})
+
+		pr, err := CreateOrUpdatePullRequest(context.Background(), client,
+			"apis", "platform", "dev-apply", "main", "title", "body", []string{"a"})
+		require.NoError(t, err)
+		assert.Equal(t, 9, pr.ID)
+		require.Len(t, *requests, 2)
+		assert.Contains(t, (*requests)[0].Query, "searchCriteria.sourceRefName=refs%2Fheads%2Fdev-apply")
+		body := decode(t, (*requests)[1].Body)
+		assert.Equal(t, "refs/heads/main", body["targetRefName"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "a"}}, body["labels"])
+	})
+
+	t.Run("updates the active pull request and adds missing labels", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + prs:                list[PullRequest]{Value: []PullRequest{{ID: 4, Title: "title", Description: "body", Labels: []Label{{Name: "a"}}}}},
+			"PATCH " + prs + "/4":       PullRequest{ID: 4},
+			"POST " + prs + "/4/labels": Label{},
+		})
+
+		_, err := CreateOrUpdatePullRequest(context.Background(), client,
+			"apis", "platform", "dev-apply", "main", "title", "new", []string{"a", "b"})
+		require.NoError(t, err)
+		require.Len(t, *requests, 3)
+		assert.Equal(t, "new", decode(t, (*requests)[1].Body)["description"])
+		assert.Equal(t, map[string]interface{}{"name": "b"}, decode(t, (*requests)[2].Body))
+	})
+
+	t.Run("long descriptions are truncated", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + prs:  list[PullRequest]{},
+			"POST " + prs: PullRequest{ID: 9},
+		})
+
+		_, err := CreateOrUpdatePullRequest(context.Background(), client,
+			"apis", "platform", "dev-apply", "main", "title", strings.Repeat("é", MaxDescriptionLength), nil)
+		require.NoError(t, err)
+		description := decode(t, (*requests)[1].Body)["description"].(string)
+		assert.LessOrEqual(t, len(description), MaxDescriptionLength)
+		assert.True(t, strings.HasSuffix(description, truncatedSuffix))
+	})
+}
+
+func TestSetVariable(t *testing.T) {
+	groups := "/org/apis/_apis/distributedtask/variablegroups"
+
+	t.Run("creates the variable group", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + groups:                                  list[VariableGroup]{},
+			"GET /org/_apis/projects/apis":                   Project{ID: "p1", Name: "apis"},
+			"POST /org/_apis/distributedtask/variablegroups": VariableGroup{},
+		})
+		require.NoError(t, SetVariable(context.Background(), client, "apis", "platform", "TOKEN", "v"))
+		require.Len(t, *requests, 3)
+		body := decode(t, (*requests)[2].Body)
+		assert.Equal(t, map[string]interface{}{"TOKEN": map[string]interface{}{"value": "v", "isSecret": true}}, body["variables"])
+	})
+
+	t.Run("keeps the other variables of the group", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + groups: list[VariableGroup]{Value: []VariableGroup{{
+				ID: 3, Name: "platform", Variables: map[string]*Variable{"OTHER": {IsSecret: true}},
+			}}},
+			"PUT /org/_apis/distributedtask/variablegroups/3": VariableGroup{},
+		})
+		require.NoError(t, SetVariable(context.Background(), client, "apis", "platform", "TOKEN", "v"))
+		require.Len(t, *requests, 2)
+		variables := decode(t, (*requests)[1].Body)["variables"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"value": nil, "isSecret": true}, variables["OTHER"])
+		assert.Equal(t, map[string]interface{}{"value": "v", "isSecret": true}, variables["TOKEN"])
+	})
+}
+
+func TestCreateRepository(t *testing.T) {
+	client, requests := newServer(t, map[string]interface{}{
+		"GET /org/_apis/projects/apis":                          Project{ID: "p1", Name: "apis"},
+		"POST /org/apis/_apis/git/repositories":                 Repository{ID: "r1"},
+		"POST /org/apis/_apis/git/repositories/platform/pushes": map[string]string{},
+	})
+	require.NoError(t, CreateRepository(context.Background(), client, "apis", "platform", "main"))
+	require.Len(t, *requests, 3)
+	assert.Contains(t, (*requests)[2].Body, `"name":"refs/heads/main"`)
+}
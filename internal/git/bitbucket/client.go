@@ -0,0 +1,268 @@
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the Bitbucket Cloud REST API
+const DefaultAPIURL = "https://api.bitbucket.org/2.0"
+
+// ErrNotFound is returned when the Bitbucket API responds with 404 Not Found
+var ErrNotFound = errors.New("not found")
+
+// Client calls the Bitbucket Cloud REST API 2.0 with an access token, or an app password of a user
+type Client struct {
+	baseURL    string
+	username   string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the Bitbucket API at baseURL. Without username, the token is sent as a
+// bearer token.
+func NewClient(baseURL, username, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		username:   username,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type Branch struct {
+	Name string `json:"name"`
+}
+
+type Ref struct {
+	Branch Branch `json:"branch"`
+}
+
+type Link struct {
+	Href string `json:"href"`
+	Name string `json:"name,omitempty"`
+}
+
+type Links struct {
+	HTML  Link   `json:"html"`
+	Clone []Link `json:"clone,omitempty"`
+}
+
+type PullRequest struct {
+	ID          int    `json:"id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Source      Ref    `json:"source"`
+	Destination Ref    `json:"destination"`
+	// CloseSourceBranch deletes the branch once the pull request is merged
+	CloseSourceBranch bool    `json:"close_source_branch,omitempty"`
+	Reviewers         []User  `json:"reviewers,omitempty"`
+	Links             *Links  `json:"links,omitempty"`
+	State             *string `json:"state,omitempty"`
+}
+
+// User is a Bitbucket account, identified by its account id or UUID
+type User struct {
+	UUID      string `json:"uuid,omitempty"`
+	AccountID string `json:"account_id,omitempty"`
+}
+
+type Content struct {
+	Raw string `json:"raw"`
+}
+
+type Comment struct {
+	ID      int     `json:"id,omitempty"`
+	Content Content `json:"content"`
+}
+
+// Variable is a repository variable of Bitbucket Pipelines
+type Variable struct {
+	UUID    string `json:"uuid,omitempty"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Secured bool   `json:"secured"`
+}
+
+type Repository struct {
+	Name       string  `json:"name"`
+	FullName   string  `json:"full_name"`
+	IsPrivate  bool    `json:"is_private"`
+	Links      Links   `json:"links"`
+	MainBranch *Branch `json:"mainbranch,omitempty"`
+}
+
+// page is a page of a paginated list, Next is the URL of the next page
+type page[T any] struct {
+	Values []T    `json:"values"`
+	Next   string `json:"next"`
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = c.baseURL + path
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, body, out interface{}) error {
+	if body == nil {
+		return c.do(ctx, method, path, "", nil, out)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, method, path, "application/json", bytes.NewReader(data), out)
+}
+
+// list reads every page of a paginated list
+func list[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	var all []T
+	for path != "" {
+		var p page[T]
+		if err := c.doJSON(ctx, http.MethodGet, path, nil, &p); err != nil {
+			return nil, err
+		}
+		all = append(all, p.Values...)
+		path = p.Next
+	}
+	return all, nil
+}
+
+func repository(workspace, slug string) string {
+	return "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(slug)
+}
+
+// CreateRepository creates a private repository in a workspace, the repository has no commits
+func (c *Client) CreateRepository(ctx context.Context, workspace, slug string) error {
+	return c.doJSON(ctx, http.MethodPost, repository(workspace, slug), map[string]interface{}{
+		"scm":        "git",
+		"is_private": true,
+	}, nil)
+}
+
+// CommitFile commits a file to a branch, e.g. to initialize a new repository
+func (c *Client) CommitFile(ctx context.Context, workspace, slug, branch, path, content, message string) error {
+	form := url.Values{}
+	form.Set(path, content)
+	form.Set("message", message)
+	form.Set("branch", branch)
+	return c.do(ctx, http.MethodPost, repository(workspace, slug)+"/src",
+		"application/x-www-form-urlencoded", strings.NewReader(form.Encode()), nil)
+}
+
+// ListRepositories returns the repositories of a workspace
+func (c *Client) ListRepositories(ctx context.Context, workspace string) ([]Repository, error) {
+	return list[Repository](ctx, c, "/repositories/"+url.PathEscape(workspace)+"?pagelen=100")
+}
+
+// FindPullRequest returns the open pull request of a source branch into a destination branch, or nil
+func (c *Client) FindPullRequest(ctx context.Context, workspace, slug, source, destination string) (*PullRequest, error) {
+	q := url.Values{}
+	q.Set("q", fmt.Sprintf(`source.branch.name="%s" AND destination.branch.name="%s" AND state="OPEN"`,
+		source, destination))
+	var p page[PullRequest]
+	if err := c.doJSON(ctx, http.MethodGet, repository(workspace, slug)+"/pullrequests?"+q.Encode(), nil, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Values) == 0 {
+		return nil, nil
+	}
+	return &p.Values[0], nil
+}
+
+// CreatePullRequest opens a pull request
+func (c *Client) CreatePullRequest(ctx context.Context, workspace, slug string, pr PullRequest) (*PullRequest, error) {
+	var created PullRequest
+	if err := c.doJSON(ctx, http.MethodPost, repository(workspace, slug)+"/pullrequests", pr, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdatePullRequest sets the title, description and reviewers of a pull request
+func (c *Client) UpdatePullRequest(ctx context.Context, workspace, slug string, pr PullRequest) (*PullRequest, error) {
+	var updated PullRequest
+	path := fmt.Sprintf("%s/pullrequests/%d", repository(workspace, slug), pr.ID)
+	if err := c.doJSON(ctx, http.MethodPut, path, pr, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// GetPullRequest returns a pull request by id
+func (c *Client) GetPullRequest(ctx context.Context, workspace, slug string, id int) (*PullRequest, error) {
+	var pr PullRequest
+	path := fmt.Sprintf("%s/pullrequests/%d", repository(workspace, slug), id)
+	if err := c.doJSON(ctx, http.MethodGet, path, nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// ListComments returns the comments of a pull request
+func (c *Client) ListComments(ctx context.Context, workspace, slug string, id int) ([]Comment, error) {
+	return list[Comment](ctx, c, fmt.Sprintf("%s/pullrequests/%d/comments?pagelen=100", repository(workspace, slug), id))
+}
+
+// CreateComment comments on a pull request
+func (c *Client) CreateComment(ctx context.Context, workspace, slug string, id int, body string) error {
+	path := fmt.Sprintf("%s/pullrequests/%d/comments", repository(workspace, slug), id)
+	return c.doJSON(ctx, http.MethodPost, path, Comment{Content: Content{Raw: body}}, nil)
+}
+
+// ListVariables returns the Pipelines variables of a repository, the values of secured variables are omitted
+func (c *Client) ListVariables(ctx context.Context, workspace, slug string) ([]Variable, error) {
+	return list[Variable](ctx, c, repository(workspace, slug)+"/pipelines_config/variables/?pagelen=100")
+}
+
+// CreateVariable creates a Pipelines variable of a repository
+func (c *Client) CreateVariable(ctx context.Context, workspace, slug string, v Variable) error {
+	return c.doJSON(ctx, http.MethodPost, repository(workspace, slug)+"/pipelines_config/variables/", v, nil)
+}
+
+// UpdateVariable updates a Pipelines variable of a repository by UUID
+func (c *Client) UpdateVariable(ctx context.Context, workspace, slug string, v Variable) error {
+	path := repository(workspace, slug) + "/pipelines_config/variables/" + url.PathEscape(v.UUID)
+	return c.doJSON(ctx, http.MethodPut, path, v, nil)
+}
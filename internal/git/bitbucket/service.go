@@ -0,0 +1,151 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+)
+
+// CreateRepository creates a repository with an initial commit on its main branch
+func CreateRepository(ctx context.Context, client *Client, workspace, slug, mainBranch string) error {
+	if err := client.CreateRepository(ctx, workspace, slug); err != nil {
+		return fmt.Errorf("failed to create repository %s/%s: %w", workspace, slug, err)
+	}
+	err := client.CommitFile(ctx, workspace, slug, mainBranch, "README.md", "# "+slug+"\n", "Initial commit")
+	if err != nil {
+		return fmt.Errorf("failed to initialize repository %s/%s: %w", workspace, slug, err)
+	}
+	return nil
+}
+
+// CreateOrUpdatePullRequest opens a pull request of a branch or updates the open one, keeping the sections
+// of the description written by other runs. Bitbucket pull requests have no labels.
+func CreateOrUpdatePullRequest(ctx context.Context,
+	client *Client,
+	workspace, slug, branch, base, title, description string,
+) (*PullRequest, error) {
+	pr, err := client.FindPullRequest(ctx, workspace, slug, branch, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if pr == nil {
+		pr, err = client.CreatePullRequest(ctx, workspace, slug, PullRequest{
+			Title:             title,
+			Description:       description,
+			Source:            Ref{Branch: Branch{Name: branch}},
+			Destination:       Ref{Branch: Branch{Name: base}},
+			CloseSourceBranch: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pull request: %w", err)
+		}
+		return pr, nil
+	}
+
+	description = github.MergeBody(pr.Description, description)
+	if pr.Title == title && pr.Description == description {
+		return pr, nil
+	}
+	pr, err = client.UpdatePullRequest(ctx, workspace, slug, PullRequest{
+		ID:          pr.ID,
+		Title:       title,
+		Description: description,
+		Destination: pr.Destination,
+		Source:      pr.Source,
+		Reviewers:   pr.Reviewers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update pull request: %w", err)
+	}
+	return pr, nil
+}
+
+// CommentOnPullRequest comments on the open pull request of a branch, unless a comment containing the
+// marker already exists. Nothing is commented when the branch has no open pull request.
+func CommentOnPullRequest(ctx context.Context, client *Client, workspace, slug, branch, base, marker, body string) error {
+	pr, err := client.FindPullRequest(ctx, workspace, slug, branch, base)
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if pr == nil {
+		return nil
+	}
+
+	comments, err := client.ListComments(ctx, workspace, slug, pr.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+	for _, c := range comments {
+		if strings.Contains(c.Content.Raw, marker) {
+			return nil
+		}
+	}
+	if err := client.CreateComment(ctx, workspace, slug, pr.ID, marker+"\n"+body); err != nil {
+		return fmt.Errorf("failed to comment on pull request: %w", err)
+	}
+	return nil
+}
+
+// RequestReviewers adds users, given by account id or {UUID}, to the reviewers of a pull request. Bitbucket
+// has no team reviewers, default reviewers of the repository should be used instead.
+func RequestReviewers(ctx context.Context, client *Client, workspace, slug string, id int, users, teams []string) error {
+	if len(users) > 0 {
+		pr, err := client.GetPullRequest(ctx, workspace, slug, id)
+		if err != nil {
+			return fmt.Errorf("failed to get pull request: %w", err)
+		}
+		reviewers := pr.Reviewers
+		for _, u := range users {
+			reviewer := User{AccountID: u}
+			if strings.HasPrefix(u, "{") {
+				reviewer = User{UUID: u}
+			}
+			if !hasReviewer(reviewers, reviewer) {
+				reviewers = append(reviewers, reviewer)
+			}
+		}
+		_, err = client.UpdatePullRequest(ctx, workspace, slug, PullRequest{
+			ID:          pr.ID,
+			Title:       pr.Title,
+			Description: pr.Description,
+			Source:      pr.Source,
+			Destination: pr.Destination,
+			Reviewers:   reviewers,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to request reviewers: %w", err)
+		}
+	}
+	if len(teams) > 0 {
+		return fmt.Errorf("team reviewers %s aren't supported by Bitbucket pull requests, "+
+			"use the default reviewers of the repository instead", strings.Join(teams, ", "))
+	}
+	return nil
+}
+
+// SetVariable creates or updates a secured Pipelines variable of a repository
+func SetVariable(ctx context.Context, client *Client, workspace, slug, key, value string) error {
+	variables, err := client.ListVariables(ctx, workspace, slug)
+	if err != nil {
+		return fmt.Errorf("failed to list pipelines variables: %w", err)
+	}
+	v := Variable{Key: key, Value: value, Secured: true}
+	for _, existing := range variables {
+		if existing.Key == key {
+			v.UUID = existing.UUID
+			return client.UpdateVariable(ctx, workspace, slug, v)
+		}
+	}
+	return client.CreateVariable(ctx, workspace, slug, v)
+}
+
+func hasReviewer(reviewers []User, user User) bool {
+	for _, r := range reviewers {
+		if (user.UUID != "" && r.UUID == user.UUID) || (user.AccountID != "" && r.AccountID == user.AccountID) {
+			return true
+		}
+	}
+	return false
+}
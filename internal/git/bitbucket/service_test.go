@@ -0,0 +1,137 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+// newServer records the requests and answers them with the responses keyed by method and path
+func newServer(t *testing.T, responses map[string]interface{}) (*Client, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: string(body)})
+
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/2.0", "", "token"), &requests
+}
+
+func decode(t *testing.T, body string) map[string]interface{} {
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &m))
+	return m
+}
+
+func TestCreateOrUpdatePullRequest(t *testing.T) {
+	prs := "/2.0/repositories/ws/platform/pullrequests"
+
+	t.Run("creates a pull request", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + prs:  page[PullRequest]{},
+			"POST " + prs: PullRequest{ID: 7},
+		})
+
+		pr, err := CreateOrUpdatePullRequest(context.Background(), client, "ws", "platform", "dev-apply", "main", "title", "body")
+		require.NoError(t, err)
+		assert.Equal(t, 7, pr.ID)
+		require.Len(t, *requests, 2)
+		assert.Contains(t, (*requests)[0].Query, "source.branch.name")
+		body := decode(t, (*requests)[1].Body)
+		assert.Equal(t, map[string]interface{}{"branch": map[string]interface{}{"name": "dev-apply"}}, body["source"])
+		assert.Equal(t, map[string]interface{}{"branch": map[string]interface{}{"name": "main"}}, body["destination"])
+	})
+
+	t.Run("updates the open pull request", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + prs:        page[PullRequest]{Values: []PullRequest{{ID: 3, Title: "title", Description: "body"}}},
+			"PUT " + prs + "/3": PullRequest{ID: 3},
+		})
+
+		_, err := CreateOrUpdatePullRequest(context.Background(), client, "ws", "platform", "dev-apply", "main", "title", "new")
+		require.NoError(t, err)
+		require.Len(t, *requests, 2)
+		assert.Equal(t, "new", decode(t, (*requests)[1].Body)["description"])
+	})
+
+	t.Run("unchanged pull request isn't updated", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + prs: page[PullRequest]{Values: []PullRequest{{ID: 3, Title: "title", Description: "body"}}},
+		})
+
+		_, err := CreateOrUpdatePullRequest(context.Background(), client, "ws", "platform", "dev-apply", "main", "title", "body")
+		require.NoError(t, err)
+		assert.Len(t, *requests, 1)
+	})
+}
+
+func TestSetVariable(t *testing.T) {
+	variables := "/2.0/repositories/ws/platform/pipelines_config/variables/"
+
+	client, requests := newServer(t, map[string]interface{}{
+		"GET " + variables:  page[Variable]{Values: []Variable{{UUID: "{1}", Key: "OTHER"}}},
+		"POST " + variables: Variable{},
+	})
+	require.NoError(t, SetVariable(context.Background(), client, "ws", "platform", "TOKEN", "v"))
+	require.Len(t, *requests, 2)
+	assert.Equal(t, map[string]interface{}{"key": "TOKEN", "value": "v", "secured": true}, decode(t, (*requests)[1].Body))
+
+	client, requests = newServer(t, map[string]interface{}{
+		"GET " + variables:         page[Variable]{Values: []Variable{{UUID: "{1}", Key: "TOKEN"}}},
+		"PUT " + variables + "{1}": Variable{},
+	})
+	require.NoError(t, SetVariable(context.Background(), client, "ws", "platform", "TOKEN", "v"))
+	require.Len(t, *requests, 2)
+	assert.Equal(t, http.MethodPut, (*requests)[1].Method)
+}
+
+func TestListRepositories(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			_ = json.NewEncoder(w).Encode(page[Repository]{Values: []Repository{{Name: "b"}}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(page[Repository]{
+			Values: []Repository{{Name: "a"}},
+			Next:   serverURL + r.URL.Path + "?page=2",
+		})
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	repos, err := NewClient(server.URL, "", "token").ListRepositories(context.Background(), "ws")
+	require.NoError(t, err)
+	assert.Equal(t, []Repository{{Name: "a"}, {Name: "b"}}, repos)
+}
+
+func TestCreateRepository(t *testing.T) {
+	client, requests := newServer(t, map[string]interface{}{
+		"POST /2.0/repositories/ws/platform":     map[string]string{},
+		"POST /2.0/repositories/ws/platform/src": map[string]string{},
+	})
+	require.NoError(t, CreateRepository(context.Background(), client, "ws", "platform", "main"))
+	require.Len(t, *requests, 2)
+	assert.Contains(t, (*requests)[1].Body, "branch=main")
+}
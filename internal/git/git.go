@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Kong/konnect-orchestrator/internal/manifest"
@@ -16,6 +18,9 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 // GetAuthMethod returns an ssh.AuthMethod based on the git config, or nil if no auth is specified
@@ -24,26 +29,21 @@ func GetAuthMethod(gitConfig manifest.GitConfig) (transport.AuthMethod, error) {
 	// supersede the git and github configuration presented here. This allows
 	// koctl to run within a GitHub action even if the user has a configuration file that reads
 	// secrets from local secrets files.
-	// check if GITHUB_TOKEN is set
-	tok, ghTokFound := os.LookupEnv("GITHUB_TOKEN")
-	if ghTokFound {
-		basicAuth := &http.BasicAuth{
-			Username: "x-access-token",
-			Password: tok,
-		}
-		return basicAuth, nil
-	} else if gitConfig.Auth == nil {
-		// by default, we can use GitHub auth for git auth which can simplify the configuration required
-		if gitConfig.GitHub == nil || gitConfig.GitHub.Token == nil {
-			return nil, errors.New("no auth configured. Must specify either auth or github with token value")
+	// The same applies to the token variables of the other git providers, e.g. GITLAB_TOKEN in a GitLab CI pipeline.
+	creds := ProviderCredentials(gitConfig)
+	if _, tokFound := os.LookupEnv(creds.EnvVar); tokFound || gitConfig.Auth == nil {
+		// by default, we can use the git provider token for git auth which can simplify the configuration required
+		if !tokFound && !creds.Configured() {
+			return nil, errors.New("no auth configured. Must specify either auth or the git provider with token value")
 		}
-		key, err := util.ResolveSecretValue(*gitConfig.GitHub.Token)
+		// GitHub App installation tokens expire after an hour and are minted again on each call
+		tok, err := creds.Resolve()
 		if err != nil {
 			return nil, err
 		}
 		basicAuth := &http.BasicAuth{
-			Username: "x-access-token",
-			Password: key,
+			Username: creds.Username,
+			Password: tok,
 		}
 		return basicAuth, nil
 	}
@@ -55,22 +55,14 @@ func GetAuthMethod(gitConfig manifest.GitConfig) (transport.AuthMethod, error) {
 
 	// Only support SSH auth for now
 	if *gitConfig.Auth.Type == "ssh" {
-		key, err := util.ResolveSecretValue(*gitConfig.Auth.SSH.Key)
-		if err != nil {
-			return nil, err
-		}
-		publicKeys, err := ssh.NewPublicKeys("git", []byte(key), "")
-		if err != nil {
-			return nil, err
-		}
-		return publicKeys, nil
+		return sshAuthMethod(gitConfig.Auth.SSH)
 	} else if *gitConfig.Auth.Type == "token" {
 		key, err := util.ResolveSecretValue(*gitConfig.Auth.Token)
 		if err != nil {
 			return nil, err
 		}
 		basicAuth := &http.BasicAuth{
-			Username: "x-access-token",
+			Username: creds.Username,
 			Password: key,
 		}
 		return basicAuth, nil
@@ -79,25 +71,67 @@ func GetAuthMethod(gitConfig manifest.GitConfig) (transport.AuthMethod, error) {
 	return nil, errors.New("unsupported auth type: " + *gitConfig.Auth.Type)
 }
 
-func GetRemoteFile(gitConfig manifest.GitConfig, branch, path string) ([]byte, error) {
-	auth, err := GetAuthMethod(gitConfig)
+// sshAuthMethod returns the auth of an SSH key, decrypted with its passphrase, verifying the host key of the
+// remote against the configured known hosts
+func sshAuthMethod(sshConfig *manifest.SSHConfig) (transport.AuthMethod, error) {
+	if sshConfig == nil || sshConfig.Key == nil {
+		return nil, errors.New("ssh auth requires an ssh key")
+	}
+	key, err := util.ResolveSecretValue(*sshConfig.Key)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to resolve ssh key: %w", err)
+	}
+	passphrase := ""
+	if sshConfig.Passphrase != nil {
+		if passphrase, err = util.ResolveSecretValue(*sshConfig.Passphrase); err != nil {
+			return nil, fmt.Errorf("failed to resolve ssh key passphrase: %w", err)
+		}
+	}
+	publicKeys, err := ssh.NewPublicKeys("git", []byte(key), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key: %w", err)
 	}
 
-	tempDir, err := os.MkdirTemp("", "repo-*")
+	if sshConfig.KnownHosts != nil {
+		knownHosts, err := util.ResolveSecretValue(*sshConfig.KnownHosts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve ssh known hosts: %w", err)
+		}
+		if publicKeys.HostKeyCallback, err = knownHostsCallback(knownHosts); err != nil {
+			return nil, err
+		}
+	}
+	return publicKeys, nil
+}
+
+// knownHostsCallback verifies host keys against the lines of a known_hosts file
+func knownHostsCallback(knownHosts string) (gossh.HostKeyCallback, error) {
+	f, err := os.CreateTemp("", "known_hosts-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(knownHosts + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return nil, err
 	}
+	// the known hosts are read once, the file isn't needed afterwards
+	callback, err := knownhosts.New(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh known hosts: %w", err)
+	}
+	return callback, nil
+}
 
-	_, err = git.PlainClone(tempDir, false, &git.CloneOptions{
-		URL:           *gitConfig.Remote,
-		Auth:          auth,
-		ReferenceName: plumbing.NewBranchReferenceName(branch),
-	})
+func GetRemoteFile(gitConfig manifest.GitConfig, branch, path string) ([]byte, error) {
+	tempDir, err := CloneBranch(gitConfig, branch)
 	if err != nil {
 		return nil, err
 	}
+	defer os.RemoveAll(tempDir)
 
 	data, err := os.ReadFile(filepath.Join(tempDir, path))
 	if err != nil {
@@ -106,6 +140,48 @@ func GetRemoteFile(gitConfig manifest.GitConfig, branch, path string) ([]byte, e
 	return data, nil
 }
 
+// CheckAccess lists the references of the remote of a git configuration, verifying its credentials
+func CheckAccess(gitConfig manifest.GitConfig) error {
+	auth, err := GetAuthMethod(gitConfig)
+	if err != nil {
+		return err
+	}
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{*gitConfig.Remote},
+	})
+	if _, err := remote.List(&git.ListOptions{Auth: auth}); err != nil {
+		return fmt.Errorf("failed to list the references of %s: %w", *gitConfig.Remote, err)
+	}
+	return nil
+}
+
+// CloneBranch clones a single branch of a git repository into a temporary directory and
+// returns the directory path. The caller is responsible for removing the directory.
+func CloneBranch(gitConfig manifest.GitConfig, branch string) (string, error) {
+	auth, err := GetAuthMethod(gitConfig)
+	if err != nil {
+		return "", err
+	}
+
+	tempDir, err := os.MkdirTemp("", "repo-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = git.PlainClone(tempDir, false, &git.CloneOptions{
+		URL:           *gitConfig.Remote,
+		Auth:          auth,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		SingleBranch:  true,
+	})
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return "", err
+	}
+	return tempDir, nil
+}
+
 // CloneInto clones a git repository into the specified directory
 func CloneInto(gitConfig manifest.GitConfig, dir string) error {
 	auth, err := GetAuthMethod(gitConfig)
@@ -154,6 +230,45 @@ func IsClean(dir string) (bool, error) {
 	return status.IsClean(), nil
 }
 
+// HeadCommit returns the hash of the commit checked out in a repository
+func HeadCommit(dir string) (string, error) {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return "", err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// ChangedFiles returns the sorted paths, relative to the repository root, of the files which are
+// added, modified or deleted in the worktree
+func ChangedFiles(dir string) ([]string, error) {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, err
+	}
+	workTree, err := r.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := workTree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(status))
+	for path, s := range status {
+		if s.Worktree != git.Unmodified || s.Staging != git.Unmodified {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
 func Branch(dir string, branch string) error {
 	r, err := git.PlainOpen(dir)
 	if err != nil {
@@ -222,7 +337,26 @@ func Commit(dir string, message string, author manifest.Author) error {
 	return nil
 }
 
+// pushAttempts is the number of times a push rejected because the remote branch moved is tried
+const pushAttempts = 3
+
+// ErrConflict is returned by Push when the remote branch moved and changed files of the pushed commit.
+// The changes have to be made again on top of the remote branch, e.g. by applying them again.
+var ErrConflict = errors.New("the remote branch changed the same files")
+
+// Push pushes the checked out branch without overwriting the commits of the remote branch. When the push
+// is rejected because the remote branch moved, the last commit is replayed on top of the remote branch and
+// the push is retried, unless the remote branch changed the same files (see ErrConflict).
 func Push(dir string, gitConfig manifest.GitConfig) error {
+	return push(dir, gitConfig, false)
+}
+
+// ForcePush pushes the checked out branch, replacing the remote branch
+func ForcePush(dir string, gitConfig manifest.GitConfig) error {
+	return push(dir, gitConfig, true)
+}
+
+func push(dir string, gitConfig manifest.GitConfig, force bool) error {
 	r, err := git.PlainOpen(dir)
 	if err != nil {
 		return err
@@ -231,19 +365,283 @@ func Push(dir string, gitConfig manifest.GitConfig) error {
 	if err != nil {
 		return err
 	}
-	// push using default options
-	err = r.Push(&git.PushOptions{
-		Auth:       auth,
-		Force:      true,
-		RemoteName: "origin",
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+	if !head.Name().IsBranch() {
+		return fmt.Errorf("failed to push: no branch is checked out")
+	}
+	branch := head.Name()
+
+	for attempt := 1; ; attempt++ {
+		err = r.Push(&git.PushOptions{
+			Auth:       auth,
+			Force:      force,
+			RemoteName: "origin",
+			RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", branch, branch))},
+		})
+		if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil
+		}
+		if force || !isRejected(err) || attempt == pushAttempts {
+			return err
+		}
+		if err := rebase(r, auth, branch.Short()); err != nil {
+			if errors.Is(err, ErrConflict) {
+				return err
+			}
+			return fmt.Errorf("failed to rebase on remote branch %s: %w", branch.Short(), err)
+		}
+	}
+}
+
+func isRejected(err error) bool {
+	return errors.Is(err, git.ErrForceNeeded) ||
+		strings.Contains(err.Error(), "non-fast-forward") ||
+		strings.Contains(err.Error(), "fetch first")
+}
+
+// rebase replays the last commit of the checked out branch on top of the remote branch. The files changed
+// by the commit take the content they have in the commit, the other files the content of the remote branch.
+// The contents of a file changed on both sides aren't merged, ErrConflict is returned instead.
+func rebase(r *git.Repository, auth transport.AuthMethod, branch string) error {
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return err
+	}
+
+	err = r.Fetch(&git.FetchOptions{
+		Auth: auth,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)),
+		},
+		Force: true,
 	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch remote branch: %w", err)
+	}
+	remoteRef, err := r.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	if err != nil {
+		return err
+	}
+	if err := checkConflicts(r, parentTree, remoteRef.Hash(), changes); err != nil {
+		return err
+	}
+
+	w, err := r.Worktree()
 	if err != nil {
 		return err
 	}
+	if err := w.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.HardReset}); err != nil {
+		return err
+	}
 
+	for _, change := range changes {
+		from, to, err := change.Files()
+		if err != nil {
+			return err
+		}
+		if from != nil && (to == nil || to.Name != from.Name) {
+			err := os.Remove(filepath.Join(w.Filesystem.Root(), from.Name))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if to == nil {
+			continue
+		}
+		content, err := to.Contents()
+		if err != nil {
+			return err
+		}
+		mode, err := to.Mode.ToOSFileMode()
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(w.Filesystem.Root(), to.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), mode.Perm()); err != nil {
+			return err
+		}
+	}
+
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+	if status.IsClean() {
+		// The remote branch already has the changes of the commit
+		return nil
+	}
+	_, err = w.Commit(commit.Message, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  commit.Author.Name,
+			Email: commit.Author.Email,
+			When:  time.Now(),
+		},
+	})
+	return err
+}
+
+// checkConflicts returns ErrConflict when the remote commit changed files of changes since base, unless
+// it made the same change
+func checkConflicts(r *git.Repository, base *object.Tree, remote plumbing.Hash, changes object.Changes) error {
+	remoteCommit, err := r.CommitObject(remote)
+	if err != nil {
+		return err
+	}
+	remoteTree, err := remoteCommit.Tree()
+	if err != nil {
+		return err
+	}
+	remoteChanges, err := object.DiffTree(base, remoteTree)
+	if err != nil {
+		return err
+	}
+	remoteChanged := map[string]plumbing.Hash{}
+	for _, c := range remoteChanges {
+		if c.From.Name != "" {
+			remoteChanged[c.From.Name] = plumbing.ZeroHash
+		}
+		if c.To.Name != "" {
+			remoteChanged[c.To.Name] = c.To.TreeEntry.Hash
+		}
+	}
+
+	var conflicts []string
+	for _, c := range changes {
+		for _, name := range []string{c.From.Name, c.To.Name} {
+			remoteHash, ok := remoteChanged[name]
+			if name == "" || !ok {
+				continue
+			}
+			// a file removed on one side has the zero hash, both sides may have made the same change
+			hash := plumbing.ZeroHash
+			if name == c.To.Name {
+				hash = c.To.TreeEntry.Hash
+			}
+			if remoteHash != hash {
+				conflicts = append(conflicts, name)
+				break
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrConflict, strings.Join(conflicts, ", "))
+	}
 	return nil
 }
 
+// ForeignCommits returns the commits of the checked out branch, which aren't on the remote base branch,
+// authored by someone else than the given email. Merges of the base branch into the orchestrator's commits,
+// e.g. by the "Update branch" button of the forge, aren't foreign. The commits are formatted as
+// "<hash> <author>: <subject>".
+func ForeignCommits(dir, base, email string) ([]string, error) {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	baseRef, err := r.Reference(plumbing.NewRemoteReferenceName("origin", base), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find base branch %s: %w", base, err)
+	}
+	baseCommit, err := r.CommitObject(baseRef.Hash())
+	if err != nil {
+		return nil, err
+	}
+	// the commits of the base branch, merging the base branch brings in more than the merge base
+	onBase := map[plumbing.Hash]bool{}
+	err = object.NewCommitPreorderIter(baseCommit, nil, nil).ForEach(func(c *object.Commit) error {
+		onBase[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	owned := map[plumbing.Hash]bool{}
+	// ownedBy reports whether a commit was authored with the email or merges the base branch into one
+	var ownedBy func(c *object.Commit) (bool, error)
+	ownedBy = func(c *object.Commit) (bool, error) {
+		if o, ok := owned[c.Hash]; ok {
+			return o, nil
+		}
+		o := strings.EqualFold(c.Author.Email, email)
+		if !o && c.NumParents() == 2 {
+			for i, p := range c.ParentHashes {
+				if !onBase[p] {
+					continue
+				}
+				other, err := c.Parent(1 - i)
+				if err != nil {
+					return false, err
+				}
+				if o, err = ownedBy(other); err != nil {
+					return false, err
+				}
+				if o {
+					break
+				}
+			}
+		}
+		owned[c.Hash] = o
+		return o, nil
+	}
+
+	var commits []string
+	err = object.NewCommitPreorderIter(headCommit, onBase, nil).ForEach(func(c *object.Commit) error {
+		o, err := ownedBy(c)
+		if err != nil {
+			return err
+		}
+		if !o {
+			subject, _, _ := strings.Cut(c.Message, "\n")
+			commits = append(commits, fmt.Sprintf("%s %s: %s", c.Hash.String()[:7], c.Author.Name, subject))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
 func CheckoutBranch(dir string, branch string, gitConfig manifest.GitConfig) error {
 	auth, err := GetAuthMethod(gitConfig)
 	if err != nil {
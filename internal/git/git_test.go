@@ -0,0 +1,274 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// newRemote creates a bare repository with a main branch holding one commit
+func newRemote(t *testing.T) manifest.GitConfig {
+	remote := t.TempDir()
+	_, err := git.PlainInitWithOptions(remote, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+		Bare:        true,
+	})
+	require.NoError(t, err)
+
+	seed := t.TempDir()
+	r, err := git.PlainInitWithOptions(seed, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.Main},
+	})
+	require.NoError(t, err)
+	_, err = r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}})
+	require.NoError(t, err)
+	commitFile(t, seed, "README.md", "platform", "platform@example.com")
+	require.NoError(t, r.Push(&git.PushOptions{RemoteName: "origin"}))
+
+	return manifest.GitConfig{Remote: &remote, Auth: &manifest.AuthConfig{}}
+}
+
+func commitFile(t *testing.T, dir, name, content, email string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	r, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	w, err := r.Worktree()
+	require.NoError(t, err)
+	_, err = w.Add(name)
+	require.NoError(t, err)
+	_, err = w.Commit("update "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: email, Email: email, When: time.Now()},
+	})
+	require.NoError(t, err)
+}
+
+func checkout(t *testing.T, cfg manifest.GitConfig, branch string) string {
+	dir := t.TempDir()
+	require.NoError(t, CloneInto(cfg, dir))
+	require.NoError(t, CheckoutBranch(dir, branch, cfg))
+	return dir
+}
+
+func TestPushRebasesOnRemoteChanges(t *testing.T) {
+	cfg := newRemote(t)
+	first := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	second := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+
+	commitFile(t, first, "fixup.yaml", "human", "dev@example.com")
+	require.NoError(t, Push(first, cfg))
+
+	commitFile(t, second, "kong.yaml", "generated", "bot@example.com")
+	require.NoError(t, Push(second, cfg))
+
+	result := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	for _, name := range []string{"README.md", "fixup.yaml", "kong.yaml"} {
+		assert.FileExists(t, filepath.Join(result, name))
+	}
+
+	foreign, err := ForeignCommits(result, "main", "bot@example.com")
+	require.NoError(t, err)
+	require.Len(t, foreign, 1)
+	assert.Contains(t, foreign[0], "dev@example.com: update fixup.yaml")
+}
+
+func TestForeignCommits(t *testing.T) {
+	cfg := newRemote(t)
+	dir := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+
+	foreign, err := ForeignCommits(dir, "main", "bot@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+
+	commitFile(t, dir, "kong.yaml", "generated", "BOT@example.com")
+	foreign, err = ForeignCommits(dir, "main", "bot@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+
+	_, err = ForeignCommits(dir, "release", "bot@example.com")
+	assert.ErrorContains(t, err, "failed to find base branch release")
+}
+
+func TestForeignCommitsSkipsBaseBranchMerges(t *testing.T) {
+	cfg := newRemote(t)
+	dir := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	commitFile(t, dir, "kong.yaml", "generated", "bot@example.com")
+
+	// the base branch moves on and the forge merges it into the pull request branch
+	mainDir := t.TempDir()
+	require.NoError(t, CloneInto(cfg, mainDir))
+	commitFile(t, mainDir, "CODEOWNERS", "* @platform", "platform@example.com")
+	mainRepo, err := git.PlainOpen(mainDir)
+	require.NoError(t, err)
+	require.NoError(t, mainRepo.Push(&git.PushOptions{RemoteName: "origin"}))
+
+	r, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	require.NoError(t, r.Fetch(&git.FetchOptions{RemoteName: "origin"}))
+	mainRef, err := r.Reference(plumbing.NewRemoteReferenceName("origin", "main"), true)
+	require.NoError(t, err)
+	merge := func(message string) {
+		head, err := r.Head()
+		require.NoError(t, err)
+		w, err := r.Worktree()
+		require.NoError(t, err)
+		_, err = w.Commit(message, &git.CommitOptions{
+			Author:            &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()},
+			Parents:           []plumbing.Hash{head.Hash(), mainRef.Hash()},
+			AllowEmptyCommits: true,
+		})
+		require.NoError(t, err)
+	}
+	merge("Merge branch 'main' into dev-konnect-orchestrator-apply")
+	merge("Merge branch 'main' into dev-konnect-orchestrator-apply again")
+
+	foreign, err := ForeignCommits(dir, "main", "bot@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+
+	// a merge into someone else's commit stays foreign
+	commitFile(t, dir, "fixup.yaml", "human", "dev@example.com")
+	merge("Merge branch 'main' into dev-konnect-orchestrator-apply")
+	foreign, err = ForeignCommits(dir, "main", "bot@example.com")
+	require.NoError(t, err)
+	require.Len(t, foreign, 2)
+	assert.Contains(t, foreign[0], "dev: Merge branch 'main'")
+	assert.Contains(t, foreign[1], "dev@example.com: update fixup.yaml")
+}
+
+func TestCheckAccess(t *testing.T) {
+	cfg := newRemote(t)
+	require.NoError(t, CheckAccess(cfg))
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	cfg.Remote = &missing
+	assert.ErrorContains(t, CheckAccess(cfg), "failed to list the references of "+missing)
+}
+
+func TestSSHAuthMethod(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := gossh.MarshalPrivateKeyWithPassphrase(private, "", []byte("s3cret"))
+	require.NoError(t, err)
+	key := string(pem.EncodeToMemory(block))
+
+	hostKey, err := gossh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	knownHosts := "github.com " + string(gossh.MarshalAuthorizedKey(hostKey.PublicKey()))
+
+	literal := func(value string) *manifest.Secret {
+		return &manifest.Secret{Type: "literal", Value: value}
+	}
+	tests := []struct {
+		name    string
+		config  *manifest.SSHConfig
+		wantErr string
+	}{
+		{name: "encrypted key", config: &manifest.SSHConfig{Key: literal(key), Passphrase: literal("s3cret\n")}},
+		{
+			name:    "missing passphrase",
+			config:  &manifest.SSHConfig{Key: literal(key)},
+			wantErr: "failed to parse ssh key",
+		},
+		{
+			name:    "wrong passphrase",
+			config:  &manifest.SSHConfig{Key: literal(key), Passphrase: literal("guess")},
+			wantErr: "failed to parse ssh key",
+		},
+		{
+			name: "known hosts",
+			config: &manifest.SSHConfig{
+				Key:        literal(key),
+				Passphrase: literal("s3cret"),
+				KnownHosts: literal(knownHosts),
+			},
+		},
+		{
+			name: "malformed known hosts",
+			config: &manifest.SSHConfig{
+				Key:        literal(key),
+				Passphrase: literal("s3cret"),
+				KnownHosts: literal("github.com ssh-ed25519 !!!"),
+			},
+			wantErr: "failed to parse ssh known hosts",
+		},
+		{name: "no key", config: &manifest.SSHConfig{}, wantErr: "ssh auth requires an ssh key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := sshAuthMethod(tt.config)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			publicKeys, ok := auth.(*ssh.PublicKeys)
+			require.True(t, ok)
+			if tt.config.KnownHosts == nil {
+				return
+			}
+			addr := &net.TCPAddr{IP: net.IPv4(140, 82, 121, 4), Port: 22}
+			assert.NoError(t, publicKeys.HostKeyCallback("github.com:22", addr, hostKey.PublicKey()))
+			other, err := gossh.NewSignerFromKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+			require.NoError(t, err)
+			assert.Error(t, publicKeys.HostKeyCallback("github.com:22", addr, other.PublicKey()))
+		})
+	}
+}
+
+func TestPushConflictsOnSameFileChanges(t *testing.T) {
+	cfg := newRemote(t)
+	base := checkout(t, cfg, "main")
+	require.NoError(t, os.MkdirAll(filepath.Join(base, ".github"), 0o755))
+	commitFile(t, base, ".github/CODEOWNERS", "/konnect/ @platform\n", "platform@example.com")
+	require.NoError(t, Push(base, cfg))
+
+	// two teams set their code owners concurrently, starting from the same revision
+	teamA := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	teamB := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	commitFile(t, teamA, ".github/CODEOWNERS", "/konnect/ @platform\n/konnect/a/ @a\n", "bot@example.com")
+	require.NoError(t, Push(teamA, cfg))
+
+	// the remote CODEOWNERS isn't overwritten with the content of the second team
+	commitFile(t, teamB, ".github/CODEOWNERS", "/konnect/ @platform\n/konnect/b/ @b\n", "bot@example.com")
+	err := Push(teamB, cfg)
+	require.ErrorIs(t, err, ErrConflict)
+	assert.ErrorContains(t, err, ".github/CODEOWNERS")
+
+	// applying the changes of the second team again on top of the remote branch keeps both
+	teamB = checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	content, err := os.ReadFile(filepath.Join(teamB, ".github", "CODEOWNERS"))
+	require.NoError(t, err)
+	commitFile(t, teamB, ".github/CODEOWNERS", string(content)+"/konnect/b/ @b\n", "bot@example.com")
+	require.NoError(t, Push(teamB, cfg))
+
+	result := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	content, err = os.ReadFile(filepath.Join(result, ".github", "CODEOWNERS"))
+	require.NoError(t, err)
+	assert.Equal(t, "/konnect/ @platform\n/konnect/a/ @a\n/konnect/b/ @b\n", string(content))
+}
+
+func TestPushSameChange(t *testing.T) {
+	cfg := newRemote(t)
+	first := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+	second := checkout(t, cfg, "dev-konnect-orchestrator-apply")
+
+	commitFile(t, first, "kong.yaml", "generated", "bot@example.com")
+	require.NoError(t, Push(first, cfg))
+	commitFile(t, second, "kong.yaml", "generated", "bot@example.com")
+	require.NoError(t, Push(second, cfg))
+}
@@ -0,0 +1,319 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the Gitea API responds with 404 Not Found
+var ErrNotFound = errors.New("not found")
+
+// pageSize is the default maximum page size of the Gitea API
+const pageSize = 50
+
+// Client calls the REST API v1 of Gitea, or Forgejo, with an access token, or the password of a user
+type Client struct {
+	baseURL    string
+	username   string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the Gitea API at baseURL, e.g. https://gitea.example.com/api/v1. With a
+// username, the token is sent as the password of the user.
+func NewClient(baseURL, username, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		username:   username,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+type Repository struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	Private       bool   `json:"private"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+	Owner         User   `json:"owner"`
+}
+
+type Label struct {
+	ID    int64  `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+type Branch struct {
+	Ref string `json:"ref"`
+}
+
+type PullRequest struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	Body    string  `json:"body"`
+	HTMLURL string  `json:"html_url"`
+	Labels  []Label `json:"labels"`
+	Head    Branch  `json:"head"`
+	Base    Branch  `json:"base"`
+}
+
+type Comment struct {
+	ID   int64  `json:"id,omitempty"`
+	Body string `json:"body"`
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.token)
+	} else {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// list reads every page of a paginated list
+func list[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	var all []T
+	for page := 1; ; page++ {
+		var items []T
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s%slimit=%d&page=%d", path, sep, pageSize, page), nil, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < pageSize {
+			return all, nil
+		}
+	}
+}
+
+func repository(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// CurrentUser returns the authenticated user
+func (c *Client) CurrentUser(ctx context.Context) (*User, error) {
+	var u User
+	if err := c.do(ctx, http.MethodGet, "/user", nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// OrganizationExists tells whether an organization exists, owners that aren't organizations are users
+func (c *Client) OrganizationExists(ctx context.Context, org string) (bool, error) {
+	err := c.do(ctx, http.MethodGet, "/orgs/"+url.PathEscape(org), nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreateOrganization creates a public organization
+func (c *Client) CreateOrganization(ctx context.Context, org string) error {
+	return c.do(ctx, http.MethodPost, "/orgs", map[string]string{"username": org, "visibility": "public"}, nil)
+}
+
+// CreateRepository creates a private repository with an initial commit on its default branch, in an
+// organization, or for the authenticated user when org is empty
+func (c *Client) CreateRepository(ctx context.Context, org, name, defaultBranch string) (*Repository, error) {
+	path := "/user/repos"
+	if org != "" {
+		path = "/orgs/" + url.PathEscape(org) + "/repos"
+	}
+	var r Repository
+	err := c.do(ctx, http.MethodPost, path, map[string]interface{}{
+		"name":           name,
+		"private":        true,
+		"auto_init":      true,
+		"default_branch": defaultBranch,
+	}, &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRepositories returns the repositories of an organization, or of a user when owner isn't an
+// organization
+func (c *Client) ListRepositories(ctx context.Context, owner string) ([]Repository, error) {
+	repos, err := list[Repository](ctx, c, "/orgs/"+url.PathEscape(owner)+"/repos")
+	if errors.Is(err, ErrNotFound) {
+		return list[Repository](ctx, c, "/users/"+url.PathEscape(owner)+"/repos")
+	}
+	return repos, err
+}
+
+// FindPullRequest returns the open pull request of a head branch into a base branch, or nil
+func (c *Client) FindPullRequest(ctx context.Context, owner, repo, head, base string) (*PullRequest, error) {
+	prs, err := list[PullRequest](ctx, c, repository(owner, repo)+"/pulls?state=open")
+	if err != nil {
+		return nil, err
+	}
+	for i := range prs {
+		if prs[i].Head.Ref == head && prs[i].Base.Ref == base {
+			return &prs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CreatePullRequest opens a pull request with labels given by id
+func (c *Client) CreatePullRequest(ctx context.Context,
+	owner, repo, head, base, title, body string,
+	labelIDs []int64,
+) (*PullRequest, error) {
+	var pr PullRequest
+	err := c.do(ctx, http.MethodPost, repository(owner, repo)+"/pulls", map[string]interface{}{
+		"head":   head,
+		"base":   base,
+		"title":  title,
+		"body":   body,
+		"labels": labelIDs,
+	}, &pr)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequest sets the title and body of a pull request
+func (c *Client) UpdatePullRequest(ctx context.Context, owner, repo string, number int, title, body string) (*PullRequest, error) {
+	var pr PullRequest
+	path := fmt.Sprintf("%s/pulls/%d", repository(owner, repo), number)
+	if err := c.do(ctx, http.MethodPatch, path, map[string]string{"title": title, "body": body}, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// ListLabels returns the labels of a repository
+func (c *Client) ListLabels(ctx context.Context, owner, repo string) ([]Label, error) {
+	return list[Label](ctx, c, repository(owner, repo)+"/labels")
+}
+
+// CreateLabel creates a label in a repository
+func (c *Client) CreateLabel(ctx context.Context, owner, repo string, label Label) (*Label, error) {
+	var created Label
+	if err := c.do(ctx, http.MethodPost, repository(owner, repo)+"/labels", label, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// AddLabels adds labels, given by id, to a pull request
+func (c *Client) AddLabels(ctx context.Context, owner, repo string, number int, labelIDs []int64) error {
+	path := fmt.Sprintf("%s/issues/%d/labels", repository(owner, repo), number)
+	return c.do(ctx, http.MethodPost, path, map[string][]int64{"labels": labelIDs}, nil)
+}
+
+// RequestReviewers requests the review of users and teams of the organization on a pull request
+func (c *Client) RequestReviewers(ctx context.Context, owner, repo string, number int, users, teams []string) error {
+	path := fmt.Sprintf("%s/pulls/%d/requested_reviewers", repository(owner, repo), number)
+	return c.do(ctx, http.MethodPost, path, map[string][]string{"reviewers": users, "team_reviewers": teams}, nil)
+}
+
+// ListComments returns the comments of a pull request
+func (c *Client) ListComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
+	return list[Comment](ctx, c, fmt.Sprintf("%s/issues/%d/comments", repository(owner, repo), number))
+}
+
+// CreateComment comments on a pull request
+func (c *Client) CreateComment(ctx context.Context, owner, repo string, number int, body string) error {
+	path := fmt.Sprintf("%s/issues/%d/comments", repository(owner, repo), number)
+	return c.do(ctx, http.MethodPost, path, Comment{Body: body}, nil)
+}
+
+// SetSecret creates or updates an Actions secret of a repository
+func (c *Client) SetSecret(ctx context.Context, owner, repo, name, value string) error {
+	path := repository(owner, repo) + "/actions/secrets/" + url.PathEscape(name)
+	return c.do(ctx, http.MethodPut, path, map[string]string{"data": value}, nil)
+}
+
+// GetRepository returns a repository
+func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*Repository, error) {
+	var r Repository
+	if err := c.do(ctx, http.MethodGet, repository(owner, repo), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CommitFiles creates files, given by path, on a branch in a single commit
+func (c *Client) CommitFiles(ctx context.Context, owner, repo, branch, message string, files map[string][]byte) error {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	changes := make([]map[string]string, 0, len(files))
+	for _, path := range paths {
+		changes = append(changes, map[string]string{
+			"operation": "create",
+			"path":      path,
+			"content":   base64.StdEncoding.EncodeToString(files[path]),
+		})
+	}
+	return c.do(ctx, http.MethodPost, repository(owner, repo)+"/contents", map[string]interface{}{
+		"branch":  branch,
+		"message": message,
+		"files":   changes,
+	}, nil)
+}
@@ -0,0 +1,146 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+)
+
+// labelColor is the color of the labels created for pull requests
+const labelColor = "#ededed"
+
+// CreateRepository creates a repository with an initial commit on its main branch, in the organization
+// owning it or for the authenticated user
+func CreateRepository(ctx context.Context, client *Client, owner, repo, mainBranch string) error {
+	isOrg, err := client.OrganizationExists(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("failed to get organization %s: %w", owner, err)
+	}
+	org := owner
+	if !isOrg {
+		user, err := client.CurrentUser(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the authenticated user: %w", err)
+		}
+		if user.Login != owner {
+			return fmt.Errorf("%s is neither an organization nor the authenticated user %s", owner, user.Login)
+		}
+		org = ""
+	}
+	if _, err := client.CreateRepository(ctx, org, repo, mainBranch); err != nil {
+		return fmt.Errorf("failed to create repository %s/%s: %w", owner, repo, err)
+	}
+	return nil
+}
+
+// CreateOrUpdatePullRequest opens a pull request of a branch or updates the open one, keeping the sections
+// of the body written by other runs. Missing labels are created in the repository.
+func CreateOrUpdatePullRequest(ctx context.Context,
+	client *Client,
+	owner, repo, branch, base, title, body string,
+	labels []string,
+) (*PullRequest, error) {
+	pr, err := client.FindPullRequest(ctx, owner, repo, branch, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var missing []string
+	if pr == nil {
+		missing = labels
+	} else {
+		has := map[string]bool{}
+		for _, l := range pr.Labels {
+			has[l.Name] = true
+		}
+		for _, l := range labels {
+			if !has[l] {
+				missing = append(missing, l)
+			}
+		}
+	}
+	labelIDs, err := labelIDs(ctx, client, owner, repo, missing)
+	if err != nil {
+		// Just log the error but don't fail the pull request update
+		fmt.Printf("Warning: failed to add labels to PR: %v\n", err)
+		labelIDs = nil
+	}
+
+	if pr == nil {
+		pr, err = client.CreatePullRequest(ctx, owner, repo, branch, base, title, body, labelIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pull request: %w", err)
+		}
+		return pr, nil
+	}
+
+	body = github.MergeBody(pr.Body, body)
+	if pr.Title != title || pr.Body != body {
+		number := pr.Number
+		if pr, err = client.UpdatePullRequest(ctx, owner, repo, number, title, body); err != nil {
+			return nil, fmt.Errorf("failed to update pull request: %w", err)
+		}
+	}
+	if len(labelIDs) > 0 {
+		if err := client.AddLabels(ctx, owner, repo, pr.Number, labelIDs); err != nil {
+			fmt.Printf("Warning: failed to add labels to PR: %v\n", err)
+		}
+	}
+	return pr, nil
+}
+
+// CommentOnPullRequest comments on the open pull request of a branch, unless a comment containing the
+// marker already exists. Nothing is commented when the branch has no open pull request.
+func CommentOnPullRequest(ctx context.Context, client *Client, owner, repo, branch, base, marker, body string) error {
+	pr, err := client.FindPullRequest(ctx, owner, repo, branch, base)
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if pr == nil {
+		return nil
+	}
+
+	comments, err := client.ListComments(ctx, owner, repo, pr.Number)
+	if err != nil {
+		return fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+	for _, c := range comments {
+		if strings.Contains(c.Body, marker) {
+			return nil
+		}
+	}
+	if err := client.CreateComment(ctx, owner, repo, pr.Number, marker+"\n"+body); err != nil {
+		return fmt.Errorf("failed to comment on pull request: %w", err)
+	}
+	return nil
+}
+
+// labelIDs returns the ids of labels of a repository by name, creating the missing labels
+func labelIDs(ctx context.Context, client *Client, owner, repo string, names []string) ([]int64, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	existing, err := client.ListLabels(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	byName := map[string]int64{}
+	for _, l := range existing {
+		byName[l.Name] = l.ID
+	}
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			created, err := client.CreateLabel(ctx, owner, repo, Label{Name: name, Color: labelColor})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create label %s: %w", name, err)
+			}
+			id = created.ID
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
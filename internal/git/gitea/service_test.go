@@ -0,0 +1,127 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// newServer records the requests and answers them with the responses keyed by method and path
+func newServer(t *testing.T, responses map[string]interface{}) (*Client, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{Method: r.Method, Path: r.URL.Path, Body: string(body)})
+
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/api/v1", "", "token"), &requests
+}
+
+func decode(t *testing.T, body string) map[string]interface{} {
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(body), &m))
+	return m
+}
+
+func TestCreateOrUpdatePullRequest(t *testing.T) {
+	repo := "/api/v1/repos/org/platform"
+
+	t.Run("creates a pull request and the missing labels", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + repo + "/pulls":   []PullRequest{{Number: 1, Head: Branch{Ref: "other"}, Base: Branch{Ref: "main"}}},
+			"GET " + repo + "/labels":  []Label{{ID: 1, Name: "a"}},
+			"POST " + repo + "/labels": Label{ID: 2, Name: "b"},
+			"POST " + repo + "/pulls":  PullRequest{Number: 2, HTMLURL: "http://gitea/org/platform/pulls/2"},
+		})
+
+		pr, err := CreateOrUpdatePullRequest(context.Background(), client,
+			"org", "platform", "dev-apply", "main", "title", "body", []string{"a", "b"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, pr.Number)
+		require.Len(t, *requests, 4)
+		assert.Equal(t, "b", decode(t, (*requests)[2].Body)["name"])
+		body := decode(t, (*requests)[3].Body)
+		assert.Equal(t, "dev-apply", body["head"])
+		assert.Equal(t, []interface{}{float64(1), float64(2)}, body["labels"])
+	})
+
+	t.Run("updates the open pull request", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET " + repo + "/pulls": []PullRequest{{
+				Number: 3, Title: "title", Body: "body", Labels: []Label{{ID: 1, Name: "a"}},
+				Head: Branch{Ref: "dev-apply"}, Base: Branch{Ref: "main"},
+			}},
+			"PATCH " + repo + "/pulls/3": PullRequest{Number: 3},
+		})
+
+		_, err := CreateOrUpdatePullRequest(context.Background(), client,
+			"org", "platform", "dev-apply", "main", "title", "new", []string{"a"})
+		require.NoError(t, err)
+		require.Len(t, *requests, 2)
+		assert.Equal(t, "new", decode(t, (*requests)[1].Body)["body"])
+	})
+}
+
+func TestCreateRepository(t *testing.T) {
+	t.Run("organization", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET /api/v1/orgs/org":        map[string]string{},
+			"POST /api/v1/orgs/org/repos": Repository{},
+		})
+		require.NoError(t, CreateRepository(context.Background(), client, "org", "platform", "main"))
+		require.Len(t, *requests, 2)
+		body := decode(t, (*requests)[1].Body)
+		assert.Equal(t, true, body["auto_init"])
+		assert.Equal(t, "main", body["default_branch"])
+	})
+
+	t.Run("user", func(t *testing.T) {
+		client, requests := newServer(t, map[string]interface{}{
+			"GET /api/v1/user":        User{Login: "jdoe"},
+			"POST /api/v1/user/repos": Repository{},
+		})
+		require.NoError(t, CreateRepository(context.Background(), client, "jdoe", "platform", "main"))
+		assert.Len(t, *requests, 3)
+
+		err := CreateRepository(context.Background(), client, "other", "platform", "main")
+		assert.ErrorContains(t, err, "neither an organization nor the authenticated user")
+	})
+}
+
+func TestListRepositories(t *testing.T) {
+	client, requests := newServer(t, map[string]interface{}{
+		"GET /api/v1/users/jdoe/repos": []Repository{{Name: "platform"}},
+	})
+	repos, err := client.ListRepositories(context.Background(), "jdoe")
+	require.NoError(t, err)
+	assert.Equal(t, []Repository{{Name: "platform"}}, repos)
+	assert.Len(t, *requests, 2)
+}
+
+func TestSetSecret(t *testing.T) {
+	client, requests := newServer(t, map[string]interface{}{
+		"PUT /api/v1/repos/org/platform/actions/secrets/TOKEN": map[string]string{},
+	})
+	require.NoError(t, client.SetSecret(context.Background(), "org", "platform", "TOKEN", "v"))
+	assert.Equal(t, map[string]interface{}{"data": "v"}, decode(t, (*requests)[0].Body))
+}
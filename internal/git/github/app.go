@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/util"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/go-github/v60/github"
+)
+
+// Environment variables configuring a GitHub App in CI, set from the repository secrets of the same name
+const (
+	AppIDEnvVar             = "KONNECT_ORCHESTRATOR_GITHUB_APP_ID"
+	AppInstallationIDEnvVar = "KONNECT_ORCHESTRATOR_GITHUB_APP_INSTALLATION_ID"
+	AppPrivateKeyEnvVar     = "KONNECT_ORCHESTRATOR_GITHUB_APP_PRIVATE_KEY" //nolint:gosec
+)
+
+// tokenRefreshMargin is how long before they expire installation tokens are refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+// appTokens mints installation tokens of GitHub Apps and caches them until they are about to expire
+type appTokens struct {
+	mu sync.Mutex
+	// installations are the discovered installation ids by API URL, app id and owner
+	installations map[string]int64
+	// tokens are the installation tokens by API URL, app id and installation id
+	tokens map[string]*github.InstallationToken
+}
+
+func newAppTokens() *appTokens {
+	return &appTokens{
+		installations: map[string]int64{},
+		tokens:        map[string]*github.InstallationToken{},
+	}
+}
+
+var installationTokens = newAppTokens()
+
+// ResolveToken returns the token of a GitHub configuration: an installation token of the app on the owner
+// of the repository when an app is configured, or else the configured token
+func ResolveToken(ctx context.Context, githubConfig manifest.GitHubConfig, owner string) (string, error) {
+	if githubConfig.App != nil {
+		return InstallationToken(ctx, ConfigEndpoints(githubConfig).APIURL, *githubConfig.App, owner)
+	}
+	if githubConfig.Token == nil {
+		return "", errors.New("github token or app is required")
+	}
+	return util.ResolveSecretValue(*githubConfig.Token)
+}
+
+// InstallationToken returns an installation token of a GitHub App on an owner, a user or an organization,
+// minting a new token with the API at apiURL when the cached one expires soon
+func InstallationToken(ctx context.Context, apiURL string, app manifest.GitHubAppConfig, owner string) (string, error) {
+	return installationTokens.token(ctx, apiURL, app, owner)
+}
+
+func (t *appTokens) token(ctx context.Context,
+	apiURL string,
+	app manifest.GitHubAppConfig,
+	owner string,
+) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	installationID := app.InstallationID
+	if installationID == 0 {
+		installationID = t.installations[fmt.Sprintf("%s %d/%s", apiURL, app.ID, owner)]
+	}
+	tokenKey := fmt.Sprintf("%s %d/%d", apiURL, app.ID, installationID)
+	if tok, ok := t.tokens[tokenKey]; ok && installationID != 0 &&
+		time.Until(tok.GetExpiresAt().Time) > tokenRefreshMargin {
+		return tok.GetToken(), nil
+	}
+
+	client, err := appClient(apiURL, app)
+	if err != nil {
+		return "", err
+	}
+	if installationID == 0 {
+		if installationID, err = findInstallation(ctx, client, owner); err != nil {
+			return "", err
+		}
+		t.installations[fmt.Sprintf("%s %d/%s", apiURL, app.ID, owner)] = installationID
+		tokenKey = fmt.Sprintf("%s %d/%d", apiURL, app.ID, installationID)
+	}
+
+	tok, _, err := client.Apps.CreateInstallationToken(ctx, installationID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a token of installation %d of GitHub App %d: %w", installationID, app.ID, err)
+	}
+	t.tokens[tokenKey] = tok
+	return tok.GetToken(), nil
+}
+
+// appClient returns a client authenticated as the app with a JSON Web Token signed by its private key
+func appClient(apiURL string, app manifest.GitHubAppConfig) (*github.Client, error) {
+	if app.PrivateKey == nil {
+		return nil, fmt.Errorf("private key of GitHub App %d is required", app.ID)
+	}
+	pem, err := util.ResolveSecretValue(*app.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the private key of GitHub App %d: %w", app.ID, err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(pem))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key of GitHub App %d: %w", app.ID, err)
+	}
+
+	now := time.Now()
+	appJWT, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer: strconv.FormatInt(app.ID, 10),
+		// GitHub allows for clock drift by accepting tokens issued in the past
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}).SignedString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the token of GitHub App %d: %w", app.ID, err)
+	}
+
+	client, err := NewEndpoints("", apiURL, "").Client(nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.WithAuthToken(appJWT), nil
+}
+
+// findInstallation returns the installation of the app on an organization, or else on a user
+func findInstallation(ctx context.Context, client *github.Client, owner string) (int64, error) {
+	installation, resp, err := client.Apps.FindOrganizationInstallation(ctx, owner)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		installation, _, err = client.Apps.FindUserInstallation(ctx, owner)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the installation of the GitHub App on %s: %w", owner, err)
+	}
+	return installation.GetID(), nil
+}
@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	// newServer answers with installation tokens expiring after ttl
+	newServer := func(t *testing.T, ttl time.Duration) (*appTokens, string, *[]string) {
+		var requests []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			claims := jwt.RegisteredClaims{}
+			_, err := jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &claims,
+				func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+			assert.NoError(t, err)
+			assert.Equal(t, "42", claims.Issuer)
+
+			switch r.URL.Path {
+			case "/orgs/KongAirlines/installation":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 7})
+			case "/users/jdoe/installation":
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 8})
+			case "/app/installations/7/access_tokens", "/app/installations/8/access_tokens", "/app/installations/9/access_tokens":
+				w.WriteHeader(http.StatusCreated)
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"token":      "token-" + strings.Split(r.URL.Path, "/")[3],
+					"expires_at": time.Now().Add(ttl).Format(time.RFC3339),
+				})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+		return newAppTokens(), server.URL + "/", &requests
+	}
+	app := manifest.GitHubAppConfig{ID: 42, PrivateKey: &manifest.Secret{Type: "literal", Value: privateKey}}
+
+	t.Run("discovers the installation of an organization and caches the token", func(t *testing.T) {
+		tokens, apiURL, requests := newServer(t, time.Hour)
+		for i := 0; i < 2; i++ {
+			tok, err := tokens.token(context.Background(), apiURL, app, "KongAirlines")
+			require.NoError(t, err)
+			assert.Equal(t, "token-7", tok)
+		}
+		assert.Equal(t, []string{
+			"GET /orgs/KongAirlines/installation",
+			"POST /app/installations/7/access_tokens",
+		}, *requests)
+	})
+
+	t.Run("falls back to the installation of a user", func(t *testing.T) {
+		tokens, apiURL, _ := newServer(t, time.Hour)
+		tok, err := tokens.token(context.Background(), apiURL, app, "jdoe")
+		require.NoError(t, err)
+		assert.Equal(t, "token-8", tok)
+	})
+
+	t.Run("uses the configured installation and refreshes expiring tokens", func(t *testing.T) {
+		tokens, apiURL, requests := newServer(t, time.Minute)
+		withInstallation := app
+		withInstallation.InstallationID = 9
+		for i := 0; i < 2; i++ {
+			tok, err := tokens.token(context.Background(), apiURL, withInstallation, "KongAirlines")
+			require.NoError(t, err)
+			assert.Equal(t, "token-9", tok)
+		}
+		assert.Equal(t, []string{
+			"POST /app/installations/9/access_tokens",
+			"POST /app/installations/9/access_tokens",
+		}, *requests)
+	})
+
+	t.Run("invalid private keys are rejected", func(t *testing.T) {
+		tokens, apiURL, _ := newServer(t, time.Hour)
+		invalid := app
+		invalid.PrivateKey = &manifest.Secret{Type: "literal", Value: "not a key"}
+		_, err := tokens.token(context.Background(), apiURL, invalid, "KongAirlines")
+		assert.ErrorContains(t, err, "failed to parse the private key of GitHub App 42")
+	})
+}
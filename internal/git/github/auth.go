@@ -10,32 +10,39 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/go-github/v60/github"
 	"golang.org/x/oauth2"
-	githubOAuth "golang.org/x/oauth2/github"
 )
 
 // AuthService handles authentication related operations
 type AuthService struct {
 	config      *config.Config
 	oauthConfig *oauth2.Config
+	endpoints   Endpoints
 }
 
 // NewAuthService creates a new AuthService
 func NewAuthService(config *config.Config) *AuthService {
+	endpoints := NewEndpoints(config.GitHubURL, config.GitHubAPIURL, config.GitHubUploadURL)
 	// Create OAuth2 configuration for GitHub
 	oauthConfig := &oauth2.Config{
 		ClientID:     config.GitHubClientID,
 		ClientSecret: config.GitHubClientSecret,
 		RedirectURL:  config.GitHubRedirectURI,
 		Scopes:       config.GitHubScopes,
-		Endpoint:     githubOAuth.Endpoint,
+		Endpoint:     endpoints.OAuth(),
 	}
 
 	return &AuthService{
 		config:      config,
 		oauthConfig: oauthConfig,
+		endpoints:   endpoints,
 	}
 }
 
+// Endpoints returns the URLs of the GitHub instance users log in with
+func (s *AuthService) Endpoints() Endpoints {
+	return s.endpoints
+}
+
 // GetAuthorizationURL returns the GitHub authorization URL
 func (s *AuthService) GetAuthorizationURL(state string) string {
 	return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOnline)
@@ -49,7 +56,10 @@ func (s *AuthService) ExchangeCodeForToken(ctx context.Context, code string) (*o
 // GetUserFromGitHub gets the user information from GitHub
 func (s *AuthService) GetUserFromGitHub(ctx context.Context, token *oauth2.Token) (*github.User, error) {
 	// Create a GitHub client with the token
-	client := github.NewClient(s.oauthConfig.Client(ctx, token))
+	client, err := s.endpoints.Client(s.oauthConfig.Client(ctx, token))
+	if err != nil {
+		return nil, err
+	}
 
 	// Get the authenticated user
 	user, _, err := client.Users.Get(ctx, "")
@@ -63,7 +73,10 @@ func (s *AuthService) GetUserFromGitHub(ctx context.Context, token *oauth2.Token
 // GetUserEmail gets the primary email of the user from GitHub
 func (s *AuthService) GetUserEmail(ctx context.Context, token *oauth2.Token) (string, error) {
 	// Create a GitHub client with the token
-	client := github.NewClient(s.oauthConfig.Client(ctx, token))
+	client, err := s.endpoints.Client(s.oauthConfig.Client(ctx, token))
+	if err != nil {
+		return "", err
+	}
 
 	// Get emails
 	emails, _, err := client.Users.ListEmails(ctx, nil)
@@ -131,14 +144,3 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 
 	return nil, errors.New("invalid token")
 }
-
-func CreateGitHubClient(ctx context.Context, token string) *github.Client {
-	// Create a token source
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-
-	// Create a GitHub client
-	return github.NewClient(tc)
-}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CodeOwnersPath is the location of the CODEOWNERS file maintained in the platform repository
+const CodeOwnersPath = ".github/CODEOWNERS"
+
+// CodeOwners returns the CODEOWNERS owners of a set of reviewers: @login for users and @org/slug for
+// teams of the organization owning the repository
+func CodeOwners(org string, users, teams []string) []string {
+	owners := make([]string, 0, len(users)+len(teams))
+	for _, u := range users {
+		owners = append(owners, "@"+strings.TrimPrefix(u, "@"))
+	}
+	for _, t := range teams {
+		t = strings.TrimPrefix(t, "@")
+		if !strings.Contains(t, "/") {
+			t = org + "/" + t
+		}
+		owners = append(owners, "@"+t)
+	}
+	return owners
+}
+
+// SetCodeOwners sets the owners of a path pattern in the content of a CODEOWNERS file. The line of the
+// pattern is replaced, or appended when the pattern isn't in the file yet, and the other lines are kept.
+// No owners removes the pattern.
+func SetCodeOwners(content, pattern string, owners []string) string {
+	line := ""
+	if len(owners) > 0 {
+		line = fmt.Sprintf("%s %s", pattern, strings.Join(owners, " "))
+	}
+
+	var lines []string
+	found := false
+	for _, l := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		fields := strings.Fields(l)
+		if len(fields) > 0 && fields[0] == pattern {
+			if !found && line != "" {
+				lines = append(lines, line)
+			}
+			found = true
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if !found && line != "" {
+		lines = append(lines, line)
+	}
+
+	out := strings.TrimLeft(strings.Join(lines, "\n"), "\n")
+	if out == "" {
+		return ""
+	}
+	return out + "\n"
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOwners(t *testing.T) {
+	assert.Equal(t,
+		[]string{"@alice", "@bob", "@KongAirlines/flight-data", "@other/team"},
+		CodeOwners("KongAirlines", []string{"alice", "@bob"}, []string{"flight-data", "other/team"}))
+}
+
+func TestSetCodeOwners(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		pattern  string
+		owners   []string
+		expected string
+	}{
+		{
+			name:     "new file",
+			pattern:  "/konnect/kongair/envs/dev/teams/flight-data/",
+			owners:   []string{"@alice"},
+			expected: "/konnect/kongair/envs/dev/teams/flight-data/ @alice\n",
+		},
+		{
+			name:     "pattern is appended",
+			content:  "# platform\n* @platform\n",
+			pattern:  "/konnect/",
+			owners:   []string{"@alice", "@org/team"},
+			expected: "# platform\n* @platform\n/konnect/ @alice @org/team\n",
+		},
+		{
+			name:     "pattern is replaced in place",
+			content:  "/a/ @alice\n/b/ @bob\n/c/ @carol\n",
+			pattern:  "/b/",
+			owners:   []string{"@dave"},
+			expected: "/a/ @alice\n/b/ @dave\n/c/ @carol\n",
+		},
+		{
+			name:     "no owners removes the pattern",
+			content:  "/a/ @alice\n/b/ @bob\n",
+			pattern:  "/b/",
+			expected: "/a/ @alice\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SetCodeOwners(tt.content, tt.pattern, tt.owners))
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/google/go-github/v60/github"
+	"golang.org/x/oauth2"
+)
+
+// URLs of github.com
+const (
+	DefaultWebURL    = "https://github.com/"
+	DefaultAPIURL    = "https://api.github.com/"
+	DefaultUploadURL = "https://uploads.github.com/"
+)
+
+// Endpoints are the URLs of github.com or of a GitHub Enterprise Server
+type Endpoints struct {
+	WebURL    string
+	APIURL    string
+	UploadURL string
+}
+
+// NewEndpoints returns the endpoints of the GitHub Enterprise Server at webURL, e.g. https://github.example.com,
+// or of github.com when webURL is empty. The API and upload URLs are derived from the web URL when empty, and
+// the web URL from an API URL of the form https://<host>/api/v3/.
+func NewEndpoints(webURL, apiURL, uploadURL string) Endpoints {
+	e := Endpoints{WebURL: DefaultWebURL, APIURL: DefaultAPIURL, UploadURL: DefaultUploadURL}
+	if webURL != "" {
+		if !strings.Contains(webURL, "://") {
+			webURL = "https://" + webURL
+		}
+		e.WebURL = withTrailingSlash(webURL)
+		if e.IsEnterprise() {
+			e.APIURL, e.UploadURL = e.WebURL+"api/v3/", e.WebURL+"api/uploads/"
+		}
+	}
+	if apiURL != "" && withTrailingSlash(apiURL) != e.APIURL {
+		e.APIURL, e.UploadURL = withTrailingSlash(apiURL), withTrailingSlash(apiURL)
+		if base, ok := strings.CutSuffix(e.APIURL, "api/v3/"); ok {
+			e.UploadURL = base + "api/uploads/"
+			if webURL == "" {
+				e.WebURL = base
+			}
+		}
+	}
+	if uploadURL != "" {
+		e.UploadURL = withTrailingSlash(uploadURL)
+	}
+	return e
+}
+
+// ConfigEndpoints returns the endpoints of the API URLs of a GitHub configuration
+func ConfigEndpoints(githubConfig manifest.GitHubConfig) Endpoints {
+	var apiURL, uploadURL string
+	if githubConfig.APIURL != nil {
+		apiURL = *githubConfig.APIURL
+	}
+	if githubConfig.UploadURL != nil {
+		uploadURL = *githubConfig.UploadURL
+	}
+	return NewEndpoints("", apiURL, uploadURL)
+}
+
+// IsEnterprise reports whether the endpoints are of a GitHub Enterprise Server
+func (e Endpoints) IsEnterprise() bool {
+	return IsEnterpriseURL(e.WebURL)
+}
+
+// OAuth returns the OAuth 2.0 endpoint of the web URL
+func (e Endpoints) OAuth() oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:  e.WebURL + "login/oauth/authorize",
+		TokenURL: e.WebURL + "login/oauth/access_token",
+	}
+}
+
+// Client returns a client of the API sending requests with httpClient
+func (e Endpoints) Client(httpClient *http.Client) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	baseURL, err := url.Parse(e.APIURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub API URL %s: %w", e.APIURL, err)
+	}
+	uploadURL, err := url.Parse(e.UploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub upload URL %s: %w", e.UploadURL, err)
+	}
+	client.BaseURL, client.UploadURL = baseURL, uploadURL
+	return client, nil
+}
+
+// TokenClient returns a client of the API authenticated with a token
+func (e Endpoints) TokenClient(ctx context.Context, token string) (*github.Client, error) {
+	return e.Client(oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})))
+}
+
+func withTrailingSlash(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+	return u + "/"
+}
+
+// IsEnterpriseURL checks if a URL is for a GitHub Enterprise instance
+func IsEnterpriseURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return !strings.Contains(rawURL, "github.com")
+	}
+	host := u.Hostname()
+	return host != "github.com" && !strings.HasSuffix(host, ".github.com")
+}
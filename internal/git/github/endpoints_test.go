@@ -0,0 +1,74 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEndpoints(t *testing.T) {
+	tests := []struct {
+		name                      string
+		webURL, apiURL, uploadURL string
+		want                      Endpoints
+		enterprise                bool
+	}{
+		{
+			name: "github.com",
+			want: Endpoints{WebURL: DefaultWebURL, APIURL: DefaultAPIURL, UploadURL: DefaultUploadURL},
+		},
+		{
+			name:   "enterprise server web URL",
+			webURL: "github.example.com",
+			want: Endpoints{
+				WebURL:    "https://github.example.com/",
+				APIURL:    "https://github.example.com/api/v3/",
+				UploadURL: "https://github.example.com/api/uploads/",
+			},
+			enterprise: true,
+		},
+		{
+			name:   "enterprise server API URL",
+			apiURL: "https://github.example.com/api/v3",
+			want: Endpoints{
+				WebURL:    "https://github.example.com/",
+				APIURL:    "https://github.example.com/api/v3/",
+				UploadURL: "https://github.example.com/api/uploads/",
+			},
+			enterprise: true,
+		},
+		{
+			name:      "custom URLs",
+			webURL:    "https://github.example.com",
+			apiURL:    "https://api.github.example.com",
+			uploadURL: "https://uploads.github.example.com",
+			want: Endpoints{
+				WebURL:    "https://github.example.com/",
+				APIURL:    "https://api.github.example.com/",
+				UploadURL: "https://uploads.github.example.com/",
+			},
+			enterprise: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEndpoints(tt.webURL, tt.apiURL, tt.uploadURL)
+			assert.Equal(t, tt.want, e)
+			assert.Equal(t, tt.enterprise, e.IsEnterprise())
+			assert.Equal(t, tt.want.WebURL+"login/oauth/authorize", e.OAuth().AuthURL)
+
+			client, err := e.Client(nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.APIURL, client.BaseURL.String())
+			assert.Equal(t, tt.want.UploadURL, client.UploadURL.String())
+		})
+	}
+}
+
+func TestIsEnterpriseURL(t *testing.T) {
+	assert.False(t, IsEnterpriseURL("https://github.com/KongAirlines/platform"))
+	assert.False(t, IsEnterpriseURL("https://api.github.com/"))
+	assert.True(t, IsEnterpriseURL("https://github.example.com/KongAirlines/platform"))
+	assert.True(t, IsEnterpriseURL("https://github.com.example.com/KongAirlines/platform"))
+}
@@ -0,0 +1,75 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var sectionPattern = regexp.MustCompile(`(?s)<!-- konnect-orchestrator:section:(\S+) -->.*?<!-- /konnect-orchestrator:section:(\S+) -->`)
+
+// Section wraps the content of a pull request body owned by one writer, e.g. a team, so that several
+// runs updating the same pull request only replace their own part of the body
+func Section(id, content string) string {
+	return fmt.Sprintf("<!-- konnect-orchestrator:section:%s -->\n%s\n<!-- /konnect-orchestrator:section:%s -->",
+		id, strings.TrimSpace(content), id)
+}
+
+// MergeBody combines the body of an existing pull request with a new body. The text before the first
+// section comes from the new body, the sections of the new body replace the existing sections with the
+// same id and the other existing sections are kept.
+func MergeBody(existing, body string) string {
+	newSections := sections(body)
+	if len(newSections) == 0 {
+		return body
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(header(body)))
+	written := map[string]bool{}
+	for _, s := range sections(existing) {
+		content := s.content
+		if replacement, ok := findSection(newSections, s.id); ok {
+			content = replacement.content
+		}
+		b.WriteString("\n\n" + content)
+		written[s.id] = true
+	}
+	for _, s := range newSections {
+		if !written[s.id] {
+			b.WriteString("\n\n" + s.content)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+type section struct {
+	id      string
+	content string
+}
+
+func sections(body string) []section {
+	var out []section
+	for _, m := range sectionPattern.FindAllStringSubmatch(body, -1) {
+		if m[1] == m[2] {
+			out = append(out, section{id: m[1], content: m[0]})
+		}
+	}
+	return out
+}
+
+func findSection(sections []section, id string) (section, bool) {
+	for _, s := range sections {
+		if s.id == id {
+			return s, true
+		}
+	}
+	return section{}, false
+}
+
+func header(body string) string {
+	if loc := sectionPattern.FindStringIndex(body); loc != nil {
+		return body[:loc[0]]
+	}
+	return body
+}
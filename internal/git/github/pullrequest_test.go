@@ -0,0 +1,50 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeBody(t *testing.T) {
+	teamA := Section("team:a", "## Team a\nold")
+	teamB := Section("team:b", "## Team b")
+
+	tests := []struct {
+		name     string
+		existing string
+		body     string
+		expected string
+	}{
+		{
+			name:     "body without sections replaces the existing body",
+			existing: "old body\n\n" + teamA,
+			body:     "new body",
+			expected: "new body",
+		},
+		{
+			name:     "new section is appended",
+			existing: "header\n\n" + teamA,
+			body:     "new header\n\n" + teamB,
+			expected: "new header\n\n" + teamA + "\n\n" + teamB,
+		},
+		{
+			name:     "existing section is replaced in place",
+			existing: "header\n\n" + teamA + "\n\n" + teamB,
+			body:     "header\n\n" + Section("team:a", "## Team a\nnew"),
+			expected: "header\n\n" + Section("team:a", "## Team a\nnew") + "\n\n" + teamB,
+		},
+		{
+			name:     "pull request without a body",
+			existing: "",
+			body:     "header\n\n" + teamA,
+			expected: "header\n\n" + teamA,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MergeBody(tt.existing, tt.body))
+		})
+	}
+}
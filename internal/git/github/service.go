@@ -16,37 +16,56 @@ import (
 	"github.com/google/go-github/v60/github"
 
 	"golang.org/x/crypto/nacl/box"
-	"golang.org/x/oauth2"
 )
 
 type GitHubService struct {
 	authService *AuthService
+	endpoints   Endpoints
 }
 
-// NewGitHubService creates a new GitHubService
+// NewGitHubService creates a new GitHubService calling the GitHub instance of the auth service, or github.com
 func NewGitHubService(authService *AuthService) *GitHubService {
+	endpoints := NewEndpoints("", "", "")
+	if authService != nil {
+		endpoints = authService.Endpoints()
+	}
 	return &GitHubService{
 		authService: authService,
+		endpoints:   endpoints,
+	}
+}
+
+// WithEndpoints returns a copy of the service calling another GitHub instance
+func (s *GitHubService) WithEndpoints(endpoints Endpoints) *GitHubService {
+	return &GitHubService{
+		authService: s.authService,
+		endpoints:   endpoints,
 	}
 }
 
 func CreateRepo(ctx context.Context,
 	owner, repo string,
 	githubConfig manifest.GitHubConfig) error {
-	token, err := util.ResolveSecretValue(*githubConfig.Token)
+	token, err := ResolveToken(ctx, githubConfig, owner)
 	if err != nil {
 		return err
 	}
 
-	client := CreateGitHubClient(ctx, token)
-	currentUser, _, err := client.Users.Get(ctx, "")
+	client, err := ConfigEndpoints(githubConfig).TokenClient(ctx, token)
 	if err != nil {
 		return err
 	}
-	// If the below is true, it means we're creating a repo in a personal account
-	// so owner needs to be empty to trigger the correct Github API
-	if currentUser.Login == &owner {
-		owner = ""
+	// GitHub Apps create repositories in the organizations they are installed on
+	if githubConfig.App == nil {
+		currentUser, _, err := client.Users.Get(ctx, "")
+		if err != nil {
+			return err
+		}
+		// If the below is true, it means we're creating a repo in a personal account
+		// so owner needs to be empty to trigger the correct Github API
+		if currentUser.Login == &owner {
+			owner = ""
+		}
 	}
 	ghRepo := &github.Repository{Name: &repo, AutoInit: github.Bool(true)}
 	_, _, err = client.Repositories.Create(ctx, owner, ghRepo)
@@ -57,22 +76,25 @@ func CreateRepo(ctx context.Context,
 }
 
 func CreateOrUpdatePullRequest(ctx context.Context,
-	owner, repo, branch, title, body string,
+	owner, repo, branch, base, title, body string,
 	githubConfig manifest.GitHubConfig,
 	labels []string,
 ) (*github.PullRequest, error) {
 	// Create GitHub client with token
-	token, err := util.ResolveSecretValue(*githubConfig.Token)
+	token, err := ResolveToken(ctx, githubConfig, owner)
 	if err != nil {
 		return nil, err
 	}
 
-	client := CreateGitHubClient(ctx, token)
+	client, err := ConfigEndpoints(githubConfig).TokenClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
 	// First, check if there's an existing PR for this branch
 	existingPRs, _, err := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
 		Head:  fmt.Sprintf("%s:%s", owner, branch),
-		Base:  "main",
+		Base:  base,
 		State: "open",
 	})
 	if err != nil {
@@ -80,9 +102,10 @@ func CreateOrUpdatePullRequest(ctx context.Context,
 	}
 
 	if len(existingPRs) > 0 {
-		// Update existing PR
+		// Update existing PR, keeping the sections of the body written by other runs
 		pr := existingPRs[0]
-		if pr.Title != nil && *pr.Title != title || pr.Body != nil && *pr.Body != body {
+		body = MergeBody(pr.GetBody(), body)
+		if pr.GetTitle() != title || pr.GetBody() != body {
 			pr, _, err = client.PullRequests.Edit(ctx, owner, repo, pr.GetNumber(), &github.PullRequest{
 				Title: github.String(title),
 				Body:  github.String(body),
@@ -91,6 +114,7 @@ func CreateOrUpdatePullRequest(ctx context.Context,
 				return nil, fmt.Errorf("failed to update pull request: %w", err)
 			}
 		}
+		addLabels(ctx, client, owner, repo, pr, labels)
 		return pr, nil
 	}
 
@@ -98,7 +122,7 @@ func CreateOrUpdatePullRequest(ctx context.Context,
 	newPR := &github.NewPullRequest{
 		Title:               github.String(title),
 		Head:                github.String(branch),
-		Base:                github.String("main"),
+		Base:                github.String(base),
 		Body:                github.String(body),
 		MaintainerCanModify: github.Bool(true),
 	}
@@ -107,21 +131,116 @@ func CreateOrUpdatePullRequest(ctx context.Context,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pull request: %w", err)
 	}
-	if labels != nil {
-		// Add "new-service" label to the new PR
-		_, _, err = client.Issues.AddLabelsToIssue(ctx, owner, repo, pr.GetNumber(), labels)
-		if err != nil {
-			// Just log the error but don't fail the PR creation
-			fmt.Printf("Warning: failed to add label to PR: %v\n", err)
+	addLabels(ctx, client, owner, repo, pr, labels)
+
+	return pr, nil
+}
+
+// CommentOnPullRequest comments on the open pull request of a branch, unless a comment containing the
+// marker already exists. Nothing is commented when the branch has no open pull request.
+func CommentOnPullRequest(ctx context.Context,
+	owner, repo, branch, marker, body string,
+	githubConfig manifest.GitHubConfig,
+) error {
+	token, err := ResolveToken(ctx, githubConfig, owner)
+	if err != nil {
+		return err
+	}
+
+	client, err := ConfigEndpoints(githubConfig).TokenClient(ctx, token)
+	if err != nil {
+		return err
+	}
+	prs, _, err := client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		Head:  fmt.Sprintf("%s:%s", owner, branch),
+		State: "open",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	if len(prs) == 0 {
+		return nil
+	}
+
+	number := prs[0].GetNumber()
+	comments, _, err := client.Issues.ListComments(ctx, owner, repo, number, &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+	for _, c := range comments {
+		if strings.Contains(c.GetBody(), marker) {
+			return nil
 		}
 	}
 
-	return pr, nil
+	_, _, err = client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{
+		Body: github.String(marker + "\n" + body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to comment on pull request: %w", err)
+	}
+	return nil
+}
+
+// RequestReviewers requests the review of a pull request from users and from teams of the organization
+// owning the repository
+func RequestReviewers(ctx context.Context,
+	owner, repo string,
+	number int,
+	users, teams []string,
+	githubConfig manifest.GitHubConfig,
+) error {
+	if len(users) == 0 && len(teams) == 0 {
+		return nil
+	}
+	token, err := ResolveToken(ctx, githubConfig, owner)
+	if err != nil {
+		return err
+	}
+
+	client, err := ConfigEndpoints(githubConfig).TokenClient(ctx, token)
+	if err != nil {
+		return err
+	}
+	_, _, err = client.PullRequests.RequestReviewers(ctx, owner, repo, number, github.ReviewersRequest{
+		Reviewers:     users,
+		TeamReviewers: teams,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request reviewers: %w", err)
+	}
+	return nil
+}
+
+// addLabels adds the labels missing from a pull request
+func addLabels(ctx context.Context, client *github.Client, owner, repo string, pr *github.PullRequest, labels []string) {
+	existing := map[string]bool{}
+	for _, l := range pr.Labels {
+		existing[l.GetName()] = true
+	}
+	var missing []string
+	for _, l := range labels {
+		if !existing[l] {
+			missing = append(missing, l)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	if _, _, err := client.Issues.AddLabelsToIssue(ctx, owner, repo, pr.GetNumber(), missing); err != nil {
+		// Just log the error but don't fail the PR creation
+		fmt.Printf("Warning: failed to add label to PR: %v\n", err)
+	}
 }
 
 // GetUserProfile gets the user profile from GitHub
 func (s *GitHubService) GetUserProfile(ctx context.Context, token string) (UserProfile, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return UserProfile{}, err
+	}
 
 	// Get user information
 	user, _, err := client.Users.Get(ctx, "")
@@ -168,7 +287,10 @@ func (s *GitHubService) getUserPrimaryEmail(ctx context.Context, client *github.
 
 // GetRepositories gets repositories for the user
 func (s *GitHubService) GetRepositories(ctx context.Context, token, visibility, affiliation string) ([]Repository, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set up options for listing repositories
 	opts := &github.RepositoryListOptions{
@@ -201,7 +323,10 @@ func (s *GitHubService) GetRepositories(ctx context.Context, token, visibility,
 
 // GetOrganizationRepositories gets repositories for an organization
 func (s *GitHubService) GetOrganizationRepositories(ctx context.Context, token, org, visibility string) ([]Repository, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set up options for listing repositories
 	opts := &github.RepositoryListByOrgOptions{
@@ -259,7 +384,10 @@ func (s *GitHubService) GetRepositoryContent(ctx context.Context, token, owner,
 		return nil, err
 	}
 
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 	// Continue with sanitizedPath instead of path
 	fileContent, directoryContent, _, err := client.Repositories.GetContents(
 		ctx,
@@ -320,7 +448,10 @@ func (s *GitHubService) GetRepositoryContent(ctx context.Context, token, owner,
 
 // GetRepositoryBranches gets branches for a repository
 func (s *GitHubService) GetRepositoryBranches(ctx context.Context, token, owner, repo string) ([]Branch, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
 	// First get the repository to find the default branch
 	repository, _, err := client.Repositories.Get(ctx, owner, repo)
@@ -365,27 +496,7 @@ func (s *GitHubService) GetRepositoryBranches(ctx context.Context, token, owner,
 // GetEnterpriseOrganizations gets organizations for a GitHub Enterprise instance
 func (s *GitHubService) GetEnterpriseOrganizations(ctx context.Context, token, enterpriseServer string) ([]*github.Organization, error) {
 	// For GitHub Enterprise, we need to create a custom client with the enterprise server URL
-	if !strings.HasPrefix(enterpriseServer, "https://") {
-		enterpriseServer = "https://" + enterpriseServer
-	}
-
-	// Make sure the URL ends with a trailing slash
-	if !strings.HasSuffix(enterpriseServer, "/") {
-		enterpriseServer = enterpriseServer + "/"
-	}
-
-	// Create a token source
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-
-	// Construct the API and upload URLs for GitHub Enterprise
-	baseAPIURL := enterpriseServer + "api/v3/"
-	uploadURL := enterpriseServer + "api/uploads/"
-
-	// Create the client using the new builder pattern approach
-	client, err := github.NewClient(tc).WithEnterpriseURLs(baseAPIURL, uploadURL)
+	client, err := NewEndpoints(enterpriseServer, "", "").TokenClient(ctx, token)
 	if err != nil {
 		return nil, err
 	}
@@ -414,7 +525,10 @@ func (s *GitHubService) GetEnterpriseOrganizations(ctx context.Context, token, e
 
 // IsRepositoryAccessible checks if a repository is accessible
 func (s *GitHubService) IsRepositoryAccessible(ctx context.Context, token, owner, repo string) (bool, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return false, err
+	}
 
 	// Try to get the repository
 	_, resp, err := client.Repositories.Get(ctx, owner, repo)
@@ -428,16 +542,9 @@ func (s *GitHubService) IsRepositoryAccessible(ctx context.Context, token, owner
 	return true, nil
 }
 
-// createClient creates a GitHub client with the token
-func (s *GitHubService) createClient(ctx context.Context, token string) *github.Client {
-	// Create a token source
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-
-	// Create a GitHub client
-	return github.NewClient(tc)
+// createClient creates a client of the GitHub instance of the service with the token
+func (s *GitHubService) createClient(ctx context.Context, token string) (*github.Client, error) {
+	return s.endpoints.TokenClient(ctx, token)
 }
 
 // mapRepository maps a GitHub repository to our model
@@ -469,7 +576,10 @@ func mapRepository(repo *github.Repository) Repository {
 
 // GetUserOrganizations gets organizations for the authenticated user
 func (s *GitHubService) GetUserOrganizations(ctx context.Context, token string) ([]Organization, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set up options for listing organizations
 	opts := &github.ListOptions{PerPage: 100}
@@ -504,7 +614,10 @@ func (s *GitHubService) GetUserOrganizations(ctx context.Context, token string)
 
 // GetUserRepositories gets repositories for a specific user
 func (s *GitHubService) GetUserRepositories(ctx context.Context, token, username, visibility string) ([]Repository, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set up options for listing repositories
 	opts := &github.RepositoryListByUserOptions{
@@ -551,12 +664,15 @@ func naclEncrypt(publicKey string, secretValue string) string {
 }
 
 func CreateRepoActionSecretFromString(ctx context.Context, githubConfig *manifest.GitHubConfig, owner, repo, secretName, secretValue string) error {
-	token, err := util.ResolveSecretValue(*githubConfig.Token)
+	token, err := ResolveToken(ctx, *githubConfig, owner)
 	if err != nil {
 		return err
 	}
 
-	client := CreateGitHubClient(ctx, token)
+	client, err := ConfigEndpoints(*githubConfig).TokenClient(ctx, token)
+	if err != nil {
+		return err
+	}
 
 	repoPubKey, _, err := client.Actions.GetRepoPublicKey(ctx, owner, repo)
 	if err != nil {
@@ -589,20 +705,18 @@ func CreateRepoActionSecret(ctx context.Context, githubConfig *manifest.GitHubCo
 }
 
 func (s *GitHubService) GetActionsSecrets(ctx context.Context, token, owner, repo string) ([]Secrets, error) {
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 	opts := &github.ListOptions{PerPage: 100}
-	_, _, err := client.Actions.ListRepoSecrets(ctx, owner, repo, opts)
+	_, _, err = client.Actions.ListRepoSecrets(ctx, owner, repo, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list secrets: %w", err)
 	}
 	return nil, nil
 }
 
-// IsEnterpriseURL checks if a URL is for a GitHub Enterprise instance
-func IsEnterpriseURL(url string) bool {
-	return !strings.Contains(url, "github.com")
-}
-
 // decodeContent decodes base64 encoded content
 func decodeContent(content string) (string, error) {
 	// GitHub API returns content with newlines, which need to be removed for base64 decoding
@@ -617,7 +731,10 @@ func decodeContent(content string) (string, error) {
 // GetRepositoryPullRequests fetches pull requests for a repository from GitHub
 func (s *GitHubService) GetRepositoryPullRequests(ctx context.Context, token, owner, repo string, labels []string) ([]PullRequest, error) {
 	// Get the GitHub client
-	client := s.createClient(ctx, token)
+	client, err := s.createClient(ctx, token)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set up the options for the GitHub API call using Issues API
 	opts := &github.IssueListByRepoOptions{
@@ -0,0 +1,260 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the GitLab API responds with 404 Not Found
+var ErrNotFound = errors.New("not found")
+
+// Client calls the GitLab REST API v4 with a personal, group or project access token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the GitLab API at baseURL, e.g. https://gitlab.com/api/v4
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// MergeRequest is the subset of the GitLab merge request fields used by the orchestrator
+type MergeRequest struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	WebURL      string   `json:"web_url"`
+	Labels      []string `json:"labels"`
+	Reviewers   []User   `json:"reviewers"`
+}
+
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type Note struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+type Namespace struct {
+	ID       int    `json:"id"`
+	FullPath string `json:"full_path"`
+}
+
+// project returns the API path of a project, addressed by its URL encoded full path
+func project(path string) string {
+	return "/projects/" + url.PathEscape(path)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// GetNamespace returns a group or user namespace by its full path
+func (c *Client) GetNamespace(ctx context.Context, path string) (*Namespace, error) {
+	var ns Namespace
+	if err := c.do(ctx, http.MethodGet, "/namespaces/"+url.PathEscape(path), nil, &ns); err != nil {
+		return nil, err
+	}
+	return &ns, nil
+}
+
+// CreateProject creates a project with an initial commit in a namespace
+func (c *Client) CreateProject(ctx context.Context, namespaceID int, name string) error {
+	return c.do(ctx, http.MethodPost, "/projects", map[string]interface{}{
+		"name":                   name,
+		"path":                   name,
+		"namespace_id":           namespaceID,
+		"initialize_with_readme": true,
+	}, nil)
+}
+
+// FindMergeRequest returns the open merge request of a source branch into a target branch, or nil
+func (c *Client) FindMergeRequest(ctx context.Context, projectPath, source, target string) (*MergeRequest, error) {
+	q := url.Values{}
+	q.Set("state", "opened")
+	q.Set("source_branch", source)
+	q.Set("target_branch", target)
+	var mrs []MergeRequest
+	if err := c.do(ctx, http.MethodGet, project(projectPath)+"/merge_requests?"+q.Encode(), nil, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return &mrs[0], nil
+}
+
+// CreateMergeRequest opens a merge request of a source branch into a target branch
+func (c *Client) CreateMergeRequest(ctx context.Context,
+	projectPath, source, target, title, description string,
+	labels []string,
+) (*MergeRequest, error) {
+	var mr MergeRequest
+	err := c.do(ctx, http.MethodPost, project(projectPath)+"/merge_requests", map[string]interface{}{
+		"source_branch":        source,
+		"target_branch":        target,
+		"title":                title,
+		"description":          description,
+		"labels":               strings.Join(labels, ","),
+		"remove_source_branch": true,
+	}, &mr)
+	if err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// UpdateMergeRequest sets fields of a merge request, e.g. title, description, add_labels or reviewer_ids
+func (c *Client) UpdateMergeRequest(ctx context.Context,
+	projectPath string,
+	iid int,
+	fields map[string]interface{},
+) (*MergeRequest, error) {
+	var mr MergeRequest
+	path := fmt.Sprintf("%s/merge_requests/%d", project(projectPath), iid)
+	if err := c.do(ctx, http.MethodPut, path, fields, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// ListNotes returns the comments of a merge request
+func (c *Client) ListNotes(ctx context.Context, projectPath string, iid int) ([]Note, error) {
+	var notes []Note
+	path := fmt.Sprintf("%s/merge_requests/%d/notes?per_page=100", project(projectPath), iid)
+	if err := c.do(ctx, http.MethodGet, path, nil, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// CreateNote comments on a merge request
+func (c *Client) CreateNote(ctx context.Context, projectPath string, iid int, body string) error {
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", project(projectPath), iid)
+	return c.do(ctx, http.MethodPost, path, map[string]string{"body": body}, nil)
+}
+
+// UserID returns the id of a user by username
+func (c *Client) UserID(ctx context.Context, username string) (int, error) {
+	var users []User
+	if err := c.do(ctx, http.MethodGet, "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("user %s: %w", username, ErrNotFound)
+	}
+	return users[0].ID, nil
+}
+
+// maskablePattern matches the values GitLab can mask in job logs: at least 8 characters of the Base64
+// alphabet, including its URL safe variant, and @, :, . or ~
+var maskablePattern = regexp.MustCompile(`^[A-Za-z0-9+/=_@:.~-]{8,}$`)
+
+// Maskable reports whether GitLab accepts to mask a variable value, it rejects masked variables otherwise
+func Maskable(value string) bool {
+	return maskablePattern.MatchString(value)
+}
+
+// SetVariable creates or updates a CI/CD variable of a project, masked if the value is Maskable
+func (c *Client) SetVariable(ctx context.Context, projectPath, key, value string) error {
+	path := project(projectPath) + "/variables"
+	fields := map[string]interface{}{
+		"key":    key,
+		"value":  value,
+		"masked": Maskable(value),
+	}
+	err := c.do(ctx, http.MethodGet, path+"/"+url.PathEscape(key), nil, nil)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return c.do(ctx, http.MethodPost, path, fields, nil)
+	case err != nil:
+		return err
+	}
+	return c.do(ctx, http.MethodPut, path+"/"+url.PathEscape(key), fields, nil)
+}
+
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Description       string `json:"description"`
+	Visibility        string `json:"visibility"`
+	WebURL            string `json:"web_url"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	SSHURLToRepo      string `json:"ssh_url_to_repo"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+// ListProjects returns the projects of a group, or of a user when no group has the path
+func (c *Client) ListProjects(ctx context.Context, namespace string) ([]Project, error) {
+	const perPage = 100
+	base := "/groups/" + url.PathEscape(namespace) + "/projects"
+	var all []Project
+	for page := 1; ; page++ {
+		var projects []Project
+		err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=%d&page=%d", base, perPage, page), nil, &projects)
+		if errors.Is(err, ErrNotFound) && page == 1 && strings.HasPrefix(base, "/groups/") {
+			base = "/users/" + url.PathEscape(namespace) + "/projects"
+			page--
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, projects...)
+		if len(projects) < perPage {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+)
+
+// CreateProject creates a project in the group or user namespace holding it, with an initial commit
+func CreateProject(ctx context.Context, client *Client, projectPath string) error {
+	i := strings.LastIndex(projectPath, "/")
+	if i < 0 {
+		return fmt.Errorf("project path %s has no namespace", projectPath)
+	}
+	ns, err := client.GetNamespace(ctx, projectPath[:i])
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", projectPath[:i], err)
+	}
+	if err := client.CreateProject(ctx, ns.ID, projectPath[i+1:]); err != nil {
+		return fmt.Errorf("failed to create project %s: %w", projectPath, err)
+	}
+	return nil
+}
+
+// CreateOrUpdateMergeRequest opens a merge request of a branch or updates the open one, keeping the
+// sections of the description written by other runs and adding the missing labels
+func CreateOrUpdateMergeRequest(ctx context.Context,
+	client *Client,
+	projectPath, branch, base, title, description string,
+	labels []string,
+) (*MergeRequest, error) {
+	mr, err := client.FindMergeRequest(ctx, projectPath, branch, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+	if mr == nil {
+		mr, err = client.CreateMergeRequest(ctx, projectPath, branch, base, title, description, labels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create merge request: %w", err)
+		}
+		return mr, nil
+	}
+
+	description = github.MergeBody(mr.Description, description)
+	fields := map[string]interface{}{}
+	if mr.Title != title {
+		fields["title"] = title
+	}
+	if mr.Description != description {
+		fields["description"] = description
+	}
+	if missing := missingLabels(mr.Labels, labels); len(missing) > 0 {
+		fields["add_labels"] = strings.Join(missing, ",")
+	}
+	if len(fields) == 0 {
+		return mr, nil
+	}
+	mr, err = client.UpdateMergeRequest(ctx, projectPath, mr.IID, fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update merge request: %w", err)
+	}
+	return mr, nil
+}
+
+// CommentOnMergeRequest comments on the open merge request of a branch, unless a comment containing the
+// marker already exists. Nothing is commented when the branch has no open merge request.
+func CommentOnMergeRequest(ctx context.Context, client *Client, projectPath, branch, base, marker, body string) error {
+	mr, err := client.FindMergeRequest(ctx, projectPath, branch, base)
+	if err != nil {
+		return fmt.Errorf("failed to list merge requests: %w", err)
+	}
+	if mr == nil {
+		return nil
+	}
+
+	notes, err := client.ListNotes(ctx, projectPath, mr.IID)
+	if err != nil {
+		return fmt.Errorf("failed to list merge request comments: %w", err)
+	}
+	for _, n := range notes {
+		if strings.Contains(n.Body, marker) {
+			return nil
+		}
+	}
+	if err := client.CreateNote(ctx, projectPath, mr.IID, marker+"\n"+body); err != nil {
+		return fmt.Errorf("failed to comment on merge request: %w", err)
+	}
+	return nil
+}
+
+// RequestReviewers adds users to the reviewers of a merge request. GitLab has no group reviewers, so the
+// code owners of the project should be used to require the approval of groups.
+func RequestReviewers(ctx context.Context, client *Client, projectPath string, iid int, users, groups []string) error {
+	if len(users) == 0 && len(groups) == 0 {
+		return nil
+	}
+	var reviewerIDs []int
+	for _, u := range users {
+		id, err := client.UserID(ctx, u)
+		if err != nil {
+			return fmt.Errorf("failed to find reviewer %s: %w", u, err)
+		}
+		reviewerIDs = append(reviewerIDs, id)
+	}
+	if len(reviewerIDs) > 0 {
+		_, err := client.UpdateMergeRequest(ctx, projectPath, iid, map[string]interface{}{
+			"reviewer_ids": reviewerIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to request reviewers: %w", err)
+		}
+	}
+	if len(groups) > 0 {
+		return fmt.Errorf("group reviewers %s aren't supported by GitLab merge requests, "+
+			"use the code owners of the project instead", strings.Join(groups, ", "))
+	}
+	return nil
+}
+
+func missingLabels(existing, labels []string) []string {
+	has := map[string]bool{}
+	for _, l := range existing {
+		has[l] = true
+	}
+	var missing []string
+	for _, l := range labels {
+		if !has[l] {
+			missing = append(missing, l)
+		}
+	}
+	return missing
+}
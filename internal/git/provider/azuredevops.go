@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/azuredevops"
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+type azureDevOpsProvider struct {
+	remote       git.Remote
+	client       *azuredevops.Client
+	organization string
+	project      string
+	repo         string
+	mainBranch   string
+}
+
+// newAzureDevOpsProvider calls the Azure DevOps API of the organization of the remote with the configured
+// personal access token, falling back to the AZURE_DEVOPS_TOKEN environment variable
+func newAzureDevOpsProvider(remote git.Remote, gitConfig manifest.GitConfig) (Provider, error) {
+	organization, project, repo, err := azuredevops.ParsePath(remote.Path)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := token(gitConfig, remote)
+	if err != nil {
+		return nil, err
+	}
+	apiURL := azuredevops.DefaultAPIURL
+	if config := gitConfig.AzureDevOps; config != nil && config.APIURL != nil && *config.APIURL != "" {
+		apiURL = *config.APIURL
+	}
+	return &azureDevOpsProvider{
+		remote:       remote,
+		client:       azuredevops.NewClient(apiURL, organization, tok),
+		organization: organization,
+		project:      project,
+		repo:         repo,
+		mainBranch:   gitConfig.BaseBranchName(),
+	}, nil
+}
+
+func (p *azureDevOpsProvider) Name() string {
+	return git.ProviderAzureDevOps
+}
+
+func (p *azureDevOpsProvider) Remote() git.Remote {
+	return p.remote
+}
+
+func (p *azureDevOpsProvider) CreateRepository(ctx context.Context) error {
+	return azuredevops.CreateRepository(ctx, p.client, p.project, p.repo, p.mainBranch)
+}
+
+func (p *azureDevOpsProvider) CreateOrUpdatePullRequest(ctx context.Context,
+	branch, base, title, body string,
+	labels []string,
+) (*PullRequest, error) {
+	pr, err := azuredevops.CreateOrUpdatePullRequest(ctx, p.client, p.project, p.repo, branch, base, title, body, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: pr.ID, URL: fmt.Sprintf("%s/pullrequest/%d", p.remote.WebURL(), pr.ID)}, nil
+}
+
+// RequestReviewers adds reviewers given by identity id, Azure Repos doesn't resolve user or group names
+func (p *azureDevOpsProvider) RequestReviewers(ctx context.Context, number int, users, teams []string) error {
+	return azuredevops.RequestReviewers(ctx, p.client, p.project, p.repo, number, users, teams)
+}
+
+func (p *azureDevOpsProvider) CommentOnPullRequest(ctx context.Context, branch, base, marker, body string) error {
+	return azuredevops.CommentOnPullRequest(ctx, p.client, p.project, p.repo, branch, base, marker, body)
+}
+
+// SetSecret sets a secret variable of the variable group named after the repository
+func (p *azureDevOpsProvider) SetSecret(ctx context.Context, name, value string) error {
+	if err := azuredevops.SetVariable(ctx, p.client, p.project, p.repo, name, value); err != nil {
+		return fmt.Errorf("failed to set pipelines variable %s: %w", name, err)
+	}
+	return nil
+}
+
+func (p *azureDevOpsProvider) ListRepositories(ctx context.Context) ([]github.Repository, error) {
+	repositories, err := p.client.ListRepositories(ctx, p.project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s/%s: %w", p.organization, p.project, err)
+	}
+	repos := make([]github.Repository, 0, len(repositories))
+	for _, repository := range repositories {
+		r := github.Repository{
+			Name:          repository.Name,
+			FullName:      p.project + "/" + repository.Name,
+			Private:       repository.Project.Visibility != "public",
+			HTMLURL:       repository.WebURL,
+			CloneURL:      repository.RemoteURL,
+			DefaultBranch: strings.TrimPrefix(repository.DefaultBranch, "refs/heads/"),
+		}
+		r.Owner.Login = p.organization
+		repos = append(repos, r)
+	}
+	return repos, nil
+}
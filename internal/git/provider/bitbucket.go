@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/bitbucket"
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// BitbucketCodeOwnersPath is the code owners file of Bitbucket repositories
+const BitbucketCodeOwnersPath = ".bitbucket/CODEOWNERS"
+
+type bitbucketProvider struct {
+	remote     git.Remote
+	client     *bitbucket.Client
+	mainBranch string
+}
+
+// newBitbucketProvider calls the Bitbucket Cloud API with the configured token, or app password when a
+// username is configured, falling back to the BITBUCKET_TOKEN environment variable
+func newBitbucketProvider(remote git.Remote, gitConfig manifest.GitConfig) (Provider, error) {
+	tok, err := token(gitConfig, remote)
+	if err != nil {
+		return nil, err
+	}
+	apiURL, username := bitbucket.DefaultAPIURL, ""
+	if config := gitConfig.Bitbucket; config != nil {
+		if config.APIURL != nil && *config.APIURL != "" {
+			apiURL = *config.APIURL
+		}
+		if config.Username != nil {
+			username = *config.Username
+		}
+	}
+	return &bitbucketProvider{
+		remote:     remote,
+		client:     bitbucket.NewClient(apiURL, username, tok),
+		mainBranch: gitConfig.BaseBranchName(),
+	}, nil
+}
+
+func (p *bitbucketProvider) Name() string {
+	return git.ProviderBitbucket
+}
+
+func (p *bitbucketProvider) Remote() git.Remote {
+	return p.remote
+}
+
+func (p *bitbucketProvider) CreateRepository(ctx context.Context) error {
+	return bitbucket.CreateRepository(ctx, p.client, p.remote.Owner(), p.remote.Name(), p.mainBranch)
+}
+
+func (p *bitbucketProvider) CreateOrUpdatePullRequest(ctx context.Context,
+	branch, base, title, body string,
+	_ []string,
+) (*PullRequest, error) {
+	pr, err := bitbucket.CreateOrUpdatePullRequest(ctx, p.client, p.remote.Owner(), p.remote.Name(),
+		branch, base, title, body)
+	if err != nil {
+		return nil, err
+	}
+	result := &PullRequest{Number: pr.ID}
+	if pr.Links != nil {
+		result.URL = pr.Links.HTML.Href
+	}
+	return result, nil
+}
+
+func (p *bitbucketProvider) RequestReviewers(ctx context.Context, number int, users, teams []string) error {
+	return bitbucket.RequestReviewers(ctx, p.client, p.remote.Owner(), p.remote.Name(), number, users, teams)
+}
+
+func (p *bitbucketProvider) CommentOnPullRequest(ctx context.Context, branch, base, marker, body string) error {
+	return bitbucket.CommentOnPullRequest(ctx, p.client, p.remote.Owner(), p.remote.Name(), branch, base, marker, body)
+}
+
+func (p *bitbucketProvider) SetSecret(ctx context.Context, name, value string) error {
+	if err := bitbucket.SetVariable(ctx, p.client, p.remote.Owner(), p.remote.Name(), name, value); err != nil {
+		return fmt.Errorf("failed to set pipelines variable %s: %w", name, err)
+	}
+	return nil
+}
+
+func (p *bitbucketProvider) ListRepositories(ctx context.Context) ([]github.Repository, error) {
+	repositories, err := p.client.ListRepositories(ctx, p.remote.Owner())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %w", p.remote.Owner(), err)
+	}
+	repos := make([]github.Repository, 0, len(repositories))
+	for _, repository := range repositories {
+		r := github.Repository{
+			Name:     repository.Name,
+			FullName: repository.FullName,
+			Private:  repository.IsPrivate,
+			HTMLURL:  repository.Links.HTML.Href,
+		}
+		for _, clone := range repository.Links.Clone {
+			switch clone.Name {
+			case "https":
+				r.CloneURL = clone.Href
+			case "ssh":
+				r.SSHURL = clone.Href
+			}
+		}
+		if repository.MainBranch != nil {
+			r.DefaultBranch = repository.MainBranch.Name
+		}
+		r.Owner.Login = p.remote.Owner()
+		repos = append(repos, r)
+	}
+	return repos, nil
+}
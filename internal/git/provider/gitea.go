@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/gitea"
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// GiteaCodeOwnersPath is the code owners file of Gitea and Forgejo repositories
+const GiteaCodeOwnersPath = ".gitea/CODEOWNERS"
+
+type giteaProvider struct {
+	remote     git.Remote
+	client     *gitea.Client
+	mainBranch string
+}
+
+// newGiteaProvider calls the API of the remote host with the configured token, or password when a username
+// is configured, falling back to the GITEA_TOKEN environment variable
+func newGiteaProvider(remote git.Remote, gitConfig manifest.GitConfig) (Provider, error) {
+	tok, err := token(gitConfig, remote)
+	if err != nil {
+		return nil, err
+	}
+	apiURL, username := fmt.Sprintf("https://%s/api/v1", remote.Host), ""
+	if config := gitConfig.Gitea; config != nil {
+		if config.APIURL != nil && *config.APIURL != "" {
+			apiURL = *config.APIURL
+		}
+		if config.Username != nil {
+			username = *config.Username
+		}
+	}
+	return &giteaProvider{
+		remote:     remote,
+		client:     gitea.NewClient(apiURL, username, tok),
+		mainBranch: gitConfig.BaseBranchName(),
+	}, nil
+}
+
+func (p *giteaProvider) Name() string {
+	return git.ProviderGitea
+}
+
+func (p *giteaProvider) Remote() git.Remote {
+	return p.remote
+}
+
+func (p *giteaProvider) CreateRepository(ctx context.Context) error {
+	return gitea.CreateRepository(ctx, p.client, p.remote.Owner(), p.remote.Name(), p.mainBranch)
+}
+
+func (p *giteaProvider) CreateOrUpdatePullRequest(ctx context.Context,
+	branch, base, title, body string,
+	labels []string,
+) (*PullRequest, error) {
+	pr, err := gitea.CreateOrUpdatePullRequest(ctx, p.client, p.remote.Owner(), p.remote.Name(),
+		branch, base, title, body, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: pr.Number, URL: pr.HTMLURL}, nil
+}
+
+func (p *giteaProvider) RequestReviewers(ctx context.Context, number int, users, teams []string) error {
+	if len(users) == 0 && len(teams) == 0 {
+		return nil
+	}
+	if err := p.client.RequestReviewers(ctx, p.remote.Owner(), p.remote.Name(), number, users, teams); err != nil {
+		return fmt.Errorf("failed to request reviewers: %w", err)
+	}
+	return nil
+}
+
+func (p *giteaProvider) CommentOnPullRequest(ctx context.Context, branch, base, marker, body string) error {
+	return gitea.CommentOnPullRequest(ctx, p.client, p.remote.Owner(), p.remote.Name(), branch, base, marker, body)
+}
+
+func (p *giteaProvider) SetSecret(ctx context.Context, name, value string) error {
+	if err := p.client.SetSecret(ctx, p.remote.Owner(), p.remote.Name(), name, value); err != nil {
+		return fmt.Errorf("failed to set Actions secret %s: %w", name, err)
+	}
+	return nil
+}
+
+func (p *giteaProvider) ListRepositories(ctx context.Context) ([]github.Repository, error) {
+	repositories, err := p.client.ListRepositories(ctx, p.remote.Owner())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %w", p.remote.Owner(), err)
+	}
+	repos := make([]github.Repository, 0, len(repositories))
+	for _, repository := range repositories {
+		r := github.Repository{
+			ID:            repository.ID,
+			Name:          repository.Name,
+			FullName:      repository.FullName,
+			Description:   repository.Description,
+			Private:       repository.Private,
+			HTMLURL:       repository.HTMLURL,
+			CloneURL:      repository.CloneURL,
+			SSHURL:        repository.SSHURL,
+			DefaultBranch: repository.DefaultBranch,
+			IsEnterprise:  true,
+		}
+		r.Owner.Login = repository.Owner.Login
+		r.Owner.ID = repository.Owner.ID
+		repos = append(repos, r)
+	}
+	return repos, nil
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+type gitHubProvider struct {
+	remote git.Remote
+	config manifest.GitHubConfig
+}
+
+func (p *gitHubProvider) Name() string {
+	return git.ProviderGitHub
+}
+
+func (p *gitHubProvider) Remote() git.Remote {
+	return p.remote
+}
+
+func (p *gitHubProvider) CreateRepository(ctx context.Context) error {
+	return github.CreateRepo(ctx, p.remote.Owner(), p.remote.Name(), p.config)
+}
+
+func (p *gitHubProvider) CreateOrUpdatePullRequest(ctx context.Context,
+	branch, base, title, body string,
+	labels []string,
+) (*PullRequest, error) {
+	pr, err := github.CreateOrUpdatePullRequest(ctx,
+		p.remote.Owner(), p.remote.Name(), branch, base, title, body, p.config, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: pr.GetNumber(), URL: pr.GetHTMLURL()}, nil
+}
+
+func (p *gitHubProvider) RequestReviewers(ctx context.Context, number int, users, teams []string) error {
+	return github.RequestReviewers(ctx, p.remote.Owner(), p.remote.Name(), number, users, teams, p.config)
+}
+
+func (p *gitHubProvider) CommentOnPullRequest(ctx context.Context, branch, _, marker, body string) error {
+	return github.CommentOnPullRequest(ctx, p.remote.Owner(), p.remote.Name(), branch, marker, body, p.config)
+}
+
+func (p *gitHubProvider) SetSecret(ctx context.Context, name, value string) error {
+	return github.CreateRepoActionSecretFromString(ctx, &p.config, p.remote.Owner(), p.remote.Name(), name, value)
+}
+
+// ListRepositories lists the repositories of the organization, or of the user, owning the repository
+func (p *gitHubProvider) ListRepositories(ctx context.Context) ([]github.Repository, error) {
+	token, err := github.ResolveToken(ctx, p.config, p.remote.Owner())
+	if err != nil {
+		return nil, err
+	}
+	s := github.NewGitHubService(nil).WithEndpoints(github.ConfigEndpoints(p.config))
+	repos, err := s.GetOrganizationRepositories(ctx, token, p.remote.Owner(), "all")
+	if err != nil {
+		repos, err = s.GetUserRepositories(ctx, token, p.remote.Owner(), "all")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of %s: %w", p.remote.Owner(), err)
+	}
+	return repos, nil
+}
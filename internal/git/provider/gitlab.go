@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/git/gitlab"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/util"
+)
+
+// GitLabCodeOwnersPath is the code owners file of GitLab projects
+const GitLabCodeOwnersPath = ".gitlab/CODEOWNERS"
+
+type gitLabProvider struct {
+	remote git.Remote
+	client *gitlab.Client
+}
+
+// newGitLabProvider calls the API of the remote host with the configured token, falling back to the
+// GITLAB_TOKEN environment variable
+func newGitLabProvider(remote git.Remote, config *manifest.GitLabConfig) (Provider, error) {
+	apiURL := fmt.Sprintf("https://%s/api/v4", remote.Host)
+	token := os.Getenv("GITLAB_TOKEN")
+	if config != nil {
+		if config.APIURL != nil && *config.APIURL != "" {
+			apiURL = *config.APIURL
+		}
+		if config.Token != nil {
+			var err error
+			if token, err = util.ResolveSecretValue(*config.Token); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("gitlab token is required for %s", remote.WebURL())
+	}
+	return &gitLabProvider{remote: remote, client: gitlab.NewClient(apiURL, token)}, nil
+}
+
+func (p *gitLabProvider) Name() string {
+	return git.ProviderGitLab
+}
+
+func (p *gitLabProvider) Remote() git.Remote {
+	return p.remote
+}
+
+func (p *gitLabProvider) CreateRepository(ctx context.Context) error {
+	return gitlab.CreateProject(ctx, p.client, p.remote.Path)
+}
+
+func (p *gitLabProvider) CreateOrUpdatePullRequest(ctx context.Context,
+	branch, base, title, body string,
+	labels []string,
+) (*PullRequest, error) {
+	mr, err := gitlab.CreateOrUpdateMergeRequest(ctx, p.client, p.remote.Path, branch, base, title, body, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: mr.IID, URL: mr.WebURL}, nil
+}
+
+func (p *gitLabProvider) RequestReviewers(ctx context.Context, number int, users, teams []string) error {
+	return gitlab.RequestReviewers(ctx, p.client, p.remote.Path, number, users, teams)
+}
+
+func (p *gitLabProvider) CommentOnPullRequest(ctx context.Context, branch, base, marker, body string) error {
+	return gitlab.CommentOnMergeRequest(ctx, p.client, p.remote.Path, branch, base, marker, body)
+}
+
+func (p *gitLabProvider) SetSecret(ctx context.Context, name, value string) error {
+	if !gitlab.Maskable(value) {
+		fmt.Printf("Warning: the value of CI/CD variable %s can't be masked by GitLab, it is stored unmasked\n", name)
+	}
+	if err := p.client.SetVariable(ctx, p.remote.Path, name, value); err != nil {
+		return fmt.Errorf("failed to set CI/CD variable %s: %w", name, err)
+	}
+	return nil
+}
+
+func (p *gitLabProvider) ListRepositories(ctx context.Context) ([]github.Repository, error) {
+	projects, err := p.client.ListProjects(ctx, p.remote.Owner())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects of %s: %w", p.remote.Owner(), err)
+	}
+	repos := make([]github.Repository, 0, len(projects))
+	for _, project := range projects {
+		r := github.Repository{
+			ID:            int64(project.ID),
+			Name:          project.Name,
+			FullName:      project.PathWithNamespace,
+			Description:   project.Description,
+			Private:       project.Visibility != "public",
+			HTMLURL:       project.WebURL,
+			CloneURL:      project.HTTPURLToRepo,
+			SSHURL:        project.SSHURLToRepo,
+			DefaultBranch: project.DefaultBranch,
+			IsEnterprise:  p.remote.Host != "gitlab.com",
+		}
+		r.Owner.Login = p.remote.Owner()
+		repos = append(repos, r)
+	}
+	return repos, nil
+}
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// PullRequest is a pull request, or a GitLab merge request
+type PullRequest struct {
+	Number int
+	URL    string
+}
+
+// Provider is the git hosting service of a repository, used for the operations beyond git itself
+type Provider interface {
+	// Name is the provider name, e.g. github
+	Name() string
+	// Remote is the repository location
+	Remote() git.Remote
+	// CreateRepository creates the repository with an initial commit
+	CreateRepository(ctx context.Context) error
+	// CreateOrUpdatePullRequest opens a pull request of a branch into base, or updates the open one
+	CreateOrUpdatePullRequest(ctx context.Context,
+		branch, base, title, body string,
+		labels []string,
+	) (*PullRequest, error)
+	// RequestReviewers requests the review of a pull request from users and teams
+	RequestReviewers(ctx context.Context, number int, users, teams []string) error
+	// CommentOnPullRequest comments on the open pull request of a branch into base, once per marker
+	CommentOnPullRequest(ctx context.Context, branch, base, marker, body string) error
+	// SetSecret creates or updates a secret available to the CI pipelines of the repository
+	SetSecret(ctx context.Context, name, value string) error
+	// ListRepositories lists the repositories of the owner of the repository, e.g. its organization or
+	// workspace, in the model of the self-service UI
+	ListRepositories(ctx context.Context) ([]github.Repository, error)
+}
+
+// New returns the provider hosting the repository of a git configuration
+func New(gitConfig manifest.GitConfig) (Provider, error) {
+	if gitConfig.Remote == nil {
+		return nil, fmt.Errorf("git remote is required")
+	}
+	remote, err := git.ParseRemote(*gitConfig.Remote)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name := git.ProviderName(gitConfig); name {
+	case git.ProviderGitHub:
+		if gitConfig.GitHub == nil || (gitConfig.GitHub.Token == nil && gitConfig.GitHub.App == nil) {
+			return nil, fmt.Errorf("github token is required for %s, or a GitHub App", *gitConfig.Remote)
+		}
+		return &gitHubProvider{remote: remote, config: git.GitHubConfig(gitConfig)}, nil
+	case git.ProviderGitLab:
+		return newGitLabProvider(remote, gitConfig.GitLab)
+	case git.ProviderBitbucket:
+		return newBitbucketProvider(remote, gitConfig)
+	case git.ProviderAzureDevOps:
+		return newAzureDevOpsProvider(remote, gitConfig)
+	case git.ProviderGitea:
+		return newGiteaProvider(remote, gitConfig)
+	default:
+		return nil, fmt.Errorf("unsupported git provider %s, expected %s, %s, %s, %s or %s", name, git.ProviderGitHub,
+			git.ProviderGitLab, git.ProviderBitbucket, git.ProviderAzureDevOps, git.ProviderGitea)
+	}
+}
+
+// CodeOwnersPath returns the code owners file of a repository, relative to its root, or an empty string
+// when the provider has no code owners
+func CodeOwnersPath(gitConfig manifest.GitConfig) string {
+	switch git.ProviderName(gitConfig) {
+	case git.ProviderGitLab:
+		return GitLabCodeOwnersPath
+	case git.ProviderBitbucket:
+		return BitbucketCodeOwnersPath
+	case git.ProviderGitea:
+		return GiteaCodeOwnersPath
+	case git.ProviderAzureDevOps:
+		// Azure Repos requires reviewers with branch policies
+		return ""
+	default:
+		return github.CodeOwnersPath
+	}
+}
+
+// token resolves the token of the provider hosting a repository
+func token(gitConfig manifest.GitConfig, remote git.Remote) (string, error) {
+	tok, err := git.ProviderCredentials(gitConfig).Resolve()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the token of %s: %w", remote.WebURL(), err)
+	}
+	if tok == "" {
+		return "", fmt.Errorf("%s token is required for %s", git.ProviderName(gitConfig), remote.WebURL())
+	}
+	return tok, nil
+}
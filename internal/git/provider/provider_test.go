@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kong/konnect-orchestrator/internal/git"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+func TestNew(t *testing.T) {
+	for _, env := range []string{"GITHUB_TOKEN", "GITLAB_TOKEN", "BITBUCKET_TOKEN", "AZURE_DEVOPS_TOKEN", "GITEA_TOKEN"} {
+		t.Setenv(env, "")
+	}
+	remote := func(r string) *string { return &r }
+	token := &manifest.Secret{Type: "literal", Value: "token"}
+
+	tests := []struct {
+		name       string
+		config     manifest.GitConfig
+		provider   string
+		codeOwners string
+		err        string
+	}{
+		{
+			name:       "github",
+			config:     manifest.GitConfig{Remote: remote("https://github.com/org/platform.git"), GitHub: &manifest.GitHubConfig{Token: token}},
+			provider:   git.ProviderGitHub,
+			codeOwners: ".github/CODEOWNERS",
+		},
+		{
+			name:       "gitlab",
+			config:     manifest.GitConfig{Remote: remote("git@gitlab.com:group/sub/platform.git"), GitLab: &manifest.GitLabConfig{Token: token}},
+			provider:   git.ProviderGitLab,
+			codeOwners: ".gitlab/CODEOWNERS",
+		},
+		{
+			name:       "bitbucket",
+			config:     manifest.GitConfig{Remote: remote("git@bitbucket.org:ws/platform.git"), Bitbucket: &manifest.BitbucketConfig{Token: token}},
+			provider:   git.ProviderBitbucket,
+			codeOwners: ".bitbucket/CODEOWNERS",
+		},
+		{
+			name:     "azure devops",
+			config:   manifest.GitConfig{Remote: remote("https://dev.azure.com/org/apis/_git/platform"), AzureDevOps: &manifest.AzureDevOpsConfig{Token: token}},
+			provider: git.ProviderAzureDevOps,
+		},
+		{
+			name: "gitea",
+			config: manifest.GitConfig{Remote: remote("http://gitea:3000/org/platform.git"),
+				Gitea: &manifest.GiteaConfig{Token: token, APIURL: remote("http://gitea:3000/api/v1")}},
+			provider:   git.ProviderGitea,
+			codeOwners: ".gitea/CODEOWNERS",
+		},
+		{
+			name:   "github without token",
+			config: manifest.GitConfig{Remote: remote("https://github.com/org/platform.git")},
+			err:    "github token is required",
+		},
+		{
+			name:   "gitlab without token",
+			config: manifest.GitConfig{Remote: remote("https://gitlab.com/org/platform.git")},
+			err:    "gitlab token is required",
+		},
+		{
+			name:   "bitbucket without token",
+			config: manifest.GitConfig{Remote: remote("https://bitbucket.org/ws/platform.git")},
+			err:    "no token configured, set BITBUCKET_TOKEN",
+		},
+		{
+			name: "azure devops remote without project",
+			config: manifest.GitConfig{Remote: remote("https://git.example.com/org/platform.git"),
+				Provider: remote(git.ProviderAzureDevOps), AzureDevOps: &manifest.AzureDevOpsConfig{Token: token}},
+			err: "isn't of the form organization/project/_git/repository",
+		},
+		{
+			name:   "unsupported provider",
+			config: manifest.GitConfig{Remote: remote("https://example.com/org/platform.git"), Provider: remote("svn")},
+			err:    "unsupported git provider svn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.config)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.provider, p.Name())
+			assert.Equal(t, tt.codeOwners, CodeOwnersPath(tt.config))
+		})
+	}
+}
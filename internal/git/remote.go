@@ -0,0 +1,214 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/util"
+)
+
+// Git hosting providers
+const (
+	ProviderGitHub      = "github"
+	ProviderGitLab      = "gitlab"
+	ProviderBitbucket   = "bitbucket"
+	ProviderAzureDevOps = "azure-devops"
+	ProviderGitea       = "gitea"
+)
+
+// AzureDevOpsHost is the host of Azure Repos remotes, remotes of the ssh and legacy visualstudio.com hosts
+// are parsed to the https remote of this host
+const AzureDevOpsHost = "dev.azure.com"
+
+// Remote is the location of a repository on a git hosting service
+type Remote struct {
+	Host string
+	// Path is the full path of the repository without the .git suffix, e.g. KongAirlines/platform or a
+	// GitLab project in nested groups like platform/apis/konnect
+	Path string
+}
+
+// ParseRemote parses https, ssh and scp-like (git@host:path) git remotes
+func ParseRemote(remote string) (Remote, error) {
+	var host, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return Remote{}, fmt.Errorf("failed to parse git remote %s: %w", remote, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(remote, "@"); at >= 0 && strings.Contains(remote[at:], ":") {
+		host, path, _ = strings.Cut(remote[at+1:], ":")
+	} else {
+		return Remote{}, fmt.Errorf("unsupported git remote %s", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	switch {
+	case host == "ssh."+AzureDevOpsHost:
+		// git@ssh.dev.azure.com:v3/org/project/repo
+		if parts := strings.Split(path, "/"); len(parts) == 4 && parts[0] == "v3" {
+			host, path = AzureDevOpsHost, strings.Join([]string{parts[1], parts[2], "_git", parts[3]}, "/")
+		}
+	case strings.HasSuffix(host, ".visualstudio.com"):
+		// https://org.visualstudio.com/[DefaultCollection/]project/_git/repo
+		path = strings.TrimPrefix(path, "DefaultCollection/")
+		host, path = AzureDevOpsHost, strings.TrimSuffix(host, ".visualstudio.com")+"/"+path
+	}
+	if host == "" || !strings.Contains(path, "/") {
+		return Remote{}, fmt.Errorf("git remote %s has no owner and repository", remote)
+	}
+	return Remote{Host: host, Path: path}, nil
+}
+
+// Owner is the user, organization or group holding the repository
+func (r Remote) Owner() string {
+	return r.Path[:strings.LastIndex(r.Path, "/")]
+}
+
+// Name is the repository name
+func (r Remote) Name() string {
+	return r.Path[strings.LastIndex(r.Path, "/")+1:]
+}
+
+// WebURL is the page of the repository
+func (r Remote) WebURL() string {
+	return fmt.Sprintf("https://%s/%s", r.Host, r.Path)
+}
+
+// ProviderName returns the configured git hosting provider of a repository. Without configuration, the provider
+// is the one with settings, or the one of the remote host: bitbucket.org, dev.azure.com, codeberg.org or a host
+// containing gitlab, gitea or forgejo. The others are on GitHub.
+func ProviderName(gitConfig manifest.GitConfig) string {
+	if gitConfig.Provider != nil && *gitConfig.Provider != "" {
+		return *gitConfig.Provider
+	}
+	switch {
+	case gitConfig.GitLab != nil:
+		return ProviderGitLab
+	case gitConfig.Bitbucket != nil:
+		return ProviderBitbucket
+	case gitConfig.AzureDevOps != nil:
+		return ProviderAzureDevOps
+	case gitConfig.Gitea != nil:
+		return ProviderGitea
+	}
+	if gitConfig.Remote != nil {
+		if r, err := ParseRemote(*gitConfig.Remote); err == nil {
+			switch {
+			case strings.Contains(r.Host, "gitlab"):
+				return ProviderGitLab
+			case r.Host == "bitbucket.org":
+				return ProviderBitbucket
+			case r.Host == AzureDevOpsHost:
+				return ProviderAzureDevOps
+			case strings.Contains(r.Host, "gitea"), strings.Contains(r.Host, "forgejo"), r.Host == "codeberg.org":
+				return ProviderGitea
+			}
+		}
+	}
+	return ProviderGitHub
+}
+
+// GitHubConfig returns the GitHub settings of a repository. The API of remotes on GitHub Enterprise Server hosts
+// defaults to https://<host>/api/v3/.
+func GitHubConfig(gitConfig manifest.GitConfig) manifest.GitHubConfig {
+	var githubConfig manifest.GitHubConfig
+	if gitConfig.GitHub != nil {
+		githubConfig = *gitConfig.GitHub
+	}
+	if githubConfig.APIURL != nil && *githubConfig.APIURL != "" || gitConfig.Remote == nil {
+		return githubConfig
+	}
+	if r, err := ParseRemote(*gitConfig.Remote); err == nil && github.IsEnterpriseURL(r.WebURL()) {
+		apiURL := github.NewEndpoints(r.Host, "", "").APIURL
+		githubConfig.APIURL = &apiURL
+	}
+	return githubConfig
+}
+
+// Credentials is the token of the git provider hosting a repository, used for its API and for git over
+// https when no auth is configured
+type Credentials struct {
+	// EnvVar holds a token superseding the configured one, e.g. GITHUB_TOKEN in GitHub Actions
+	EnvVar string
+	// Username of the token for git over https
+	Username string
+	Token    *manifest.Secret
+	// GitHubApp mints installation tokens on Owner with the API at GitHubAPIURL instead of Token
+	GitHubApp    *manifest.GitHubAppConfig
+	GitHubAPIURL string
+	Owner        string
+}
+
+// ProviderCredentials returns the token settings of the provider hosting a repository
+func ProviderCredentials(gitConfig manifest.GitConfig) Credentials {
+	switch ProviderName(gitConfig) {
+	case ProviderGitLab:
+		c := Credentials{EnvVar: "GITLAB_TOKEN", Username: "oauth2"}
+		if gitConfig.GitLab != nil {
+			c.Token = gitConfig.GitLab.Token
+		}
+		return c
+	case ProviderBitbucket:
+		c := Credentials{EnvVar: "BITBUCKET_TOKEN", Username: "x-token-auth"}
+		if gitConfig.Bitbucket != nil {
+			c.Token = gitConfig.Bitbucket.Token
+			if gitConfig.Bitbucket.Username != nil && *gitConfig.Bitbucket.Username != "" {
+				c.Username = *gitConfig.Bitbucket.Username
+			}
+		}
+		return c
+	case ProviderGitea:
+		c := Credentials{EnvVar: "GITEA_TOKEN", Username: "x-access-token"}
+		if gitConfig.Gitea != nil {
+			c.Token = gitConfig.Gitea.Token
+			if gitConfig.Gitea.Username != nil && *gitConfig.Gitea.Username != "" {
+				c.Username = *gitConfig.Gitea.Username
+			}
+		}
+		return c
+	case ProviderAzureDevOps:
+		c := Credentials{EnvVar: "AZURE_DEVOPS_TOKEN", Username: "pat"}
+		if gitConfig.AzureDevOps != nil {
+			c.Token = gitConfig.AzureDevOps.Token
+		}
+		return c
+	default:
+		c := Credentials{EnvVar: "GITHUB_TOKEN", Username: "x-access-token"}
+		githubConfig := GitHubConfig(gitConfig)
+		c.Token, c.GitHubApp = githubConfig.Token, githubConfig.App
+		c.GitHubAPIURL = github.ConfigEndpoints(githubConfig).APIURL
+		if gitConfig.Remote != nil {
+			if r, err := ParseRemote(*gitConfig.Remote); err == nil {
+				c.Owner = r.Owner()
+			}
+		}
+		return c
+	}
+}
+
+// Configured reports whether a token or a GitHub App is configured
+func (c Credentials) Configured() bool {
+	return c.Token != nil || c.GitHubApp != nil
+}
+
+// Resolve returns the token of the environment variable, or else an installation token of the GitHub App,
+// or else the configured token
+func (c Credentials) Resolve() (string, error) {
+	if tok := os.Getenv(c.EnvVar); tok != "" {
+		return tok, nil
+	}
+	if c.GitHubApp != nil {
+		return github.InstallationToken(context.Background(), c.GitHubAPIURL, *c.GitHubApp, c.Owner)
+	}
+	if c.Token == nil {
+		return "", fmt.Errorf("no token configured, set %s or the token of the git provider", c.EnvVar)
+	}
+	return util.ResolveSecretValue(*c.Token)
+}
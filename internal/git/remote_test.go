@@ -0,0 +1,121 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		remote  string
+		want    Remote
+		owner   string
+		name    string
+		wantErr bool
+	}{
+		{
+			remote: "https://github.com/KongAirlines/platform.git",
+			want:   Remote{Host: "github.com", Path: "KongAirlines/platform"},
+			owner:  "KongAirlines",
+			name:   "platform",
+		},
+		{
+			remote: "git@gitlab.example.com:platform/apis/konnect.git",
+			want:   Remote{Host: "gitlab.example.com", Path: "platform/apis/konnect"},
+			owner:  "platform/apis",
+			name:   "konnect",
+		},
+		{
+			remote: "ssh://git@git.example.com:2222/platform/konnect",
+			want:   Remote{Host: "git.example.com", Path: "platform/konnect"},
+			owner:  "platform",
+			name:   "konnect",
+		},
+		{
+			remote: "https://KongAirlines@dev.azure.com/KongAirlines/apis/_git/platform",
+			want:   Remote{Host: "dev.azure.com", Path: "KongAirlines/apis/_git/platform"},
+			owner:  "KongAirlines/apis/_git",
+			name:   "platform",
+		},
+		{
+			remote: "git@ssh.dev.azure.com:v3/KongAirlines/apis/platform",
+			want:   Remote{Host: "dev.azure.com", Path: "KongAirlines/apis/_git/platform"},
+			owner:  "KongAirlines/apis/_git",
+			name:   "platform",
+		},
+		{
+			remote: "https://kongairlines.visualstudio.com/DefaultCollection/apis/_git/platform",
+			want:   Remote{Host: "dev.azure.com", Path: "kongairlines/apis/_git/platform"},
+			owner:  "kongairlines/apis/_git",
+			name:   "platform",
+		},
+		{remote: "https://github.com/KongAirlines", wantErr: true},
+		{remote: "/tmp/platform", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			r, err := ParseRemote(tt.remote)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, r)
+			assert.Equal(t, tt.owner, r.Owner())
+			assert.Equal(t, tt.name, r.Name())
+		})
+	}
+}
+
+func TestProviderName(t *testing.T) {
+	remote := func(s string) *string { return &s }
+	gitlab := ProviderGitLab
+
+	assert.Equal(t, ProviderGitHub, ProviderName(manifest.GitConfig{Remote: remote("https://github.com/a/b")}))
+	assert.Equal(t, ProviderGitLab, ProviderName(manifest.GitConfig{Remote: remote("git@gitlab.com:a/b.git")}))
+	assert.Equal(t, ProviderGitLab, ProviderName(manifest.GitConfig{Remote: remote("https://git.example.com/a/b"),
+		GitLab: &manifest.GitLabConfig{}}))
+	assert.Equal(t, ProviderGitLab, ProviderName(manifest.GitConfig{Remote: remote("https://git.example.com/a/b"),
+		Provider: &gitlab}))
+	assert.Equal(t, ProviderBitbucket, ProviderName(manifest.GitConfig{Remote: remote("git@bitbucket.org:ws/platform.git")}))
+	assert.Equal(t, ProviderAzureDevOps, ProviderName(manifest.GitConfig{
+		Remote: remote("https://dev.azure.com/org/apis/_git/platform")}))
+	assert.Equal(t, ProviderGitea, ProviderName(manifest.GitConfig{Remote: remote("http://gitea:3000/org/platform.git")}))
+	assert.Equal(t, ProviderGitea, ProviderName(manifest.GitConfig{Remote: remote("https://codeberg.org/org/platform.git")}))
+}
+
+func TestGitHubConfig(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name      string
+		gitConfig manifest.GitConfig
+		apiURL    *string
+	}{
+		{
+			name:      "github.com",
+			gitConfig: manifest.GitConfig{Remote: str("https://github.com/KongAirlines/platform.git")},
+		},
+		{
+			name:      "enterprise server remote",
+			gitConfig: manifest.GitConfig{Remote: str("git@github.example.com:KongAirlines/platform.git")},
+			apiURL:    str("https://github.example.com/api/v3/"),
+		},
+		{
+			name: "configured api",
+			gitConfig: manifest.GitConfig{
+				Remote: str("https://github.example.com/KongAirlines/platform.git"),
+				GitHub: &manifest.GitHubConfig{APIURL: str("https://api.example.com/")},
+			},
+			apiURL: str("https://api.example.com/"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.apiURL, GitHubConfig(tt.gitConfig).APIURL)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package lint
+
+const operations = `$.paths[*]['get','put','post','delete','options','head','patch','trace']`
+
+// builtinRulesets are the rulesets which can be extended. Only the rules which can be checked with
+// the supported functions are available, the other Spectral rules are ignored.
+var builtinRulesets = map[string]func() map[string]*Rule{
+	"spectral:oas": spectralOAS,
+}
+
+func spectralOAS() map[string]*Rule {
+	return map[string]*Rule{
+		"info-contact": {
+			Description: `Info object must have "contact" object`,
+			Given:       []string{"$.info"},
+			Severity:    Warn,
+			Then:        []Then{{Field: "contact", Function: "truthy"}},
+		},
+		"info-description": {
+			Description: `Info "description" must be present and non-empty string`,
+			Given:       []string{"$.info"},
+			Severity:    Warn,
+			Then:        []Then{{Field: "description", Function: "truthy"}},
+		},
+		"openapi-tags": {
+			Description: `OpenAPI object must have non-empty "tags" array`,
+			Given:       []string{"$"},
+			Severity:    Warn,
+			Then:        []Then{{Field: "tags", Function: "truthy"}},
+		},
+		"operation-description": {
+			Description: `Operation "description" must be present and non-empty string`,
+			Given:       []string{operations},
+			Severity:    Warn,
+			Then:        []Then{{Field: "description", Function: "truthy"}},
+		},
+		"operation-operationId": {
+			Description: `Operation must have "operationId"`,
+			Given:       []string{operations},
+			Severity:    Warn,
+			Then:        []Then{{Field: "operationId", Function: "truthy"}},
+		},
+		"operation-tags": {
+			Description: `Operation must have non-empty "tags" array`,
+			Given:       []string{operations},
+			Severity:    Warn,
+			Then:        []Then{{Field: "tags", Function: "truthy"}},
+		},
+	}
+}
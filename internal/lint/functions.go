@@ -0,0 +1,126 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// function checks a value and returns a description of the problem, or an empty string when the value passes
+type function func(value interface{}, exists bool, options map[string]interface{}) string
+
+var functions = map[string]function{
+	"enumeration": enumeration,
+	"pattern":     pattern,
+	"truthy":      truthy,
+}
+
+// enumeration checks the value is one of the `values` option, which can be a list or a single value
+func enumeration(value interface{}, exists bool, options map[string]interface{}) string {
+	if !exists {
+		return ""
+	}
+	var allowed []interface{}
+	switch v := options["values"].(type) {
+	case []interface{}:
+		allowed = v
+	case nil:
+	default:
+		allowed = []interface{}{v}
+	}
+	for _, a := range allowed {
+		if scalar(a) == scalar(value) {
+			return ""
+		}
+	}
+	names := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		names = append(names, scalar(a))
+	}
+	return fmt.Sprintf("%q must be one of the allowed values: %s", scalar(value), strings.Join(names, ", "))
+}
+
+// pattern checks the value matches the `match` regular expression and doesn't match `notMatch`.
+// Regular expressions can be written as /regex/ like in Spectral.
+func pattern(value interface{}, exists bool, options map[string]interface{}) string {
+	if !exists {
+		return ""
+	}
+	s := scalar(value)
+	if m, ok := options["match"]; ok {
+		re, err := compilePattern(m)
+		if err != nil {
+			return err.Error()
+		}
+		if !re.MatchString(s) {
+			return fmt.Sprintf("%q must match the pattern %q", s, re.String())
+		}
+	}
+	if m, ok := options["notMatch"]; ok {
+		re, err := compilePattern(m)
+		if err != nil {
+			return err.Error()
+		}
+		if re.MatchString(s) {
+			return fmt.Sprintf("%q must not match the pattern %q", s, re.String())
+		}
+	}
+	return ""
+}
+
+// truthy checks the value is set and isn't false, zero or empty
+func truthy(value interface{}, exists bool, _ map[string]interface{}) string {
+	if !exists {
+		return "property is not defined"
+	}
+	switch v := value.(type) {
+	case nil:
+		return "property is null"
+	case bool:
+		if !v {
+			return "property is false"
+		}
+	case string:
+		if v == "" {
+			return "property is empty"
+		}
+	case int:
+		if v == 0 {
+			return "property is 0"
+		}
+	case float64:
+		if v == 0 {
+			return "property is 0"
+		}
+	}
+	return ""
+}
+
+func compilePattern(p interface{}) (*regexp.Regexp, error) {
+	s := scalar(p)
+	if len(s) > 1 && strings.HasPrefix(s, "/") && strings.LastIndex(s, "/") > 0 {
+		end := strings.LastIndex(s, "/")
+		flags := s[end+1:]
+		s = s[1:end]
+		if strings.Contains(flags, "i") {
+			s = "(?i)" + s
+		}
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+	return re, nil
+}
+
+// scalar formats a value for comparisons, so that a boolean true matches "true"
+func scalar(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return t
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,162 @@
+package jsonpath
+
+// missingValue is the value of a filter operand pointing to a field which doesn't exist
+type missingValue struct{}
+
+var missing = missingValue{}
+
+type expression interface {
+	eval(current interface{}) interface{}
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(interface{}) interface{} {
+	return e.value
+}
+
+// currentExpr is a relative path from the filtered node, e.g. @.name or @['x-kong'][0]
+type currentExpr struct {
+	steps []interface{}
+}
+
+func (e currentExpr) eval(current interface{}) interface{} {
+	v := current
+	for _, step := range e.steps {
+		switch s := step.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return missing
+			}
+			if v, ok = m[s]; !ok {
+				return missing
+			}
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || s < 0 || s >= len(a) {
+				return missing
+			}
+			v = a[s]
+		}
+	}
+	return v
+}
+
+type notExpr struct {
+	expr expression
+}
+
+func (e notExpr) eval(current interface{}) interface{} {
+	return !truthy(e.expr.eval(current))
+}
+
+type logicalExpr struct {
+	or          bool
+	left, right expression
+}
+
+func (e logicalExpr) eval(current interface{}) interface{} {
+	if e.or {
+		return truthy(e.left.eval(current)) || truthy(e.right.eval(current))
+	}
+	return truthy(e.left.eval(current)) && truthy(e.right.eval(current))
+}
+
+type compareExpr struct {
+	op          string
+	left, right expression
+}
+
+func (e compareExpr) eval(current interface{}) interface{} {
+	l, r := e.left.eval(current), e.right.eval(current)
+	if l == missing || r == missing {
+		// a missing field is only different from everything
+		return e.op == "!="
+	}
+
+	if lf, ok := toFloat(l); ok {
+		if rf, ok := toFloat(r); ok {
+			switch e.op {
+			case "==":
+				return lf == rf
+			case "!=":
+				return lf != rf
+			case "<":
+				return lf < rf
+			case "<=":
+				return lf <= rf
+			case ">":
+				return lf > rf
+			case ">=":
+				return lf >= rf
+			}
+		}
+	}
+
+	if ls, ok := l.(string); ok {
+		if rs, ok := r.(string); ok {
+			switch e.op {
+			case "==":
+				return ls == rs
+			case "!=":
+				return ls != rs
+			case "<":
+				return ls < rs
+			case "<=":
+				return ls <= rs
+			case ">":
+				return ls > rs
+			case ">=":
+				return ls >= rs
+			}
+		}
+	}
+
+	switch e.op {
+	case "==":
+		return scalarEqual(l, r)
+	case "!=":
+		return !scalarEqual(l, r)
+	}
+	return false
+}
+
+func scalarEqual(a, b interface{}) bool {
+	switch a.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	switch b.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return a == b
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// truthy reports whether a filter result selects a node: existing values other than false and null
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case missingValue, nil:
+		return false
+	case bool:
+		return b
+	}
+	return true
+}
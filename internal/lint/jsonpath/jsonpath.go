@@ -0,0 +1,216 @@
+// Package jsonpath implements the subset of JSONPath used by the `given` paths of lint rules:
+// child (`.name`, `['name']`), wildcard (`*`), index (`[0]`), union (`['a','b']`), recursive descent
+// (`..`) and filter (`[?(@.name == "x" && @.enabled)]`) segments, evaluated over decoded YAML or JSON
+// documents (map[string]interface{} and []interface{} values). Unlike the selectors of decK patches,
+// the selected values keep their location so findings can point to them.
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node is a value selected by a path along with its location in the document
+type Node struct {
+	// Location holds the keys (string) and indexes (int) leading from the root to the value
+	Location []interface{}
+	Value    interface{}
+}
+
+// Path formats the location of the node as a normalized JSONPath, e.g. $.services[0].name
+func (n Node) Path() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, l := range n.Location {
+		switch v := l.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(v) + "]")
+		case string:
+			if isIdentifier(v) {
+				b.WriteString("." + v)
+			} else {
+				b.WriteString("['" + strings.ReplaceAll(v, "'", "\\'") + "']")
+			}
+		}
+	}
+	return b.String()
+}
+
+// Path is a compiled JSONPath expression
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+type segment struct {
+	recursive bool
+	selector  selector
+}
+
+type selector interface {
+	selectFrom(node Node) []Node
+}
+
+// Compile parses a JSONPath expression
+func Compile(expr string) (*Path, error) {
+	p := &parser{input: strings.TrimSpace(expr)}
+	segments, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+	}
+	return &Path{expr: expr, segments: segments}, nil
+}
+
+// MustCompile is like Compile but panics if the expression can't be parsed
+func MustCompile(expr string) *Path {
+	p, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source expression
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Query returns the nodes of doc selected by the path, in document order
+func (p *Path) Query(doc interface{}) []Node {
+	nodes := []Node{{Value: doc}}
+	for _, seg := range p.segments {
+		var next []Node
+		for _, n := range nodes {
+			candidates := []Node{n}
+			if seg.recursive {
+				candidates = descendants(n)
+			}
+			for _, c := range candidates {
+				next = append(next, seg.selector.selectFrom(c)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// Query compiles expr and evaluates it against doc
+func Query(expr string, doc interface{}) ([]Node, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Query(doc), nil
+}
+
+// descendants returns the node and all of its descendants, parents before children
+func descendants(n Node) []Node {
+	out := []Node{n}
+	for _, c := range children(n) {
+		out = append(out, descendants(c)...)
+	}
+	return out
+}
+
+func children(n Node) []Node {
+	switch v := n.Value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]Node, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, child(n, k, v[k]))
+		}
+		return out
+	case []interface{}:
+		out := make([]Node, 0, len(v))
+		for i, item := range v {
+			out = append(out, child(n, i, item))
+		}
+		return out
+	}
+	return nil
+}
+
+func child(parent Node, key interface{}, value interface{}) Node {
+	loc := make([]interface{}, len(parent.Location), len(parent.Location)+1)
+	copy(loc, parent.Location)
+	return Node{Location: append(loc, key), Value: value}
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(n Node) []Node {
+	return children(n)
+}
+
+type nameSelector struct {
+	names []string
+}
+
+func (s nameSelector) selectFrom(n Node) []Node {
+	m, ok := n.Value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var out []Node
+	for _, name := range s.names {
+		if v, ok := m[name]; ok {
+			out = append(out, child(n, name, v))
+		}
+	}
+	return out
+}
+
+type indexSelector struct {
+	indexes []int
+}
+
+func (s indexSelector) selectFrom(n Node) []Node {
+	a, ok := n.Value.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []Node
+	for _, i := range s.indexes {
+		if i < 0 {
+			i += len(a)
+		}
+		if i >= 0 && i < len(a) {
+			out = append(out, child(n, i, a[i]))
+		}
+	}
+	return out
+}
+
+type filterSelector struct {
+	expr expression
+}
+
+func (s filterSelector) selectFrom(n Node) []Node {
+	var out []Node
+	for _, c := range children(n) {
+		if truthy(s.expr.eval(c.Value)) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || r == '-' && i > 0 || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' && i > 0 {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const testDoc = `
+_format_version: "3.0"
+services:
+  - name: flights
+    enabled: true
+    retries: 5
+    routes:
+      - name: list-flights
+        protocols: [http, https]
+      - name: get-flight
+        protocols: [https]
+    plugins:
+      - name: rate-limiting
+        enabled: true
+  - name: acme-dummy-service
+    retries: 1
+    routes:
+      - name: acme
+        protocols: [http]
+upstreams:
+  - name: flights.internal
+`
+
+func TestQuery(t *testing.T) {
+	var doc interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(testDoc), &doc))
+
+	tests := []struct {
+		name      string
+		expr      string
+		wantPaths []string
+		wantErr   bool
+	}{
+		{
+			name:      "root",
+			expr:      "$",
+			wantPaths: []string{"$"},
+		},
+		{
+			name:      "wildcard and child",
+			expr:      "$.services[*].name",
+			wantPaths: []string{"$.services[0].name", "$.services[1].name"},
+		},
+		{
+			name:      "recursive descent",
+			expr:      "$..routes[*]",
+			wantPaths: []string{"$.services[0].routes[0]", "$.services[0].routes[1]", "$.services[1].routes[0]"},
+		},
+		{
+			name:      "recursive name",
+			expr:      "$..services[*]",
+			wantPaths: []string{"$.services[0]", "$.services[1]"},
+		},
+		{
+			name: "filter with double quotes",
+			expr: `$.services[?(@.name != "acme-dummy-service")].routes[*].protocols[*]`,
+			wantPaths: []string{
+				"$.services[0].routes[0].protocols[0]",
+				"$.services[0].routes[0].protocols[1]",
+				"$.services[0].routes[1].protocols[0]",
+			},
+		},
+		{
+			name:      "filter on existence and number",
+			expr:      "$.services[?(@.enabled && @.retries > 2)].name",
+			wantPaths: []string{"$.services[0].name"},
+		},
+		{
+			name:      "negated filter",
+			expr:      "$.services[?(!@.enabled)]",
+			wantPaths: []string{"$.services[1]"},
+		},
+		{
+			name:      "filter with or",
+			expr:      "$.services[?(@.retries == 1 || @.name == 'flights')].name",
+			wantPaths: []string{"$.services[0].name", "$.services[1].name"},
+		},
+		{
+			name:      "bracket names and indexes",
+			expr:      "$['services'][-1]['name','retries']",
+			wantPaths: []string{"$.services[1].name", "$.services[1].retries"},
+		},
+		{
+			name:      "no match",
+			expr:      "$.consumers[*]",
+			wantPaths: nil,
+		},
+		{
+			name:    "missing root",
+			expr:    "services[*]",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated bracket",
+			expr:    "$.services[0",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := Query(tt.expr, doc)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var paths []string
+			for _, n := range nodes {
+				paths = append(paths, n.Path())
+			}
+			assert.Equal(t, tt.wantPaths, paths)
+		})
+	}
+}
+
+func TestQueryReturnsMutableObjects(t *testing.T) {
+	var doc interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(testDoc), &doc))
+
+	for _, n := range MustCompile("$..services[*]").Query(doc) {
+		n.Value.(map[string]interface{})["tags"] = []interface{}{"patched"}
+	}
+
+	tags, err := Query("$.services[*].tags[0]", doc)
+	assert.NoError(t, err)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, "patched", tags[0].Value)
+}
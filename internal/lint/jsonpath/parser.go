@@ -0,0 +1,339 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) parse() ([]segment, error) {
+	if !strings.HasPrefix(p.input, "$") {
+		return nil, fmt.Errorf("must start with $")
+	}
+	p.pos = 1
+
+	var segments []segment
+	for !p.done() {
+		recursive := false
+		switch {
+		case p.consume(".."):
+			recursive = true
+			if p.peek() == '[' {
+				break
+			}
+			sel, err := p.dotSelector()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment{recursive: true, selector: sel})
+			continue
+		case p.consume("."):
+			sel, err := p.dotSelector()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment{selector: sel})
+			continue
+		}
+
+		if p.peek() != '[' {
+			return nil, fmt.Errorf("unexpected %q at position %d", p.peek(), p.pos)
+		}
+		sel, err := p.bracketSelector()
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment{recursive: recursive, selector: sel})
+	}
+	return segments, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpaces() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) dotSelector() (selector, error) {
+	if p.consume("*") {
+		return wildcardSelector{}, nil
+	}
+	name := p.identifier()
+	if name == "" {
+		return nil, fmt.Errorf("expected a name at position %d", p.pos)
+	}
+	return nameSelector{names: []string{name}}, nil
+}
+
+func (p *parser) identifier() string {
+	start := p.pos
+	for !p.done() {
+		c := p.peek()
+		if c == '.' || c == '[' || c == ']' || c == ',' || c == ' ' || c == ')' || c == '=' || c == '!' || c == '<' || c == '>' ||
+			c == '&' || c == '|' {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) bracketSelector() (selector, error) {
+	p.pos++ // [
+	p.skipSpaces()
+
+	var sel selector
+	switch {
+	case p.consume("*"):
+		sel = wildcardSelector{}
+	case p.consume("?"):
+		p.skipSpaces()
+		parens := p.consume("(")
+		expr, err := p.orExpression()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if parens && !p.consume(")") {
+			return nil, fmt.Errorf("expected ) at position %d", p.pos)
+		}
+		sel = filterSelector{expr: expr}
+	case p.peek() == '\'' || p.peek() == '"':
+		var names []string
+		for {
+			name, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+			p.skipSpaces()
+			if !p.consume(",") {
+				break
+			}
+			p.skipSpaces()
+		}
+		sel = nameSelector{names: names}
+	default:
+		var indexes []int
+		for {
+			i, err := p.integer()
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, i)
+			p.skipSpaces()
+			if !p.consume(",") {
+				break
+			}
+			p.skipSpaces()
+		}
+		sel = indexSelector{indexes: indexes}
+	}
+
+	p.skipSpaces()
+	if !p.consume("]") {
+		return nil, fmt.Errorf("expected ] at position %d", p.pos)
+	}
+	return sel, nil
+}
+
+func (p *parser) quoted() (string, error) {
+	quote := p.peek()
+	p.pos++
+	var b strings.Builder
+	for !p.done() {
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '\\':
+			if p.done() {
+				return "", fmt.Errorf("unterminated string")
+			}
+			b.WriteByte(p.peek())
+			p.pos++
+		case quote:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *parser) integer() (int, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.done() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	i, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		return 0, fmt.Errorf("expected an index at position %d", start)
+	}
+	return i, nil
+}
+
+func (p *parser) orExpression() (expression, error) {
+	left, err := p.andExpression()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.andExpression()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{or: true, left: left, right: right}
+	}
+}
+
+func (p *parser) andExpression() (expression, error) {
+	left, err := p.unaryExpression()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.unaryExpression()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{left: left, right: right}
+	}
+}
+
+func (p *parser) unaryExpression() (expression, error) {
+	p.skipSpaces()
+	if strings.HasPrefix(p.input[p.pos:], "!") && !strings.HasPrefix(p.input[p.pos:], "!=") {
+		p.pos++
+		e, err := p.unaryExpression()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{e}, nil
+	}
+	if p.consume("(") {
+		e, err := p.orExpression()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expected ) at position %d", p.pos)
+		}
+		return e, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			p.skipSpaces()
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return compareExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) operand() (expression, error) {
+	switch c := p.peek(); {
+	case c == '@':
+		p.pos++
+		var steps []interface{}
+		for {
+			switch {
+			case p.consume("."):
+				name := p.identifier()
+				if name == "" {
+					return nil, fmt.Errorf("expected a name at position %d", p.pos)
+				}
+				steps = append(steps, name)
+				continue
+			case p.peek() == '[':
+				p.pos++
+				p.skipSpaces()
+				if p.peek() == '\'' || p.peek() == '"' {
+					name, err := p.quoted()
+					if err != nil {
+						return nil, err
+					}
+					steps = append(steps, name)
+				} else {
+					i, err := p.integer()
+					if err != nil {
+						return nil, err
+					}
+					steps = append(steps, i)
+				}
+				p.skipSpaces()
+				if !p.consume("]") {
+					return nil, fmt.Errorf("expected ] at position %d", p.pos)
+				}
+				continue
+			}
+			return currentExpr{steps: steps}, nil
+		}
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{value: s}, nil
+	case p.consume("true"):
+		return literalExpr{value: true}, nil
+	case p.consume("false"):
+		return literalExpr{value: false}, nil
+	case p.consume("null"):
+		return literalExpr{value: nil}, nil
+	default:
+		start := p.pos
+		for !p.done() && strings.ContainsRune("-+.0123456789eE", rune(p.peek())) {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected operand at position %d", start)
+		}
+		return literalExpr{value: f}, nil
+	}
+}
@@ -0,0 +1,357 @@
+// Package lint runs Spectral style rulesets, like the ones used by `deck file lint`, natively against
+// decK files and API specs. Rules select values with JSONPath `given` expressions and check them with
+// the enumeration, pattern and truthy functions.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kong/konnect-orchestrator/internal/lint/jsonpath"
+)
+
+// Rule files of the platform repository, stored in the konnect directory
+const (
+	OASRulesFileName  = "oas-file-rules.yaml"
+	DeckRulesFileName = "deck-file-rules.yaml"
+)
+
+// Severity of a rule, ordered like Spectral from the most to the least severe
+type Severity int
+
+const (
+	Error Severity = iota
+	Warn
+	Info
+	Hint
+	// Off disables a rule
+	Off Severity = -1
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warn:
+		return "warn"
+	case Info:
+		return "info"
+	case Hint:
+		return "hint"
+	default:
+		return "off"
+	}
+}
+
+// ParseSeverity parses a Spectral severity name or number
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error", "0":
+		return Error, nil
+	case "warn", "warning", "1":
+		return Warn, nil
+	case "info", "information", "2":
+		return Info, nil
+	case "hint", "3":
+		return Hint, nil
+	case "off", "false", "-1":
+		return Off, nil
+	default:
+		return Off, fmt.Errorf("unknown severity %q", s)
+	}
+}
+
+// Then is a check applied to every value selected by a rule
+type Then struct {
+	// Field is a property of the selected value to check instead of the value itself, `@key` checks the key
+	Field           string                 `yaml:"field"`
+	Function        string                 `yaml:"function"`
+	FunctionOptions map[string]interface{} `yaml:"functionOptions"`
+}
+
+// Rule is a Spectral rule
+type Rule struct {
+	Description string
+	Message     string
+	Given       []string
+	Severity    Severity
+	Then        []Then
+
+	paths []*jsonpath.Path
+}
+
+// Ruleset is a set of named rules
+type Ruleset struct {
+	Rules map[string]*Rule
+}
+
+// Finding is a rule violation
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	// Path is the JSONPath of the offending value
+	Path string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s %s: %s", f.Severity, f.Path, f.Rule, f.Message)
+}
+
+// HasErrors reports whether any finding has the error severity
+func HasErrors(findings []Finding) bool {
+	return AtLeast(findings, Error)
+}
+
+// AtLeast reports whether any finding is at least as severe as s
+func AtLeast(findings []Finding, s Severity) bool {
+	for _, f := range findings {
+		if f.Severity <= s {
+			return true
+		}
+	}
+	return false
+}
+
+type rawRuleset struct {
+	Extends yaml.Node            `yaml:"extends"`
+	Rules   map[string]yaml.Node `yaml:"rules"`
+}
+
+type rawRule struct {
+	Description string    `yaml:"description"`
+	Message     string    `yaml:"message"`
+	Given       yaml.Node `yaml:"given"`
+	Severity    yaml.Node `yaml:"severity"`
+	Then        yaml.Node `yaml:"then"`
+}
+
+// Load reads a ruleset file
+func Load(path string) (*Ruleset, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ruleset %s: %w", path, err)
+	}
+	rs, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ruleset %s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// LoadIfExists reads a ruleset file, returning nil when the file doesn't exist
+func LoadIfExists(path string) (*Ruleset, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Parse parses a ruleset. Rules of the extended `spectral:oas` ruleset are limited to the subset
+// which can be checked with the supported functions. A rule set to a severity or `off` changes the
+// severity of an extended rule.
+func Parse(content []byte) (*Ruleset, error) {
+	var raw rawRuleset
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	rs := &Ruleset{Rules: map[string]*Rule{}}
+	var extends []string
+	switch raw.Extends.Kind {
+	case yaml.ScalarNode:
+		extends = []string{raw.Extends.Value}
+	case yaml.SequenceNode:
+		if err := raw.Extends.Decode(&extends); err != nil {
+			return nil, fmt.Errorf("invalid extends: %w", err)
+		}
+	}
+	for _, e := range extends {
+		builtin, ok := builtinRulesets[e]
+		if !ok {
+			return nil, fmt.Errorf("unsupported ruleset %q in extends", e)
+		}
+		for name, r := range builtin() {
+			rs.Rules[name] = r
+		}
+	}
+
+	for name, node := range raw.Rules {
+		if node.Kind == yaml.ScalarNode {
+			// `rule: off` or `rule: warn` override an extended rule
+			severity, err := ParseSeverity(node.Value)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", name, err)
+			}
+			r, ok := rs.Rules[name]
+			if !ok {
+				return nil, fmt.Errorf("rule %s doesn't override an extended rule", name)
+			}
+			r.Severity = severity
+			continue
+		}
+
+		var rr rawRule
+		if err := node.Decode(&rr); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		r, err := rr.rule()
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		rs.Rules[name] = r
+	}
+
+	for name, r := range rs.Rules {
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+	}
+	return rs, nil
+}
+
+func (rr rawRule) rule() (*Rule, error) {
+	r := &Rule{Description: rr.Description, Message: rr.Message, Severity: Warn}
+
+	switch rr.Given.Kind {
+	case yaml.ScalarNode:
+		r.Given = []string{rr.Given.Value}
+	case yaml.SequenceNode:
+		if err := rr.Given.Decode(&r.Given); err != nil {
+			return nil, fmt.Errorf("invalid given: %w", err)
+		}
+	}
+	if len(r.Given) == 0 {
+		return nil, fmt.Errorf("given is required")
+	}
+
+	if rr.Severity.Kind == yaml.ScalarNode {
+		severity, err := ParseSeverity(rr.Severity.Value)
+		if err != nil {
+			return nil, err
+		}
+		r.Severity = severity
+	}
+
+	switch rr.Then.Kind {
+	case yaml.MappingNode:
+		var t Then
+		if err := rr.Then.Decode(&t); err != nil {
+			return nil, fmt.Errorf("invalid then: %w", err)
+		}
+		r.Then = []Then{t}
+	case yaml.SequenceNode:
+		if err := rr.Then.Decode(&r.Then); err != nil {
+			return nil, fmt.Errorf("invalid then: %w", err)
+		}
+	}
+	if len(r.Then) == 0 {
+		return nil, fmt.Errorf("then is required")
+	}
+	return r, nil
+}
+
+func (r *Rule) compile() error {
+	r.paths = r.paths[:0]
+	for _, g := range r.Given {
+		p, err := jsonpath.Compile(g)
+		if err != nil {
+			return err
+		}
+		r.paths = append(r.paths, p)
+	}
+	for _, t := range r.Then {
+		if _, ok := functions[t.Function]; !ok {
+			return fmt.Errorf("unsupported function %q", t.Function)
+		}
+	}
+	return nil
+}
+
+// Lint parses a YAML or JSON document and checks it against every enabled rule. Findings are sorted by
+// severity, path and rule.
+func (rs *Ruleset) Lint(content []byte) ([]Finding, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	findings := []Finding{}
+	for name, r := range rs.Rules {
+		if r.Severity == Off {
+			continue
+		}
+		for _, p := range r.paths {
+			for _, n := range p.Query(doc) {
+				for _, t := range r.Then {
+					for _, target := range targets(n, t.Field) {
+						problem := functions[t.Function](target.value, target.exists, t.FunctionOptions)
+						if problem == "" {
+							continue
+						}
+						findings = append(findings, Finding{
+							Rule:     name,
+							Severity: r.Severity,
+							Message:  r.message(problem),
+							Path:     target.path,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity < b.Severity
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Rule < b.Rule
+	})
+	return findings, nil
+}
+
+func (r *Rule) message(problem string) string {
+	switch {
+	case r.Message != "":
+		return strings.ReplaceAll(r.Message, "{{error}}", problem)
+	case r.Description != "":
+		return r.Description + ": " + problem
+	default:
+		return problem
+	}
+}
+
+type target struct {
+	path   string
+	value  interface{}
+	exists bool
+}
+
+// targets returns the values checked for a selected node: the node itself, its key or one of its fields
+func targets(n jsonpath.Node, field string) []target {
+	switch {
+	case field == "":
+		return []target{{path: n.Path(), value: n.Value, exists: true}}
+	case field == "@key":
+		if len(n.Location) == 0 {
+			return nil
+		}
+		return []target{{path: n.Path(), value: n.Location[len(n.Location)-1], exists: true}}
+	}
+
+	m, ok := n.Value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	v, exists := m[field]
+	loc := append(append([]interface{}{}, n.Location...), field)
+	return []target{{path: jsonpath.Node{Location: loc}.Path(), value: v, exists: exists}}
+}
@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deckRules = `
+rules:
+  route-https-check:
+    description: "Ensure https usage in Kong GW Routes, except for acme-dummy-service"
+    given: $.services[?(@.name != "acme-dummy-service")].routes[*].protocols[*]
+    severity: error
+    then:
+      function: enumeration
+      functionOptions:
+        values: "https"
+  svc-plugin-check-check-list:
+    description: "Ensure all plugins present are enabled for Kong GW Services"
+    given: "$.services[*].plugins[*].enabled"
+    severity: error
+    then:
+      function: pattern
+      functionOptions:
+        match: "^true$"
+`
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleset  string
+		document string
+		want     []Finding
+	}{
+		{
+			name:    "decK rules",
+			ruleset: deckRules,
+			document: `
+services:
+  - name: flights
+    plugins:
+      - name: cors
+        enabled: true
+      - name: rate-limiting
+        enabled: false
+    routes:
+      - name: flights_get
+        protocols: [https, http]
+  - name: acme-dummy-service
+    routes:
+      - protocols: [http]
+`,
+			want: []Finding{
+				{
+					Rule:     "svc-plugin-check-check-list",
+					Severity: Error,
+					Message:  `Ensure all plugins present are enabled for Kong GW Services: "false" must match the pattern "^true$"`,
+					Path:     "$.services[0].plugins[1].enabled",
+				},
+				{
+					Rule:     "route-https-check",
+					Severity: Error,
+					Message:  `Ensure https usage in Kong GW Routes, except for acme-dummy-service: "http" must be one of the allowed values: https`,
+					Path:     "$.services[0].routes[0].protocols[1]",
+				},
+			},
+		},
+		{
+			name: "extended rules with overrides",
+			ruleset: `
+extends: ["spectral:oas"]
+rules:
+  info-contact: off
+  openapi-tags: off
+  operation-tags: error
+  operation-description: off
+  operation-operationId: off
+  paths-kebab-case:
+    message: "Paths must be kebab case: {{error}}"
+    given: $.paths[*]
+    then:
+      field: "@key"
+      function: pattern
+      functionOptions:
+        notMatch: /[A-Z_]/
+`,
+			document: `
+openapi: 3.0.3
+info:
+  title: flights
+  description: Flights API
+paths:
+  /flights:
+    get:
+      tags: [flights]
+  /flight_Details:
+    post:
+      operationId: details
+`,
+			want: []Finding{
+				{
+					Rule:     "operation-tags",
+					Severity: Error,
+					Message:  `Operation must have non-empty "tags" array: property is not defined`,
+					Path:     "$.paths['/flight_Details'].post.tags",
+				},
+				{
+					Rule:     "paths-kebab-case",
+					Severity: Warn,
+					Message:  `Paths must be kebab case: "/flight_Details" must not match the pattern "[A-Z_]"`,
+					Path:     "$.paths['/flight_Details']",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := Parse([]byte(tt.ruleset))
+			require.NoError(t, err)
+
+			findings, err := rs.Lint([]byte(tt.document))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, findings)
+		})
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	findings := []Finding{{Severity: Warn}, {Severity: Info}}
+	assert.False(t, HasErrors(findings))
+	assert.True(t, AtLeast(findings, Warn))
+	assert.True(t, HasErrors(append(findings, Finding{Severity: Error})))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ruleset string
+		wantErr string
+	}{
+		{
+			name:    "unsupported function",
+			ruleset: "rules:\n  r:\n    given: $\n    then:\n      function: schema\n",
+			wantErr: `unsupported function "schema"`,
+		},
+		{
+			name:    "invalid given",
+			ruleset: "rules:\n  r:\n    given: services\n    then:\n      function: truthy\n",
+			wantErr: "invalid JSONPath",
+		},
+		{
+			name:    "unknown severity",
+			ruleset: "rules:\n  r:\n    given: $\n    severity: fatal\n    then:\n      function: truthy\n",
+			wantErr: `unknown severity "fatal"`,
+		},
+		{
+			name:    "unsupported extends",
+			ruleset: "extends: spectral:asyncapi\n",
+			wantErr: `unsupported ruleset "spectral:asyncapi"`,
+		},
+		{
+			name:    "override of an unknown rule",
+			ruleset: "rules:\n  info-contact: off\n",
+			wantErr: "doesn't override an extended rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.ruleset))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadIfExists(t *testing.T) {
+	rs, err := LoadIfExists(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Nil(t, rs)
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(deckRules), 0o600))
+	rs, err = LoadIfExists(path)
+	require.NoError(t, err)
+	assert.Len(t, rs.Rules, 2)
+}
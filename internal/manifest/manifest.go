@@ -2,6 +2,8 @@ package manifest
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 )
 
 type Orchestrator struct {
@@ -14,6 +16,35 @@ type Team struct {
 	Description *string             `json:"description,omitempty" yaml:"description,omitempty"`
 	Users       []string            `json:"users,omitempty" yaml:"users,omitempty"`
 	Services    map[string]*Service `json:"services,omitempty" yaml:"services,omitempty"`
+	Reviewers   *Reviewers          `json:"reviewers,omitempty" yaml:"reviewers,omitempty"`
+	// SystemAccount opts the team in to a Konnect system account for the CI pipelines of its service
+	// repositories
+	SystemAccount *TeamSystemAccount `json:"system-account,omitempty" yaml:"system-account,omitempty"`
+}
+
+// TeamSystemAccount is a Konnect system account of a team in every organization the team belongs to. The
+// orchestrator assigns the account the roles of the team on its control planes, Admin in DEV and Viewer in
+// PROD environments, and writes its access tokens to the CI secret of each of the team's service repositories.
+type TeamSystemAccount struct {
+	// Name defaults to konnect-orchestrator-<team>
+	Name *string `json:"name,omitempty" yaml:"name,omitempty"`
+	// TokenTTL is the lifetime of the access tokens, e.g. 168h. Defaults to 720h.
+	TokenTTL *string `json:"token-ttl,omitempty" yaml:"token-ttl,omitempty"`
+	// RotateBefore issues a new token when the newest one expires within the duration. Defaults to a third of
+	// the token TTL.
+	RotateBefore *string `json:"rotate-before,omitempty" yaml:"rotate-before,omitempty"`
+	// Secret is the CI secret of the service repositories the token is written to, defaults to
+	// <ORGANIZATION>_KONNECT_TOKEN
+	Secret *string `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// Reviewers are requested to review the platform repository pull requests changing the files of a team,
+// and own the team's directories in the CODEOWNERS file of the platform repository
+type Reviewers struct {
+	// Users are GitHub user logins
+	Users []string `json:"users,omitempty" yaml:"users,omitempty"`
+	// Teams are GitHub team slugs in the organization owning the platform repository
+	Teams []string `json:"teams,omitempty" yaml:"teams,omitempty"`
 }
 
 type Service struct {
@@ -21,10 +52,28 @@ type Service struct {
 	Git         *GitConfig `json:"git,omitempty" yaml:"git,omitempty"`
 	Description *string    `json:"description,omitempty" yaml:"description,omitempty"`
 	SpecPath    string     `json:"spec-path" yaml:"spec-path,omitempty"`
+	Specs       []SpecFile `json:"specs,omitempty" yaml:"specs,omitempty"`
 	ProdBranch  string     `json:"prod-branch-name" yaml:"prod-branch-name"`
 	DevBranch   string     `json:"dev-branch-name" yaml:"dev-branch-name"`
 }
 
+// SpecFile is an API description file in a service repository
+type SpecFile struct {
+	// Path is relative to the root of the service repository
+	Path string `json:"path" yaml:"path"`
+	// Type is one of oas2, oas3, asyncapi, graphql or proto. Detected from the file content when omitted.
+	Type *string `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// SpecFiles returns the spec files declared for the service, falling back to SpecPath
+// when no specs list is provided
+func (s *Service) SpecFiles() []SpecFile {
+	if len(s.Specs) > 0 {
+		return s.Specs
+	}
+	return []SpecFile{{Path: s.SpecPath}}
+}
+
 type serviceAlias Service
 
 func newDefaultService() *serviceAlias {
@@ -62,6 +111,148 @@ type Organization struct {
 	Authorization       *Authorization          `json:"authorization,omitempty" yaml:"authorization,omitempty"`
 	Notifications       *Notifications          `json:"notifications,omitempty" yaml:"notifications,omitempty"`
 	EnableCustomReports *bool                   `json:"enable-custom-reports,omitempty" yaml:"enable-custom-reports,omitempty"`
+	APIVersioning       *APIVersioning          `json:"api-versioning,omitempty" yaml:"api-versioning,omitempty"`
+	Naming              *Naming                 `json:"naming,omitempty" yaml:"naming,omitempty"`
+	Policies            *Policies               `json:"policies,omitempty" yaml:"policies,omitempty"`
+	PullRequests        *PullRequests           `json:"pull-requests,omitempty" yaml:"pull-requests,omitempty"`
+	// SystemAccount has the orchestrator manage the system account of its access token
+	SystemAccount *SystemAccount `json:"system-account,omitempty" yaml:"system-account,omitempty"`
+}
+
+// SystemAccount is the Konnect system account the orchestrator applies an organization with. The orchestrator
+// creates the account, adds it to its teams, assigns its roles and issues a new access token when the current
+// one nears its expiry, writing it to the CI secret of the platform repository.
+type SystemAccount struct {
+	// Name defaults to konnect-orchestrator
+	Name *string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Teams the account is a member of, defaults to the predefined Organization Admin team
+	Teams []string `json:"teams,omitempty" yaml:"teams,omitempty"`
+	// Roles are assigned to the account in addition to the roles of its teams
+	Roles []RoleAssignment `json:"roles,omitempty" yaml:"roles,omitempty"`
+	// TokenTTL is the lifetime of the access tokens, e.g. 168h. Defaults to 720h.
+	TokenTTL *string `json:"token-ttl,omitempty" yaml:"token-ttl,omitempty"`
+	// RotateBefore issues a new token when the newest one expires within the duration. Defaults to a third of
+	// the token TTL.
+	RotateBefore *string `json:"rotate-before,omitempty" yaml:"rotate-before,omitempty"`
+	// Secret is the CI secret of the platform repository the token is written to, defaults to
+	// <ORGANIZATION>_KONNECT_TOKEN
+	Secret *string `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// RoleAssignment is a Konnect role on entities of a type, e.g. the Admin role of Control Planes
+type RoleAssignment struct {
+	Role       string `json:"role" yaml:"role"`
+	EntityType string `json:"entity-type" yaml:"entity-type"`
+	// EntityID defaults to *, every entity of the type
+	EntityID string `json:"entity-id,omitempty" yaml:"entity-id,omitempty"`
+	// Region defaults to *, every region
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+}
+
+// PullRequests configures the pull requests proposing the changes to the platform repository
+type PullRequests struct {
+	// Grouping is one of environment (default), team or service: changes are proposed in one pull request
+	// per environment, per team in an environment or per service in an environment
+	Grouping *string `json:"grouping,omitempty" yaml:"grouping,omitempty"`
+}
+
+// Policies are enforced by the platform team on every gateway service of an organization or environment
+type Policies struct {
+	Plugins []PluginPolicy `json:"plugins,omitempty" yaml:"plugins,omitempty"`
+}
+
+// PluginPolicy is a plugin added to every gateway service generated from the service specs.
+// The plugin replaces a plugin with the same name configured by the service.
+type PluginPolicy struct {
+	// Name is the Kong plugin name, e.g. rate-limiting
+	Name string `json:"name" yaml:"name"`
+	// EnvironmentTypes limits the policy to environments of the given types, e.g. PROD. Empty applies to all.
+	EnvironmentTypes []string `json:"environment-types,omitempty" yaml:"environment-types,omitempty"`
+	// Enabled defaults to true
+	Enabled *bool                  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Config  map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
+	// Secrets sets config fields, addressed with dotted paths (e.g. redis.password), to Kong vault
+	// references so secret values are never written to the platform repository
+	Secrets map[string]SecretReference `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	// Exemptions lists the services the policy doesn't apply to
+	Exemptions []PolicyExemption `json:"exemptions,omitempty" yaml:"exemptions,omitempty"`
+}
+
+// SecretReference is a Kong vault reference, rendered as {vault://<vault>/<key>[/<field>]}
+type SecretReference struct {
+	// Vault is the prefix of the vault, e.g. env, aws, gcp, hcv or the prefix of a Konnect config store vault
+	Vault string  `json:"vault" yaml:"vault"`
+	Key   string  `json:"key" yaml:"key"`
+	Field *string `json:"field,omitempty" yaml:"field,omitempty"`
+}
+
+// PolicyExemption excludes a service, or every service of a team when Service is empty, from a policy
+type PolicyExemption struct {
+	Team    string `json:"team" yaml:"team"`
+	Service string `json:"service,omitempty" yaml:"service,omitempty"`
+	// Justification is required and records why the service doesn't follow the policy
+	Justification string `json:"justification" yaml:"justification"`
+	// Expires is an optional date (YYYY-MM-DD) after which the exemption no longer applies
+	Expires *string `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// Naming holds Go templates for the names of the resources created for an organization.
+// Templates can use the .Org, .Env, .EnvType, .Team, .Service and .Version variables, except .Version
+// in API names, unset templates keep the default names.
+type Naming struct {
+	ControlPlane *string `json:"control-plane,omitempty" yaml:"control-plane,omitempty"`
+	API          *string `json:"api,omitempty" yaml:"api,omitempty"`
+	Portal       *string `json:"portal,omitempty" yaml:"portal,omitempty"`
+	Team         *string `json:"team,omitempty" yaml:"team,omitempty"`
+	Branch       *string `json:"branch,omitempty" yaml:"branch,omitempty"`
+}
+
+// APIVersioning configures the lifecycle of the API catalog entries created for each spec version
+type APIVersioning struct {
+	// Retain is the number of versions of an API that stay published to the portal, including the newest.
+	// Zero or unset retains every version.
+	Retain *int `json:"retain,omitempty" yaml:"retain,omitempty"`
+	// DeprecatedVisibility is the portal visibility of deprecated versions, either `public` or `private`
+	DeprecatedVisibility *string `json:"deprecated-visibility,omitempty" yaml:"deprecated-visibility,omitempty"`
+	// EnforceVersionBump blocks PROD applies of a changed spec unless info.version was bumped: a new major
+	// version for breaking changes and a greater version for other changes
+	EnforceVersionBump *bool `json:"enforce-version-bump,omitempty" yaml:"enforce-version-bump,omitempty"`
+}
+
+// DeprecatedVisibilities are the portal visibilities of deprecated API versions
+var DeprecatedVisibilities = []string{"public", "private"}
+
+type apiVersioningAlias APIVersioning
+
+func (v *APIVersioning) validate() error {
+	if v.DeprecatedVisibility == nil {
+		return nil
+	}
+	for _, visibility := range DeprecatedVisibilities {
+		if *v.DeprecatedVisibility == visibility {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid deprecated-visibility %q, expected one of %s",
+		*v.DeprecatedVisibility, strings.Join(DeprecatedVisibilities, ", "))
+}
+
+func (v *APIVersioning) UnmarshalJSON(data []byte) error {
+	var alias apiVersioningAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*v = APIVersioning(alias)
+	return v.validate()
+}
+
+func (v *APIVersioning) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var alias apiVersioningAlias
+	if err := unmarshal(&alias); err != nil {
+		return err
+	}
+	*v = APIVersioning(alias)
+	return v.validate()
 }
 
 type Notifications struct {
@@ -73,6 +264,13 @@ type Environment struct {
 	Type   string                      `json:"type" yaml:"type"`
 	Region string                      `json:"region" yaml:"region"`
 	Teams  map[string]*TeamEnvironment `json:"teams,omitempty" yaml:"teams,omitempty"`
+	// Policies are added to the policies of the organization, replacing the plugins with the same name
+	Policies *Policies `json:"policies,omitempty" yaml:"policies,omitempty"`
+	// PullRequests overrides the pull request configuration of the organization
+	PullRequests *PullRequests `json:"pull-requests,omitempty" yaml:"pull-requests,omitempty"`
+	// PlatformChanges is one of pr (default), direct or none: the changes to the platform repository are
+	// proposed in pull requests, committed to the base branch or only written to a local checkout
+	PlatformChanges *string `json:"platform-changes,omitempty" yaml:"platform-changes,omitempty"`
 }
 
 type TeamEnvironment struct {
@@ -85,10 +283,19 @@ type EnvironmentService struct {
 }
 
 type Secret struct {
-	// Type is the storage type of secret, e.g. file, env, literal
+	// Type is the storage type of secret, e.g. file, env, literal, or the secret stores vault, aws-sm, gcp-sm,
+	// azure-kv, exec to run a command, or age for an age encrypted value
 	Type string `json:"type" yaml:"type"`
-	// Value is the value of the secret, e.g. the file path, env var name, or literal value
+	// Value is the value of the secret, e.g. the file path, env var name, literal value, path or name of the
+	// secret in the store, command, or the armored age value or age encrypted file
 	Value string `json:"value" yaml:"value"`
+	// Key selects a key of a secret holding a JSON object
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// Trim removes the leading and trailing whitespace of the value, e.g. the newline ending a token file.
+	// Defaults to true.
+	Trim *bool `json:"trim,omitempty" yaml:"trim,omitempty"`
+	// Encoding of the value, base64 to decode a base64 encoded secret
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty"`
 }
 
 // Platform represents the platform team configuration
@@ -102,6 +309,30 @@ type GitConfig struct {
 	Author *Author       `json:"author,omitempty" yaml:"author,omitempty"`
 	Auth   *AuthConfig   `json:"auth,omitempty" yaml:"auth,omitempty"`
 	GitHub *GitHubConfig `json:"github,omitempty" yaml:"github,omitempty"`
+	GitLab *GitLabConfig `json:"gitlab,omitempty" yaml:"gitlab,omitempty"`
+	// Bitbucket configures repositories hosted on Bitbucket Cloud
+	Bitbucket *BitbucketConfig `json:"bitbucket,omitempty" yaml:"bitbucket,omitempty"`
+	// AzureDevOps configures repositories hosted on Azure Repos
+	AzureDevOps *AzureDevOpsConfig `json:"azure-devops,omitempty" yaml:"azure-devops,omitempty"`
+	// Gitea configures repositories hosted on Gitea or Forgejo
+	Gitea *GiteaConfig `json:"gitea,omitempty" yaml:"gitea,omitempty"`
+	// Provider is the git hosting service of the repository: github, gitlab, bitbucket, azure-devops or gitea.
+	// Detected from the provider settings and the remote host when omitted.
+	Provider *string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	// BaseBranch is the branch pull requests are opened against and changes are committed to when they
+	// aren't proposed in a pull request. Defaults to main.
+	BaseBranch *string `json:"base-branch,omitempty" yaml:"base-branch,omitempty"`
+}
+
+// DefaultBaseBranch is the base branch of repositories without a configured base branch
+const DefaultBaseBranch = "main"
+
+// BaseBranchName returns the configured base branch of the repository or the default one
+func (g GitConfig) BaseBranchName() string {
+	if g.BaseBranch != nil && *g.BaseBranch != "" {
+		return *g.BaseBranch
+	}
+	return DefaultBaseBranch
 }
 
 func LoadGitConfigFromGhValues(url, ghToken, authorName, authorEmail string) GitConfig {
@@ -122,6 +353,59 @@ func LoadGitConfigFromGhValues(url, ghToken, authorName, authorEmail string) Git
 
 type GitHubConfig struct {
 	Token *Secret `json:"token,omitempty" yaml:"token,omitempty"`
+	// App authenticates as an installation of a GitHub App instead of with Token
+	App *GitHubAppConfig `json:"app,omitempty" yaml:"app,omitempty"`
+	// APIURL is the REST API of a GitHub Enterprise Server, e.g. https://github.example.com/api/v3/. Defaults to
+	// the API of the remote host.
+	APIURL *string `json:"api-url,omitempty" yaml:"api-url,omitempty"`
+	// UploadURL is the upload API of a GitHub Enterprise Server, derived from APIURL when omitted
+	UploadURL *string `json:"upload-url,omitempty" yaml:"upload-url,omitempty"`
+}
+
+// GitHubAppConfig configures a GitHub App. Short-lived installation tokens are minted with the private key of
+// the app and refreshed before they expire.
+type GitHubAppConfig struct {
+	ID int64 `json:"id" yaml:"id"`
+	// InstallationID is the installation of the app on the owner of the repository, discovered when omitted
+	InstallationID int64   `json:"installation-id,omitempty" yaml:"installation-id,omitempty"`
+	PrivateKey     *Secret `json:"private-key" yaml:"private-key"`
+}
+
+// GitLabConfig configures the GitLab API of repositories hosted on GitLab
+type GitLabConfig struct {
+	// Token is a personal, group or project access token with the api and write_repository scopes
+	Token *Secret `json:"token,omitempty" yaml:"token,omitempty"`
+	// APIURL defaults to https://<remote host>/api/v4
+	APIURL *string `json:"api-url,omitempty" yaml:"api-url,omitempty"`
+}
+
+// BitbucketConfig configures the Bitbucket Cloud API of repositories hosted on Bitbucket
+type BitbucketConfig struct {
+	// Token is a repository or workspace access token, or an app password when Username is set
+	Token *Secret `json:"token,omitempty" yaml:"token,omitempty"`
+	// Username of the app password
+	Username *string `json:"username,omitempty" yaml:"username,omitempty"`
+	// APIURL defaults to https://api.bitbucket.org/2.0
+	APIURL *string `json:"api-url,omitempty" yaml:"api-url,omitempty"`
+}
+
+// AzureDevOpsConfig configures the Azure DevOps API of repositories hosted on Azure Repos
+type AzureDevOpsConfig struct {
+	// Token is a personal access token with the Code (read, write and manage) and Variable Groups (read, create,
+	// manage) scopes
+	Token *Secret `json:"token,omitempty" yaml:"token,omitempty"`
+	// APIURL defaults to https://dev.azure.com
+	APIURL *string `json:"api-url,omitempty" yaml:"api-url,omitempty"`
+}
+
+// GiteaConfig configures the API of repositories hosted on Gitea or Forgejo
+type GiteaConfig struct {
+	// Token is an access token with the repository and organization scopes, or the password of Username
+	Token *Secret `json:"token,omitempty" yaml:"token,omitempty"`
+	// Username authenticates with the token as password
+	Username *string `json:"username,omitempty" yaml:"username,omitempty"`
+	// APIURL defaults to https://<remote host>/api/v1
+	APIURL *string `json:"api-url,omitempty" yaml:"api-url,omitempty"`
 }
 
 // AuthConfig represents git authentication configuration
@@ -134,6 +418,11 @@ type AuthConfig struct {
 // SSHConfig represents SSH key configuration
 type SSHConfig struct {
 	Key *Secret `json:"key,omitempty" yaml:"key,omitempty"`
+	// Passphrase decrypts an encrypted key
+	Passphrase *Secret `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
+	// KnownHosts holds the known_hosts lines the host key of the remote is verified against, by default the
+	// files of SSH_KNOWN_HOSTS or ~/.ssh/known_hosts
+	KnownHosts *Secret `json:"known-hosts,omitempty" yaml:"known-hosts,omitempty"`
 }
 
 // Author represents git commit author configuration
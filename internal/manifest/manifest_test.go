@@ -0,0 +1,42 @@
+package manifest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAPIVersioningDeprecatedVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		wantErr    string
+	}{
+		{name: "public", visibility: "public"},
+		{name: "private", visibility: "private"},
+		{name: "unknown", visibility: "hidden", wantErr: `invalid deprecated-visibility "hidden"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var org Organization
+			err := yaml.Unmarshal([]byte("api-versioning:\n  retain: 2\n  deprecated-visibility: "+tt.visibility+"\n"), &org)
+			var fromJSON Organization
+			jsonErr := json.Unmarshal([]byte(`{"api-versioning":{"deprecated-visibility":"`+tt.visibility+`"}}`),
+				&fromJSON)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.ErrorContains(t, jsonErr, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, jsonErr)
+			assert.Equal(t, 2, *org.APIVersioning.Retain)
+			assert.Equal(t, tt.visibility, *org.APIVersioning.DeprecatedVisibility)
+			assert.Equal(t, tt.visibility, *fromJSON.APIVersioning.DeprecatedVisibility)
+		})
+	}
+}
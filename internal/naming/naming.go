@@ -0,0 +1,178 @@
+package naming
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// Default templates, matching the names the orchestrator has always used
+const (
+	DefaultControlPlane = "{{.Team}}-{{.Env}}"
+	DefaultAPI          = `{{if eq .EnvType "PROD"}}{{.Service}}{{else}}{{.Service}}-{{.Env}}{{end}}`
+	DefaultPortal       = "{{.Env}}"
+	DefaultTeam         = "{{.Team}}"
+	DefaultBranch       = "{{.Env}}{{with .Team}}-{{.}}{{end}}{{with .Service}}-{{.}}{{end}}-konnect-orchestrator-apply"
+)
+
+// Vars are the values available to the naming templates. Not every variable is set for every
+// resource, e.g. Service is only known when naming an API. Branches are named with the Team and Service
+// of their pull request grouping, e.g. without Team and Service for one pull request per environment.
+// Version is the spec version of the service, known for the branches of per service pull requests and
+// empty otherwise. API names can't use it: the versions of an API share its name, which the version
+// lifecycle groups them by.
+type Vars struct {
+	Org     string
+	Env     string
+	EnvType string
+	Team    string
+	Service string
+	Version string
+}
+
+var funcs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": strings.ReplaceAll,
+	"trim":    strings.TrimSpace,
+}
+
+// Templates renders the names of the resources managed for an organization
+type Templates struct {
+	controlPlane *template.Template
+	api          *template.Template
+	portal       *template.Template
+	team         *template.Template
+	branch       *template.Template
+}
+
+// New parses the naming templates of an organization, using the defaults for the ones not configured
+func New(cfg *manifest.Naming) (*Templates, error) {
+	if cfg == nil {
+		cfg = &manifest.Naming{}
+	}
+
+	t := &Templates{}
+	for _, n := range []struct {
+		name   string
+		value  *string
+		dflt   string
+		target **template.Template
+	}{
+		{"control-plane", cfg.ControlPlane, DefaultControlPlane, &t.controlPlane},
+		{"api", cfg.API, DefaultAPI, &t.api},
+		{"portal", cfg.Portal, DefaultPortal, &t.portal},
+		{"team", cfg.Team, DefaultTeam, &t.team},
+		{"branch", cfg.Branch, DefaultBranch, &t.branch},
+	} {
+		text := n.dflt
+		if n.value != nil && *n.value != "" {
+			text = *n.value
+		}
+		tmpl, err := template.New(n.name).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s naming template: %w", n.name, err)
+		}
+		if n.target == &t.api && usesField(tmpl.Root, "Version") {
+			return nil, fmt.Errorf("invalid api naming template: .Version can't be used, " +
+				"the versions of an API share its name")
+		}
+		*n.target = tmpl
+	}
+	return t, nil
+}
+
+// Default returns the templates used when an organization has no naming configuration
+func Default() *Templates {
+	t, err := New(nil)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// BranchUsesVersion reports whether branch names depend on the spec version
+func (t *Templates) BranchUsesVersion() bool {
+	return usesField(t.branch.Root, "Version")
+}
+
+func (t *Templates) ControlPlane(v Vars) (string, error) {
+	return render(t.controlPlane, v)
+}
+
+func (t *Templates) API(v Vars) (string, error) {
+	return render(t.api, v)
+}
+
+func (t *Templates) Portal(v Vars) (string, error) {
+	return render(t.portal, v)
+}
+
+func (t *Templates) Team(v Vars) (string, error) {
+	return render(t.team, v)
+}
+
+func (t *Templates) Branch(v Vars) (string, error) {
+	return render(t.branch, v)
+}
+
+func render(tmpl *template.Template, v Vars) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, v); err != nil {
+		return "", fmt.Errorf("failed to render %s name: %w", tmpl.Name(), err)
+	}
+	name := strings.TrimSpace(buf.String())
+	if name == "" {
+		return "", fmt.Errorf("%s naming template rendered an empty name", tmpl.Name())
+	}
+	return name, nil
+}
+
+// usesField reports whether a template refers to a field of its data, e.g. .Version
+func usesField(node parse.Node, field string) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Nodes {
+			if usesField(c, field) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return usesField(n.Pipe, field)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Cmds {
+			if usesField(c, field) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			if usesField(a, field) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == field
+	case *parse.ChainNode:
+		return usesField(n.Node, field) || (len(n.Field) > 0 && n.Field[0] == field)
+	case *parse.IfNode:
+		return usesField(n.Pipe, field) || usesField(n.List, field) || usesField(n.ElseList, field)
+	case *parse.RangeNode:
+		return usesField(n.Pipe, field) || usesField(n.List, field) || usesField(n.ElseList, field)
+	case *parse.WithNode:
+		return usesField(n.Pipe, field) || usesField(n.List, field) || usesField(n.ElseList, field)
+	case *parse.TemplateNode:
+		return usesField(n.Pipe, field)
+	}
+	return false
+}
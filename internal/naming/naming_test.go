@@ -0,0 +1,112 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestTemplates(t *testing.T) {
+	vars := Vars{Org: "kongair", Env: "dev", EnvType: "DEV", Team: "flight-data", Service: "flights", Version: "1.2.0"}
+	prodVars := Vars{Org: "kongair", Env: "prod", EnvType: "PROD", Team: "flight-data", Service: "flights"}
+
+	tests := []struct {
+		name    string
+		cfg     *manifest.Naming
+		render  func(*Templates, Vars) (string, error)
+		vars    Vars
+		want    string
+		wantErr bool
+	}{
+		{name: "default control plane", render: (*Templates).ControlPlane, vars: vars, want: "flight-data-dev"},
+		{name: "default api in dev", render: (*Templates).API, vars: vars, want: "flights-dev"},
+		{name: "default api in prod", render: (*Templates).API, vars: prodVars, want: "flights"},
+		{name: "default portal", render: (*Templates).Portal, vars: vars, want: "dev"},
+		{name: "default team", render: (*Templates).Team, vars: vars, want: "flight-data"},
+		{name: "default branch", render: (*Templates).Branch, vars: Vars{Org: "kongair", Env: "dev", EnvType: "DEV"}, want: "dev-konnect-orchestrator-apply"},
+		{
+			name:   "default branch per team",
+			render: (*Templates).Branch,
+			vars:   Vars{Org: "kongair", Env: "dev", EnvType: "DEV", Team: "flight-data"},
+			want:   "dev-flight-data-konnect-orchestrator-apply",
+		},
+		{name: "default branch per service", render: (*Templates).Branch, vars: vars, want: "dev-flight-data-flights-konnect-orchestrator-apply"},
+		{
+			name:   "custom api",
+			cfg:    &manifest.Naming{API: strPtr("{{.Team}}.{{.Service}}")},
+			render: (*Templates).API,
+			vars:   vars,
+			want:   "flight-data.flights",
+		},
+		{
+			name:   "branch with version",
+			cfg:    &manifest.Naming{Branch: strPtr("{{.Env}}-{{.Service}}-v{{.Version}}")},
+			render: (*Templates).Branch,
+			vars:   vars,
+			want:   "dev-flights-v1.2.0",
+		},
+		{
+			name:   "portal with version",
+			cfg:    &manifest.Naming{Portal: strPtr("{{.Env}}{{with .Version}}-{{.}}{{end}}")},
+			render: (*Templates).Portal,
+			vars:   Vars{Org: "kongair", Env: "dev", EnvType: "DEV"},
+			want:   "dev",
+		},
+		{
+			name:   "custom branch with functions",
+			cfg:    &manifest.Naming{Branch: strPtr(`ko/{{lower .EnvType}}/{{replace .Team "-" "_"}}`)},
+			render: (*Templates).Branch,
+			vars:   vars,
+			want:   "ko/dev/flight_data",
+		},
+		{
+			name:    "empty name",
+			cfg:     &manifest.Naming{Team: strPtr("{{.Service}}")},
+			render:  (*Templates).Team,
+			vars:    Vars{Team: "flight-data"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := New(tt.cfg)
+			assert.NoError(t, err)
+			got, err := tt.render(templates, tt.vars)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewInvalidTemplate(t *testing.T) {
+	_, err := New(&manifest.Naming{Portal: strPtr("{{.Env")})
+	assert.ErrorContains(t, err, "failed to parse portal naming template")
+
+	for _, api := range []string{"{{.Service}}-v{{.Version}}", "{{if .Version}}{{.Service}}{{end}}", `{{printf "%s" .Version}}`} {
+		_, err = New(&manifest.Naming{API: strPtr(api)})
+		assert.ErrorContains(t, err, ".Version can't be used", api)
+	}
+
+	templates, err := New(&manifest.Naming{Portal: strPtr("{{.Environment}}")})
+	assert.NoError(t, err)
+	_, err = templates.Portal(Vars{Env: "dev"})
+	assert.Error(t, err)
+}
+
+func TestBranchUsesVersion(t *testing.T) {
+	assert.False(t, Default().BranchUsesVersion())
+
+	templates, err := New(&manifest.Naming{Branch: strPtr("{{.Env}}-{{.Service}}{{with .Version}}-v{{.}}{{end}}")})
+	assert.NoError(t, err)
+	assert.True(t, templates.BranchUsesVersion())
+}
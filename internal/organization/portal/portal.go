@@ -2,10 +2,10 @@ package portal
 
 import (
 	"context"
-
-	"gopkg.in/yaml.v3"
+	"fmt"
 
 	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/Kong/konnect-orchestrator/internal/spec"
 	kk "github.com/Kong/sdk-konnect-go-internal"
 	"github.com/Kong/sdk-konnect-go-internal/models/components"
 	"github.com/Kong/sdk-konnect-go-internal/models/operations"
@@ -60,6 +60,10 @@ type APIPublicationConfigService interface {
 	PublishAPIToPortal(ctx context.Context,
 		request operations.PublishAPIToPortalRequest,
 		opts ...operations.Option) (*operations.PublishAPIToPortalResponse, error)
+	DeletePublication(ctx context.Context,
+		apiID string,
+		portalID string,
+		opts ...operations.Option) (*operations.DeletePublicationResponse, error)
 }
 
 type APIImplementationConfigService interface {
@@ -70,11 +74,16 @@ type APIImplementationConfigService interface {
 	ListAPIImplementations(ctx context.Context,
 		request operations.ListAPIImplementationsRequest,
 		opts ...operations.Option) (*operations.ListAPIImplementationsResponse, error)
+	DeleteAPIImplementation(ctx context.Context,
+		apiID string,
+		implementationID string,
+		opts ...operations.Option) (*operations.DeleteAPIImplementationResponse, error)
 }
 
 // If you change the name of a portal, a new one will be created an the old one remains
 func ApplyPortalConfig(
 	ctx context.Context,
+	portalName string,
 	portalDisplayName string,
 	envName string,
 	envType string,
@@ -89,7 +98,7 @@ func ApplyPortalConfig(
 		Filter: &components.PortalFilterParameters{
 			Name: &components.StringFieldFilter{
 				StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
-					Str: kk.String(envName),
+					Str: kk.String(portalName),
 				},
 			},
 		},
@@ -108,7 +117,7 @@ func ApplyPortalConfig(
 
 	if len(portals.ListPortalsResponseV3.Data) < 1 {
 		newPortal, err := portalsConfigService.CreatePortal(ctx, components.CreatePortalV3{
-			Name:                             envName,
+			Name:                             portalName,
 			DisplayName:                      kk.String(portalDisplayName),
 			AuthenticationEnabled:            kk.Bool(authEnabled),
 			DefaultAPIVisibility:             components.DefaultAPIVisibility(visibility).ToPointer(),
@@ -142,29 +151,46 @@ func ApplyPortalConfig(
 	return portalID, nil
 }
 
+// APIResult reports the outcome of applying an API to the catalog
+type APIResult struct {
+	ID string
+	// Newest is false when a newer version of the API exists, which owns the gateway service links
+	Newest bool
+	// LinkedServices are the gateway service IDs implementing the API
+	LinkedServices []string
+	// PendingLinks is set when no gateway service exists yet for the API, the links are created
+	// by a later apply once the decK sync has run
+	PendingLinks bool
+}
+
 func ApplyAPIConfig(ctx context.Context,
 	apisConfigService ApisConfigService,
 	apiSpecsConfigService APISpecsConfigService,
 	apiPubConfigService APIPublicationConfigService,
 	apiImplementationConfigService APIImplementationConfigService,
+	apiDocsConfigService APIDocumentationConfigService,
 	apiName string,
 	serviceConfig manifest.Service,
-	rawSpec []byte,
+	specs []spec.File,
+	doc spec.Document,
 	portalID string,
 	cpID string,
-	gwSvcID string,
+	gwSvcIDs []string,
+	versioning *manifest.APIVersioning,
 	labels map[string]string,
-) (string, error) {
-	var spec map[string]interface{}
-	err := yaml.Unmarshal(rawSpec, &spec)
-	if err != nil {
-		return "", err
+) (*APIResult, error) {
+	// Service level metadata takes precedence over the metadata of the spec
+	var version, description string
+	if doc.Info != nil {
+		version = doc.Info.Version
+		description = doc.Info.Description
 	}
-
-	// Extract the version
-	var version string
-	if info, ok := spec["info"].(map[string]interface{}); ok {
-		version = info["version"].(string)
+	if serviceConfig.Description != nil && *serviceConfig.Description != "" {
+		description = *serviceConfig.Description
+	}
+	apiLabels := doc.Labels()
+	for k, v := range labels {
+		apiLabels[k] = v
 	}
 
 	var api *components.APIResponseSchema
@@ -187,7 +213,7 @@ func ApplyAPIConfig(ctx context.Context,
 			},
 		})
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to list APIs named %s: %w", apiName, err)
 	}
 	// **************************************************************************
 
@@ -198,11 +224,11 @@ func ApplyAPIConfig(ctx context.Context,
 			components.CreateAPIRequest{
 				Name:        apiName,
 				Version:     kk.String(version),
-				Description: kk.String(*serviceConfig.Description),
-				Labels:      labels,
+				Description: kk.String(description),
+				Labels:      apiLabels,
 			})
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		api = createResponse.APIResponseSchema
 	} else {
@@ -212,100 +238,167 @@ func ApplyAPIConfig(ctx context.Context,
 			components.UpdateAPIRequest{
 				Name:        kk.String(apiName),
 				Version:     kk.String(version),
-				Description: kk.String(*serviceConfig.Description),
-				Labels:      toPortalLabels(labels),
+				Description: kk.String(description),
 			})
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 	}
 	// **************************************************************************
 
 	// **************************************************************************
-	// Update the API Spec
+	// Update the API Specs, one per spec type
 	listSpecResponse, err := apiSpecsConfigService.ListAPISpecs(ctx, operations.ListAPISpecsRequest{
 		APIID: api.ID,
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if len(listSpecResponse.ListAPISpecResponse.Data) < 1 {
-		_, err = apiSpecsConfigService.CreateAPISpec(ctx, api.ID, components.CreateAPISpecRequest{
-			Content: string(rawSpec),
-			Type:    components.APISpecTypeOas3.ToPointer(),
-		})
-		if err != nil {
-			return "", err
+	// Existing specs are matched by type, two specs of the same type would keep overwriting each other
+	registered := map[components.APISpecType]string{}
+	for _, s := range specs {
+		specType, ok := toAPISpecType(s.Type)
+		if !ok {
+			fmt.Printf("Warn: spec %s of type %s cannot be registered to API %s, "+
+				"it is only stored in the platform repository\n", s.Path, s.Type, apiName)
+			continue
 		}
-	} else {
-		_, err = apiSpecsConfigService.UpdateAPISpec(ctx, operations.UpdateAPISpecRequest{
-			APIID:  api.ID,
-			SpecID: listSpecResponse.ListAPISpecResponse.Data[0].ID,
-			APISpec: components.APISpec{
-				Content: kk.String(string(rawSpec)),
-				Type:    components.APISpecTypeOas3.ToPointer(),
-			},
-		})
-		if err != nil {
-			return "", err
+		if other, ok := registered[specType]; ok {
+			return nil, fmt.Errorf("specs %s and %s of API %s are both of type %s", other, s.Path, apiName, s.Type)
+		}
+		registered[specType] = s.Path
+
+		var existingSpecID string
+		for _, existing := range listSpecResponse.ListAPISpecResponse.Data {
+			if existing.Type != nil && *existing.Type == specType {
+				existingSpecID = existing.ID
+				break
+			}
+		}
+
+		if existingSpecID == "" {
+			_, err = apiSpecsConfigService.CreateAPISpec(ctx, api.ID, components.CreateAPISpecRequest{
+				Content: string(s.Content),
+				Type:    specType.ToPointer(),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create spec %s for API %s: %w", s.Path, apiName, err)
+			}
+		} else {
+			_, err = apiSpecsConfigService.UpdateAPISpec(ctx, operations.UpdateAPISpecRequest{
+				APIID:  api.ID,
+				SpecID: existingSpecID,
+				APISpec: components.APISpec{
+					Content: kk.String(string(s.Content)),
+					Type:    specType.ToPointer(),
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to update spec %s for API %s: %w", s.Path, apiName, err)
+			}
 		}
 	}
 	// **************************************************************************
 
 	// **************************************************************************
-	// Publish the API to the portal
-	_, err = apiPubConfigService.PublishAPIToPortal(ctx,
-		operations.PublishAPIToPortalRequest{
-			APIID:    api.ID,
-			PortalID: portalID,
-		})
+	// Label and publish the API by its lifecycle, deprecate older versions of the API and move the
+	// implementation to the newest one. The labels of an existing API are only written here so an
+	// older version keeps its deprecated or retired state.
+	isNewest, err := applyVersionLifecycle(ctx,
+		apisConfigService,
+		apiPubConfigService,
+		apiImplementationConfigService,
+		apiDocsConfigService,
+		apiName,
+		*api,
+		portalID,
+		cpID,
+		versioning,
+		apiLabels)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	// **************************************************************************
 
-	if gwSvcID == "" {
-		return api.ID, nil
+	result := &APIResult{ID: api.ID, Newest: isNewest}
+
+	// Only the newest version of an API is implemented by the gateway services
+	if !isNewest {
+		return result, nil
+	}
+
+	// The gateway services only exist once the decK sync of the platform repository has run,
+	// until then the links are pending and are created by a later apply
+	if len(gwSvcIDs) == 0 {
+		result.PendingLinks = true
+		return result, nil
 	}
 
 	// **************************************************************************
-	// Create an API Implementation
-	apiImpls, err := apiImplementationConfigService.ListAPIImplementations(ctx, operations.ListAPIImplementationsRequest{
-		Filter: &components.APIImplementationFilterParameters{
-			APIID: &components.UUIDFieldFilter{
-				StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
-					Str: kk.String(api.ID),
+	// Create an API Implementation per gateway service and remove the stale ones
+	keep := map[string]struct{}{}
+	for _, gwSvcID := range gwSvcIDs {
+		keep[gwSvcID] = struct{}{}
+	}
+	if err := removeAPIImplementations(ctx, apiImplementationConfigService, api.ID, cpID, keep); err != nil {
+		return nil, err
+	}
+
+	for _, gwSvcID := range gwSvcIDs {
+		apiImpls, err := apiImplementationConfigService.ListAPIImplementations(ctx, operations.ListAPIImplementationsRequest{
+			Filter: &components.APIImplementationFilterParameters{
+				APIID: &components.UUIDFieldFilter{
+					StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
+						Str: kk.String(api.ID),
+					},
 				},
-			},
-			ControlPlaneID: &components.UUIDFieldFilter{
-				StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
-					Str: kk.String(cpID),
+				ControlPlaneID: &components.UUIDFieldFilter{
+					StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
+						Str: kk.String(cpID),
+					},
 				},
-			},
-			ServiceID: &components.UUIDFieldFilter{
-				StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
-					Str: kk.String(gwSvcID),
+				ServiceID: &components.UUIDFieldFilter{
+					StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
+						Str: kk.String(gwSvcID),
+					},
 				},
 			},
-		},
-	})
-	if err != nil {
-		return "", err
-	}
-
-	if len(apiImpls.ListAPIImplementationsResponse.Data) < 1 {
-		_, err = apiImplementationConfigService.CreateAPIImplementation(ctx, api.ID, components.APIImplementation{
-			Service: components.APIImplementationService{
-				ControlPlaneID: cpID,
-				ID:             gwSvcID,
-			},
 		})
 		if err != nil {
-			return "", err
+			return nil, fmt.Errorf("failed to list API implementations for API %s: %w", apiName, err)
 		}
+
+		if len(apiImpls.ListAPIImplementationsResponse.Data) < 1 {
+			_, err = apiImplementationConfigService.CreateAPIImplementation(ctx, api.ID, components.APIImplementation{
+				Service: components.APIImplementationService{
+					ControlPlaneID: cpID,
+					ID:             gwSvcID,
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to link gateway service %s to API %s: %w", gwSvcID, apiName, err)
+			}
+		}
+		result.LinkedServices = append(result.LinkedServices, gwSvcID)
 	}
+	// **************************************************************************
+
+	return result, nil
+}
 
-	return api.ID, nil
+// toAPISpecType maps a spec type to the Konnect API spec type, false if Konnect has no equivalent
+func toAPISpecType(t spec.Type) (components.APISpecType, bool) {
+	switch t {
+	case spec.OAS2:
+		return components.APISpecTypeOas2, true
+	case spec.OAS3:
+		return components.APISpecTypeOas3, true
+	case spec.AsyncAPI:
+		return components.APISpecTypeAsyncapi, true
+	case spec.GraphQL, spec.Proto:
+		return "", false
+	}
+	return "", false
 }
 
 func toPortalLabels(labels map[string]string) map[string]*string {
@@ -0,0 +1,341 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	kk "github.com/Kong/sdk-konnect-go-internal"
+	"github.com/Kong/sdk-konnect-go-internal/models/components"
+	"github.com/Kong/sdk-konnect-go-internal/models/operations"
+	"github.com/Kong/sdk-konnect-go-internal/models/sdkerrors"
+)
+
+const (
+	lifecycleLabel        = "ko-api-lifecycle"
+	successorVersionLabel = "ko-api-successor-version"
+	deprecationNoticeSlug = "deprecation-notice"
+
+	lifecycleCurrent    = "current"
+	lifecycleDeprecated = "deprecated"
+	lifecycleRetired    = "retired"
+)
+
+type APIDocumentationConfigService interface {
+	ListAPIDocuments(ctx context.Context,
+		request operations.ListAPIDocumentsRequest,
+		opts ...operations.Option) (*operations.ListAPIDocumentsResponse, error)
+	CreateAPIDocument(ctx context.Context,
+		apiID string,
+		createAPIDocumentRequest components.CreateAPIDocumentRequest,
+		opts ...operations.Option) (*operations.CreateAPIDocumentResponse, error)
+	UpdateAPIDocument(ctx context.Context,
+		request operations.UpdateAPIDocumentRequest,
+		opts ...operations.Option) (*operations.UpdateAPIDocumentResponse, error)
+}
+
+// applyVersionLifecycle walks every catalog entry sharing apiName and applies the version lifecycle:
+// the newest version is labelled current and published, the next `retain - 1` versions are deprecated
+// and everything older is unpublished from the portal. Gateway service implementations are moved off
+// of the older versions so only the newest version is linked to the running service.
+// It returns false if currentAPI is not the newest version, in which case only the deprecated or
+// retired state of currentAPI is applied again, so re-applying an older spec doesn't revive it.
+func applyVersionLifecycle(ctx context.Context,
+	apisConfigService ApisConfigService,
+	apiPubConfigService APIPublicationConfigService,
+	apiImplementationConfigService APIImplementationConfigService,
+	apiDocsConfigService APIDocumentationConfigService,
+	apiName string,
+	currentAPI components.APIResponseSchema,
+	portalID string,
+	cpID string,
+	versioning *manifest.APIVersioning,
+	labels map[string]string,
+) (bool, error) {
+	resp, err := apisConfigService.ListApis(ctx,
+		operations.ListApisRequest{
+			Filter: &components.APIFilterParameters{
+				Name: &components.StringFieldFilter{
+					StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
+						Str: kk.String(apiName),
+					},
+				},
+			},
+		})
+	if err != nil {
+		return false, fmt.Errorf("failed to list versions of API %s: %w", apiName, err)
+	}
+
+	versions := append([]components.APIResponseSchema{}, resp.ListAPIResponse.Data...)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareVersions(apiVersion(versions[i]), apiVersion(versions[j])) > 0
+	})
+	if len(versions) == 0 {
+		versions = []components.APIResponseSchema{currentAPI}
+	}
+
+	retain, deprecatedVisibility := versioningSettings(versioning)
+	newestVersion := apiVersion(versions[0])
+	isNewest := versions[0].ID == currentAPI.ID
+
+	for i, api := range versions {
+		if !isNewest && api.ID != currentAPI.ID {
+			continue
+		}
+
+		lifecycle := lifecycleCurrent
+		if i > 0 {
+			lifecycle = lifecycleDeprecated
+			if retain > 0 && i >= retain {
+				lifecycle = lifecycleRetired
+			}
+		}
+
+		if err := applyVersionState(ctx,
+			apisConfigService,
+			apiPubConfigService,
+			apiImplementationConfigService,
+			apiDocsConfigService,
+			apiName,
+			api,
+			lifecycle,
+			newestVersion,
+			portalID,
+			cpID,
+			deprecatedVisibility,
+			labels); err != nil {
+			return false, err
+		}
+	}
+
+	return isNewest, nil
+}
+
+// applyVersionState labels and publishes a version of an API according to its lifecycle
+func applyVersionState(ctx context.Context,
+	apisConfigService ApisConfigService,
+	apiPubConfigService APIPublicationConfigService,
+	apiImplementationConfigService APIImplementationConfigService,
+	apiDocsConfigService APIDocumentationConfigService,
+	apiName string,
+	api components.APIResponseSchema,
+	lifecycle string,
+	newestVersion string,
+	portalID string,
+	cpID string,
+	deprecatedVisibility string,
+	labels map[string]string,
+) error {
+	apiLabels := map[string]string{}
+	for k, v := range labels {
+		apiLabels[k] = v
+	}
+	apiLabels[lifecycleLabel] = lifecycle
+	if lifecycle != lifecycleCurrent {
+		apiLabels[successorVersionLabel] = newestVersion
+	}
+
+	_, err := apisConfigService.UpdateAPI(ctx, api.ID, components.UpdateAPIRequest{
+		Labels: toPortalLabels(apiLabels),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to label API %s version %s as %s: %w",
+			apiName, apiVersion(api), lifecycle, err)
+	}
+
+	if lifecycle == lifecycleCurrent {
+		// The newest version is published with the default visibility of the portal
+		_, err = apiPubConfigService.PublishAPIToPortal(ctx,
+			operations.PublishAPIToPortalRequest{
+				APIID:    api.ID,
+				PortalID: portalID,
+			})
+		if err != nil {
+			return fmt.Errorf("failed to publish API %s version %s: %w", apiName, apiVersion(api), err)
+		}
+		return nil
+	}
+
+	// Older versions are no longer linked to the gateway service, the newest version owns it
+	if err := removeAPIImplementations(ctx, apiImplementationConfigService, api.ID, cpID, nil); err != nil {
+		return err
+	}
+
+	if lifecycle == lifecycleRetired {
+		// Retired versions stay retired, their publication is already gone after the first apply
+		_, err = apiPubConfigService.DeletePublication(ctx, api.ID, portalID)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to unpublish API %s version %s: %w", apiName, apiVersion(api), err)
+		}
+		return nil
+	}
+
+	_, err = apiPubConfigService.PublishAPIToPortal(ctx,
+		operations.PublishAPIToPortalRequest{
+			APIID:    api.ID,
+			PortalID: portalID,
+			APIPublication: components.APIPublication{
+				Visibility: components.APIPublicationVisibility(deprecatedVisibility).ToPointer(),
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to publish deprecated API %s version %s: %w", apiName, apiVersion(api), err)
+	}
+
+	return applyDeprecationNotice(ctx, apiDocsConfigService, api, apiName, newestVersion)
+}
+
+// removeAPIImplementations deletes the implementations of an API on the given control plane,
+// except for the gateway services listed in keep
+func removeAPIImplementations(ctx context.Context,
+	apiImplementationConfigService APIImplementationConfigService,
+	apiID string,
+	cpID string,
+	keep map[string]struct{},
+) error {
+	apiImpls, err := apiImplementationConfigService.ListAPIImplementations(ctx, operations.ListAPIImplementationsRequest{
+		Filter: &components.APIImplementationFilterParameters{
+			APIID: &components.UUIDFieldFilter{
+				StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
+					Str: kk.String(apiID),
+				},
+			},
+			ControlPlaneID: &components.UUIDFieldFilter{
+				StringFieldEqualsFilter: &components.StringFieldEqualsFilter{
+					Str: kk.String(cpID),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list API implementations for API %s: %w", apiID, err)
+	}
+
+	for _, impl := range apiImpls.ListAPIImplementationsResponse.Data {
+		if impl.Service != nil {
+			if _, ok := keep[impl.Service.ID]; ok {
+				continue
+			}
+		}
+		_, err = apiImplementationConfigService.DeleteAPIImplementation(ctx, apiID, impl.ID)
+		if err != nil {
+			return fmt.Errorf("failed to delete API implementation %s for API %s: %w", impl.ID, apiID, err)
+		}
+	}
+	return nil
+}
+
+// applyDeprecationNotice creates or updates a document on a deprecated API version pointing
+// consumers to the newest version
+func applyDeprecationNotice(ctx context.Context,
+	apiDocsConfigService APIDocumentationConfigService,
+	api components.APIResponseSchema,
+	apiName string,
+	newestVersion string,
+) error {
+	content := fmt.Sprintf("# Deprecation notice\n\n"+
+		"Version `%s` of the %s API is deprecated and will be removed from this portal in a future release.\n\n"+
+		"Migrate to version `%s`, which is the current version of this API.\n",
+		apiVersion(api), apiName, newestVersion)
+
+	docs, err := apiDocsConfigService.ListAPIDocuments(ctx, operations.ListAPIDocumentsRequest{
+		APIID: api.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list documents for API %s: %w", api.ID, err)
+	}
+
+	for _, doc := range docs.ListAPIDocumentResponse.Data {
+		if doc.Slug != deprecationNoticeSlug {
+			continue
+		}
+		_, err = apiDocsConfigService.UpdateAPIDocument(ctx, operations.UpdateAPIDocumentRequest{
+			APIID:      api.ID,
+			DocumentID: doc.ID,
+			APIDocument: components.APIDocument{
+				Content: kk.String(content),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update deprecation notice for API %s: %w", api.ID, err)
+		}
+		return nil
+	}
+
+	_, err = apiDocsConfigService.CreateAPIDocument(ctx, api.ID, components.CreateAPIDocumentRequest{
+		Title:   kk.String("Deprecation notice"),
+		Slug:    kk.String(deprecationNoticeSlug),
+		Content: content,
+		Status:  components.APIDocumentStatusPublished.ToPointer(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create deprecation notice for API %s: %w", api.ID, err)
+	}
+	return nil
+}
+
+// isNotFound reports whether the Konnect API responded with 404 Not Found
+func isNotFound(err error) bool {
+	var notFound *sdkerrors.NotFoundError
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var sdkErr *sdkerrors.SDKError
+	return errors.As(err, &sdkErr) && sdkErr.StatusCode == http.StatusNotFound
+}
+
+func versioningSettings(versioning *manifest.APIVersioning) (int, string) {
+	retain := 0
+	visibility := "public"
+	if versioning != nil {
+		if versioning.Retain != nil {
+			retain = *versioning.Retain
+		}
+		if versioning.DeprecatedVisibility != nil {
+			visibility = *versioning.DeprecatedVisibility
+		}
+	}
+	return retain, visibility
+}
+
+func apiVersion(api components.APIResponseSchema) string {
+	if api.Version == nil {
+		return ""
+	}
+	return *api.Version
+}
+
+// compareVersions orders dotted version strings numerically where possible, e.g. 1.10.0 > 1.9.2.
+// A leading "v" is ignored and non-numeric segments fall back to a lexical comparison.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil:
+			if xn != yn {
+				if xn > yn {
+					return 1
+				}
+				return -1
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
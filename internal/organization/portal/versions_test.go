@@ -0,0 +1,305 @@
+package portal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	kk "github.com/Kong/sdk-konnect-go-internal"
+	"github.com/Kong/sdk-konnect-go-internal/models/components"
+	"github.com/Kong/sdk-konnect-go-internal/models/operations"
+	"github.com/Kong/sdk-konnect-go-internal/models/sdkerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.10.0", b: "1.9.2", want: 1},
+		{a: "1.9.2", b: "1.10.0", want: -1},
+		{a: "2.0.0", b: "10.0.0", want: -1},
+		{a: "v1.2.0", b: "1.2.0", want: 0},
+		{a: "1.2", b: "1.2.0", want: 0},
+		{a: "1.2.1", b: "1.2", want: 1},
+		{a: "1.0.0-beta", b: "1.0.0-alpha", want: 1},
+		{a: "1.0.0", b: "", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareVersions(tt.a, tt.b))
+		})
+	}
+}
+
+// fakeCatalog is an API catalog recording the lifecycle changes of the versions of an API
+type fakeCatalog struct {
+	apis []components.APIResponseSchema
+	// labels are the lifecycle labels of the APIs by ID
+	labels map[string]string
+	// published are the visibilities of the publications of the APIs by ID
+	published map[string]components.APIPublicationVisibility
+	// unpublished counts the publication deletions by API ID
+	unpublished map[string]int
+	notices     map[string]bool
+}
+
+func newFakeCatalog(versions ...string) *fakeCatalog {
+	c := &fakeCatalog{
+		labels:      map[string]string{},
+		published:   map[string]components.APIPublicationVisibility{},
+		unpublished: map[string]int{},
+		notices:     map[string]bool{},
+	}
+	for _, v := range versions {
+		c.apis = append(c.apis, components.APIResponseSchema{ID: "api-" + v, Name: "flights", Version: kk.String(v)})
+		c.published["api-"+v] = "public"
+	}
+	return c
+}
+
+func (c *fakeCatalog) api(version string) components.APIResponseSchema {
+	for _, api := range c.apis {
+		if *api.Version == version {
+			return api
+		}
+	}
+	return components.APIResponseSchema{}
+}
+
+func (c *fakeCatalog) ListApis(_ context.Context,
+	_ operations.ListApisRequest,
+	_ ...operations.Option,
+) (*operations.ListApisResponse, error) {
+	return &operations.ListApisResponse{ListAPIResponse: &components.ListAPIResponse{Data: c.apis}}, nil
+}
+
+func (c *fakeCatalog) CreateAPI(_ context.Context,
+	_ components.CreateAPIRequest,
+	_ ...operations.Option,
+) (*operations.CreateAPIResponse, error) {
+	return &operations.CreateAPIResponse{}, nil
+}
+
+func (c *fakeCatalog) UpdateAPI(_ context.Context,
+	apiID string,
+	request components.UpdateAPIRequest,
+	_ ...operations.Option,
+) (*operations.UpdateAPIResponse, error) {
+	if lifecycle := request.Labels[lifecycleLabel]; lifecycle != nil {
+		c.labels[apiID] = *lifecycle
+	}
+	return &operations.UpdateAPIResponse{}, nil
+}
+
+func (c *fakeCatalog) PublishAPIToPortal(_ context.Context,
+	request operations.PublishAPIToPortalRequest,
+	_ ...operations.Option,
+) (*operations.PublishAPIToPortalResponse, error) {
+	// without a visibility the API is published with the default visibility of the portal
+	visibility := components.APIPublicationVisibility("default")
+	if request.APIPublication.Visibility != nil {
+		visibility = *request.APIPublication.Visibility
+	}
+	c.published[request.APIID] = visibility
+	return &operations.PublishAPIToPortalResponse{}, nil
+}
+
+func (c *fakeCatalog) DeletePublication(_ context.Context,
+	apiID string,
+	_ string,
+	_ ...operations.Option,
+) (*operations.DeletePublicationResponse, error) {
+	c.unpublished[apiID]++
+	if _, ok := c.published[apiID]; !ok {
+		return nil, &sdkerrors.NotFoundError{}
+	}
+	delete(c.published, apiID)
+	return &operations.DeletePublicationResponse{}, nil
+}
+
+func (c *fakeCatalog) CreateAPIImplementation(_ context.Context,
+	_ string,
+	_ components.APIImplementation,
+	_ ...operations.Option,
+) (*operations.CreateAPIImplementationResponse, error) {
+	return &operations.CreateAPIImplementationResponse{}, nil
+}
+
+func (c *fakeCatalog) ListAPIImplementations(_ context.Context,
+	_ operations.ListAPIImplementationsRequest,
+	_ ...operations.Option,
+) (*operations.ListAPIImplementationsResponse, error) {
+	return &operations.ListAPIImplementationsResponse{
+		ListAPIImplementationsResponse: &components.ListAPIImplementationsResponse{},
+	}, nil
+}
+
+func (c *fakeCatalog) DeleteAPIImplementation(_ context.Context,
+	_ string,
+	_ string,
+	_ ...operations.Option,
+) (*operations.DeleteAPIImplementationResponse, error) {
+	return &operations.DeleteAPIImplementationResponse{}, nil
+}
+
+func (c *fakeCatalog) ListAPIDocuments(_ context.Context,
+	_ operations.ListAPIDocumentsRequest,
+	_ ...operations.Option,
+) (*operations.ListAPIDocumentsResponse, error) {
+	return &operations.ListAPIDocumentsResponse{ListAPIDocumentResponse: &components.ListAPIDocumentResponse{}}, nil
+}
+
+func (c *fakeCatalog) CreateAPIDocument(_ context.Context,
+	apiID string,
+	_ components.CreateAPIDocumentRequest,
+	_ ...operations.Option,
+) (*operations.CreateAPIDocumentResponse, error) {
+	c.notices[apiID] = true
+	return &operations.CreateAPIDocumentResponse{}, nil
+}
+
+func (c *fakeCatalog) UpdateAPIDocument(_ context.Context,
+	_ operations.UpdateAPIDocumentRequest,
+	_ ...operations.Option,
+) (*operations.UpdateAPIDocumentResponse, error) {
+	return &operations.UpdateAPIDocumentResponse{}, nil
+}
+
+func TestApplyVersionLifecycle(t *testing.T) {
+	tests := []struct {
+		name       string
+		versions   []string
+		current    string
+		versioning *manifest.APIVersioning
+		wantNewest bool
+		// want are the lifecycle labels by version
+		want map[string]string
+		// wantPublished are the visibilities of the published versions
+		wantPublished map[string]components.APIPublicationVisibility
+	}{
+		{
+			name:       "retains every version by default",
+			versions:   []string{"1.9.0", "1.10.0", "1.2.0"},
+			current:    "1.10.0",
+			wantNewest: true,
+			want: map[string]string{
+				"1.10.0": lifecycleCurrent,
+				"1.9.0":  lifecycleDeprecated,
+				"1.2.0":  lifecycleDeprecated,
+			},
+			wantPublished: map[string]components.APIPublicationVisibility{
+				"1.10.0": "default",
+				"1.9.0":  "public",
+				"1.2.0":  "public",
+			},
+		},
+		{
+			name:     "retires the versions beyond retain",
+			versions: []string{"1.9.0", "2.0.0", "1.10.0", "1.2.0"},
+			current:  "2.0.0",
+			versioning: &manifest.APIVersioning{
+				Retain:               kk.Int(2),
+				DeprecatedVisibility: kk.String("private"),
+			},
+			wantNewest: true,
+			want: map[string]string{
+				"2.0.0":  lifecycleCurrent,
+				"1.10.0": lifecycleDeprecated,
+				"1.9.0":  lifecycleRetired,
+				"1.2.0":  lifecycleRetired,
+			},
+			wantPublished: map[string]components.APIPublicationVisibility{
+				"2.0.0":  "default",
+				"1.10.0": "private",
+			},
+		},
+		{
+			name:       "retain of one keeps the newest version only",
+			versions:   []string{"v1", "v2"},
+			current:    "v2",
+			versioning: &manifest.APIVersioning{Retain: kk.Int(1)},
+			wantNewest: true,
+			want: map[string]string{
+				"v2": lifecycleCurrent,
+				"v1": lifecycleRetired,
+			},
+			wantPublished: map[string]components.APIPublicationVisibility{
+				"v2": "default",
+			},
+		},
+		{
+			name:       "keeps an older applied version retired",
+			versions:   []string{"1.9.0", "1.10.0"},
+			current:    "1.9.0",
+			versioning: &manifest.APIVersioning{Retain: kk.Int(1)},
+			want: map[string]string{
+				"1.9.0": lifecycleRetired,
+			},
+			wantPublished: map[string]components.APIPublicationVisibility{
+				"1.10.0": "public",
+			},
+		},
+		{
+			name:     "keeps an older applied version deprecated",
+			versions: []string{"1.9.0", "1.10.0", "1.8.0"},
+			current:  "1.9.0",
+			versioning: &manifest.APIVersioning{
+				Retain:               kk.Int(2),
+				DeprecatedVisibility: kk.String("private"),
+			},
+			want: map[string]string{
+				"1.9.0": lifecycleDeprecated,
+			},
+			wantPublished: map[string]components.APIPublicationVisibility{
+				"1.10.0": "public",
+				"1.9.0":  "private",
+				"1.8.0":  "public",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeCatalog(tt.versions...)
+			ctx := context.Background()
+
+			// a second apply finds the retired versions already unpublished
+			for i := 0; i < 2; i++ {
+				newest, err := applyVersionLifecycle(ctx, c, c, c, c, "flights", c.api(tt.current),
+					"portal-1", "cp-1", tt.versioning, nil)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantNewest, newest)
+			}
+
+			labels := map[string]string{}
+			for id, lifecycle := range c.labels {
+				labels[*c.apiByID(id).Version] = lifecycle
+			}
+			assert.Equal(t, tt.want, labels)
+
+			published := map[string]components.APIPublicationVisibility{}
+			for id, visibility := range c.published {
+				published[*c.apiByID(id).Version] = visibility
+			}
+			assert.Equal(t, tt.wantPublished, published)
+
+			for version, lifecycle := range tt.want {
+				assert.Equal(t, lifecycle == lifecycleDeprecated, c.notices[c.api(version).ID],
+					"deprecation notice of %s", version)
+			}
+		})
+	}
+}
+
+func (c *fakeCatalog) apiByID(id string) components.APIResponseSchema {
+	for _, api := range c.apis {
+		if api.ID == id {
+			return api
+		}
+	}
+	return components.APIResponseSchema{}
+}
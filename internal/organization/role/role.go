@@ -32,73 +32,122 @@ const (
 	PROD EnvironmentType = "PROD"
 )
 
-func applyProdRoles(
-	ctx context.Context,
-	rolesSvc Service,
-	teamID string,
-	cpID string,
-	envConfig manifest.Environment,
-) error {
-	// Check if the role is already assigned
-	listResp, err := rolesSvc.ListTeamRoles(ctx, teamID, &operations.ListTeamRolesQueryParamFilter{
-		RoleName:       kk.Pointer(components.CreateStringFieldEqualsFilterStr("Viewer")),
+// SystemAccountService is the Roles SDK API of system accounts
+type SystemAccountService interface {
+	GetSystemAccountsAccountIDAssignedRoles(ctx context.Context,
+		accountID string,
+		filter *operations.GetSystemAccountsAccountIDAssignedRolesQueryParamFilter,
+		opts ...operations.Option) (*operations.GetSystemAccountsAccountIDAssignedRolesResponse, error)
+	PostSystemAccountsAccountIDAssignedRoles(ctx context.Context,
+		accountID string,
+		assignRole *components.AssignRole,
+		opts ...operations.Option) (*operations.PostSystemAccountsAccountIDAssignedRolesResponse, error)
+}
+
+// assignee lists and assigns the roles of a team or of a system account
+type assignee interface {
+	listRoles(ctx context.Context, roleName string) (*components.AssignedRoleCollection, error)
+	assignRole(ctx context.Context, assignRole *components.AssignRole) error
+}
+
+type teamAssignee struct {
+	svc    Service
+	teamID string
+}
+
+func (a teamAssignee) listRoles(ctx context.Context, roleName string) (*components.AssignedRoleCollection, error) {
+	resp, err := a.svc.ListTeamRoles(ctx, a.teamID, &operations.ListTeamRolesQueryParamFilter{
+		RoleName:       kk.Pointer(components.CreateStringFieldEqualsFilterStr(roleName)),
 		EntityTypeName: kk.Pointer(components.CreateStringFieldEqualsFilterStr("Control Planes")),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to list team roles: %w", err)
+		return nil, fmt.Errorf("failed to list team roles: %w", err)
+	}
+	if resp == nil {
+		return nil, nil
 	}
+	return resp.AssignedRoleCollection, nil
+}
 
-	if listResp != nil && listResp.AssignedRoleCollection != nil && len(listResp.AssignedRoleCollection.Data) > 0 {
-		for _, assignedRole := range listResp.AssignedRoleCollection.Data {
-			if *assignedRole.RoleName == "Viewer" && *assignedRole.EntityID == cpID {
-				return nil
-			}
-		}
+func (a teamAssignee) assignRole(ctx context.Context, assignRole *components.AssignRole) error {
+	if _, err := a.svc.TeamsAssignRole(ctx, a.teamID, assignRole); err != nil {
+		return fmt.Errorf("failed to assign role to team: %w", err)
 	}
-	_, err = rolesSvc.TeamsAssignRole(ctx, teamID, &components.AssignRole{
-		RoleName:       kk.Pointer(components.RoleName("Viewer")),
-		EntityID:       kk.Pointer(cpID),
-		EntityRegion:   kk.Pointer(components.EntityRegion(envConfig.Region)),
-		EntityTypeName: kk.Pointer(components.EntityTypeName("Control Planes")),
-	})
+	return nil
+}
+
+type systemAccountAssignee struct {
+	svc       SystemAccountService
+	accountID string
+}
+
+func (a systemAccountAssignee) listRoles(ctx context.Context,
+	roleName string,
+) (*components.AssignedRoleCollection, error) {
+	resp, err := a.svc.GetSystemAccountsAccountIDAssignedRoles(ctx, a.accountID,
+		&operations.GetSystemAccountsAccountIDAssignedRolesQueryParamFilter{
+			RoleName:       kk.Pointer(components.CreateStringFieldEqualsFilterStr(roleName)),
+			EntityTypeName: kk.Pointer(components.CreateStringFieldEqualsFilterStr("Control Planes")),
+		})
 	if err != nil {
-		return fmt.Errorf("failed to assign role to team: %w", err)
+		return nil, fmt.Errorf("failed to list system account roles: %w", err)
+	}
+	if resp == nil {
+		return nil, nil
+	}
+	return resp.AssignedRoleCollection, nil
+}
+
+func (a systemAccountAssignee) assignRole(ctx context.Context, assignRole *components.AssignRole) error {
+	if _, err := a.svc.PostSystemAccountsAccountIDAssignedRoles(ctx, a.accountID, assignRole); err != nil {
+		return fmt.Errorf("failed to assign role to system account: %w", err)
 	}
 	return nil
 }
 
-func applyDevRoles(
+// applyControlPlaneRole assigns a role on a control plane unless it's already assigned
+func applyControlPlaneRole(
 	ctx context.Context,
-	rolesSvc Service,
-	teamID string,
+	a assignee,
+	roleName string,
 	cpID string,
 	envConfig manifest.Environment,
 ) error {
 	// Check if the role is already assigned
-	listResp, err := rolesSvc.ListTeamRoles(ctx, teamID, &operations.ListTeamRolesQueryParamFilter{
-		RoleName:       kk.Pointer(components.CreateStringFieldEqualsFilterStr("Admin")),
-		EntityTypeName: kk.Pointer(components.CreateStringFieldEqualsFilterStr("Control Planes")),
-	})
+	assigned, err := a.listRoles(ctx, roleName)
 	if err != nil {
-		return fmt.Errorf("failed to list team roles: %w", err)
+		return err
 	}
 
-	if listResp != nil && listResp.AssignedRoleCollection != nil && len(listResp.AssignedRoleCollection.Data) > 0 {
-		for _, assignedRole := range listResp.AssignedRoleCollection.Data {
-			if *assignedRole.RoleName == "Admin" && *assignedRole.EntityID == cpID {
+	if assigned != nil {
+		for _, assignedRole := range assigned.Data {
+			if *assignedRole.RoleName == roleName && *assignedRole.EntityID == cpID {
 				return nil
 			}
 		}
 	}
-	_, err = rolesSvc.TeamsAssignRole(ctx, teamID, &components.AssignRole{
-		RoleName:       kk.Pointer(components.RoleName("Admin")),
+	return a.assignRole(ctx, &components.AssignRole{
+		RoleName:       kk.Pointer(components.RoleName(roleName)),
 		EntityID:       kk.Pointer(cpID),
 		EntityRegion:   kk.Pointer(components.EntityRegion(envConfig.Region)),
 		EntityTypeName: kk.Pointer(components.EntityTypeName("Control Planes")),
 	})
-	if err != nil {
-		return fmt.Errorf("failed to assign role to team: %w", err)
+}
+
+// applyEnvironmentRoles assigns the Admin role of a DEV control plane or the Viewer role of a PROD control plane
+func applyEnvironmentRoles(
+	ctx context.Context,
+	a assignee,
+	cpID string,
+	envConfig manifest.Environment,
+) error {
+	envType := EnvironmentType(envConfig.Type)
+	if envType == DEV {
+		return applyControlPlaneRole(ctx, a, "Admin", cpID, envConfig)
+	} else if envType == PROD {
+		return applyControlPlaneRole(ctx, a, "Viewer", cpID, envConfig)
 	}
+
 	return nil
 }
 
@@ -110,12 +159,17 @@ func ApplyRoles(
 	cpID string,
 	envConfig manifest.Environment,
 ) error {
-	envType := EnvironmentType(envConfig.Type)
-	if envType == DEV {
-		return applyDevRoles(ctx, rolesSvc, teamID, cpID, envConfig)
-	} else if envType == PROD {
-		return applyProdRoles(ctx, rolesSvc, teamID, cpID, envConfig)
-	}
+	return applyEnvironmentRoles(ctx, teamAssignee{svc: rolesSvc, teamID: teamID}, cpID, envConfig)
+}
 
-	return nil
+// ApplySystemAccountRoles assigns a system account the roles a team has on its control plane in the
+// environment
+func ApplySystemAccountRoles(
+	ctx context.Context,
+	rolesSvc SystemAccountService,
+	accountID string,
+	cpID string,
+	envConfig manifest.Environment,
+) error {
+	return applyEnvironmentRoles(ctx, systemAccountAssignee{svc: rolesSvc, accountID: accountID}, cpID, envConfig)
 }
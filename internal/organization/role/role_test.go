@@ -183,3 +183,66 @@ func TestApplyRoles(t *testing.T) {
 		})
 	}
 }
+
+// fakeSystemAccountService records the roles assigned to system accounts
+type fakeSystemAccountService struct {
+	assigned []components.AssignedRole
+}
+
+func (f *fakeSystemAccountService) GetSystemAccountsAccountIDAssignedRoles(_ context.Context,
+	_ string,
+	filter *operations.GetSystemAccountsAccountIDAssignedRolesQueryParamFilter,
+	_ ...operations.Option,
+) (*operations.GetSystemAccountsAccountIDAssignedRolesResponse, error) {
+	var data []components.AssignedRole
+	for _, r := range f.assigned {
+		if filter == nil || *r.RoleName == *filter.RoleName.Str {
+			data = append(data, r)
+		}
+	}
+	return &operations.GetSystemAccountsAccountIDAssignedRolesResponse{
+		AssignedRoleCollection: &components.AssignedRoleCollection{Data: data},
+	}, nil
+}
+
+func (f *fakeSystemAccountService) PostSystemAccountsAccountIDAssignedRoles(_ context.Context,
+	_ string,
+	assignRole *components.AssignRole,
+	_ ...operations.Option,
+) (*operations.PostSystemAccountsAccountIDAssignedRolesResponse, error) {
+	f.assigned = append(f.assigned, components.AssignedRole{
+		RoleName: kk.String(string(*assignRole.RoleName)),
+		EntityID: assignRole.EntityID,
+	})
+	return &operations.PostSystemAccountsAccountIDAssignedRolesResponse{}, nil
+}
+
+func TestApplySystemAccountRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		envType EnvironmentType
+		want    []string
+	}{
+		{name: "admin of the DEV control plane", envType: DEV, want: []string{"Admin"}},
+		{name: "viewer of the PROD control plane", envType: PROD, want: []string{"Viewer"}},
+		{name: "no role in other environments", envType: "TEST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeSystemAccountService{}
+			envConfig := manifest.Environment{Type: string(tt.envType), Region: "us"}
+			// applying twice assigns the role once
+			for i := 0; i < 2; i++ {
+				assert.NoError(t, ApplySystemAccountRoles(context.Background(), svc, "account-123", "cp-123", envConfig))
+			}
+
+			var got []string
+			for _, r := range svc.assigned {
+				assert.Equal(t, "cp-123", *r.EntityID)
+				got = append(got, *r.RoleName)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
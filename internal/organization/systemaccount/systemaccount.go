@@ -0,0 +1,432 @@
+package systemaccount
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	kk "github.com/Kong/sdk-konnect-go"
+	"github.com/Kong/sdk-konnect-go/models/components"
+	"github.com/Kong/sdk-konnect-go/models/operations"
+)
+
+const (
+	// DefaultName is the name of the system account of the orchestrator
+	DefaultName = "konnect-orchestrator"
+	// DefaultTeam is the predefined team granting the account the permissions the orchestrator needs
+	DefaultTeam = "Organization Admin"
+	// DefaultTokenTTL is the lifetime of the access tokens
+	DefaultTokenTTL = 30 * 24 * time.Hour
+
+	pageSize = 100
+)
+
+// https://docs.konghq.com/konnect/api/identity-management/latest/#/System%20Accounts
+type Service interface {
+	GetSystemAccounts(ctx context.Context,
+		request operations.GetSystemAccountsRequest,
+		opts ...operations.Option) (*operations.GetSystemAccountsResponse, error)
+	PostSystemAccounts(ctx context.Context,
+		request *components.CreateSystemAccount,
+		opts ...operations.Option) (*operations.PostSystemAccountsResponse, error)
+}
+
+// https://docs.konghq.com/konnect/api/identity-management/latest/#/System%20Accounts%20-%20Access%20Tokens
+type AccessTokenService interface {
+	GetSystemAccountIDAccessTokens(ctx context.Context,
+		request operations.GetSystemAccountIDAccessTokensRequest,
+		opts ...operations.Option) (*operations.GetSystemAccountIDAccessTokensResponse, error)
+	PostSystemAccountsIDAccessTokens(ctx context.Context,
+		accountID string,
+		createSystemAccountAccessToken *components.CreateSystemAccountAccessToken,
+		opts ...operations.Option) (*operations.PostSystemAccountsIDAccessTokensResponse, error)
+	DeleteSystemAccountsIDAccessTokensID(ctx context.Context,
+		accountID string,
+		tokenID string,
+		opts ...operations.Option) (*operations.DeleteSystemAccountsIDAccessTokensIDResponse, error)
+}
+
+// https://docs.konghq.com/konnect/api/identity-management/latest/#/System%20Accounts%20-%20Roles
+type RoleService interface {
+	GetSystemAccountsAccountIDAssignedRoles(ctx context.Context,
+		accountID string,
+		filter *operations.GetSystemAccountsAccountIDAssignedRolesQueryParamFilter,
+		opts ...operations.Option) (*operations.GetSystemAccountsAccountIDAssignedRolesResponse, error)
+	PostSystemAccountsAccountIDAssignedRoles(ctx context.Context,
+		accountID string,
+		assignRole *components.AssignRole,
+		opts ...operations.Option) (*operations.PostSystemAccountsAccountIDAssignedRolesResponse, error)
+}
+
+// https://docs.konghq.com/konnect/api/identity-management/latest/#/System%20Accounts%20-%20Team%20Membership
+type TeamMembershipService interface {
+	GetTeamsTeamIDSystemAccounts(ctx context.Context,
+		request operations.GetTeamsTeamIDSystemAccountsRequest,
+		opts ...operations.Option) (*operations.GetTeamsTeamIDSystemAccountsResponse, error)
+	PostTeamsTeamIDSystemAccounts(ctx context.Context,
+		teamID string,
+		addSystemAccountToTeam *components.AddSystemAccountToTeam,
+		opts ...operations.Option) (*operations.PostTeamsTeamIDSystemAccountsResponse, error)
+}
+
+// TeamService lists the teams the account is added to
+type TeamService interface {
+	ListTeams(ctx context.Context,
+		request operations.ListTeamsRequest,
+		opts ...operations.Option) (*operations.ListTeamsResponse, error)
+}
+
+// Services are the Konnect APIs managing a system account
+type Services struct {
+	Accounts        Service
+	AccessTokens    AccessTokenService
+	Roles           RoleService
+	TeamMemberships TeamMembershipService
+	Teams           TeamService
+}
+
+// NewServices returns the services of an SDK
+func NewServices(sdk *kk.SDK) Services {
+	return Services{
+		Accounts:        sdk.SystemAccounts,
+		AccessTokens:    sdk.SystemAccountsAccessTokens,
+		Roles:           sdk.SystemAccountsRoles,
+		TeamMemberships: sdk.SystemAccountsTeamMembership,
+		Teams:           sdk.Teams,
+	}
+}
+
+// Token is an access token issued to a system account
+type Token struct {
+	AccountID string
+	ID        string
+	Value     string
+	ExpiresAt time.Time
+}
+
+// Policy is the lifetime and rotation window of the access tokens of a system account
+type Policy struct {
+	TTL          time.Duration
+	RotateBefore time.Duration
+}
+
+// ParsePolicy returns the token policy of a system account configuration
+func ParsePolicy(cfg manifest.SystemAccount) (Policy, error) {
+	return parsePolicy(cfg.TokenTTL, cfg.RotateBefore)
+}
+
+func parsePolicy(tokenTTL, rotateBefore *string) (Policy, error) {
+	p := Policy{TTL: DefaultTokenTTL}
+	if tokenTTL != nil && *tokenTTL != "" {
+		ttl, err := time.ParseDuration(*tokenTTL)
+		if err != nil || ttl <= 0 {
+			return Policy{}, fmt.Errorf("invalid token-ttl %s, expected a positive duration like 720h", *tokenTTL)
+		}
+		p.TTL = ttl
+	}
+	p.RotateBefore = p.TTL / 3
+	if rotateBefore != nil && *rotateBefore != "" {
+		before, err := time.ParseDuration(*rotateBefore)
+		if err != nil || before < 0 || before >= p.TTL {
+			return Policy{}, fmt.Errorf("invalid rotate-before %s, expected a duration shorter than the token TTL",
+				*rotateBefore)
+		}
+		p.RotateBefore = before
+	}
+	return p, nil
+}
+
+// Name returns the name of the system account of a configuration
+func Name(cfg manifest.SystemAccount) string {
+	if cfg.Name != nil && *cfg.Name != "" {
+		return *cfg.Name
+	}
+	return DefaultName
+}
+
+// Apply creates the system account of a configuration, adds it to its teams and assigns its roles. It issues
+// a new access token when the newest one expires within the rotation window, or when force is set, and
+// returns it, or nil when no token was issued. Expired tokens are deleted, the previous tokens stay valid
+// until they expire so that running jobs holding them aren't interrupted.
+func Apply(ctx context.Context,
+	svc Services,
+	cfg manifest.SystemAccount,
+	now time.Time,
+	force bool,
+) (*Token, error) {
+	policy, err := ParsePolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	name := Name(cfg)
+
+	accountID, err := applyAccount(ctx, svc.Accounts, name, "Applies the configuration of the Konnect Orchestrator")
+	if err != nil {
+		return nil, err
+	}
+
+	teams := cfg.Teams
+	if len(teams) == 0 {
+		teams = []string{DefaultTeam}
+	}
+	for _, teamName := range teams {
+		if err := applyTeamMembership(ctx, svc, accountID, teamName); err != nil {
+			return nil, err
+		}
+	}
+	for _, role := range cfg.Roles {
+		if err := applyRole(ctx, svc.Roles, accountID, role); err != nil {
+			return nil, err
+		}
+	}
+
+	return applyTokens(ctx, svc.AccessTokens, accountID, name, policy, now, force)
+}
+
+// Revoke deletes an access token, e.g. a token which couldn't be stored
+func Revoke(ctx context.Context, svc AccessTokenService, token Token) error {
+	if _, err := svc.DeleteSystemAccountsIDAccessTokensID(ctx, token.AccountID, token.ID); err != nil {
+		return fmt.Errorf("failed to delete system account access token: %w", err)
+	}
+	return nil
+}
+
+// TeamName returns the name of the system account of a team
+func TeamName(teamName string, cfg manifest.TeamSystemAccount) string {
+	if cfg.Name != nil && *cfg.Name != "" {
+		return *cfg.Name
+	}
+	return DefaultName + "-" + teamName
+}
+
+// ApplyTeamAccount creates the system account of a team and returns its ID. The roles of the account are
+// assigned per environment, on the control planes of the team.
+func ApplyTeamAccount(ctx context.Context,
+	svc Service,
+	teamName string,
+	cfg manifest.TeamSystemAccount,
+) (string, error) {
+	return applyAccount(ctx, svc, TeamName(teamName, cfg),
+		fmt.Sprintf("Runs the CI pipelines of the service repositories of team %s", teamName))
+}
+
+// ApplyTeamTokens creates the system account of a team and, like Apply, issues a new access token when the
+// newest one expires within the rotation window or when force is set
+func ApplyTeamTokens(ctx context.Context,
+	svc Services,
+	teamName string,
+	cfg manifest.TeamSystemAccount,
+	now time.Time,
+	force bool,
+) (*Token, error) {
+	policy, err := parsePolicy(cfg.TokenTTL, cfg.RotateBefore)
+	if err != nil {
+		return nil, err
+	}
+	accountID, err := ApplyTeamAccount(ctx, svc.Accounts, teamName, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return applyTokens(ctx, svc.AccessTokens, accountID, TeamName(teamName, cfg), policy, now, force)
+}
+
+func applyAccount(ctx context.Context, svc Service, name, description string) (string, error) {
+	for page := int64(1); ; page++ {
+		resp, err := svc.GetSystemAccounts(ctx, operations.GetSystemAccountsRequest{
+			PageSize:   kk.Int64(pageSize),
+			PageNumber: kk.Int64(page),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to list system accounts: %w", err)
+		}
+		var accounts []components.SystemAccount
+		if resp.SystemAccountCollection != nil {
+			accounts = resp.SystemAccountCollection.Data
+		}
+		for _, account := range accounts {
+			if account.Name != nil && *account.Name == name && account.ID != nil {
+				return *account.ID, nil
+			}
+		}
+		if len(accounts) < pageSize {
+			break
+		}
+	}
+
+	resp, err := svc.PostSystemAccounts(ctx, &components.CreateSystemAccount{
+		Name:        name,
+		Description: description,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create system account %s: %w", name, err)
+	}
+	if resp.SystemAccount == nil || resp.SystemAccount.ID == nil {
+		return "", fmt.Errorf("failed to create system account %s: no account returned", name)
+	}
+	return *resp.SystemAccount.ID, nil
+}
+
+func applyTeamMembership(ctx context.Context, svc Services, accountID, teamName string) error {
+	teamID, err := findTeam(ctx, svc.Teams, teamName)
+	if err != nil {
+		return err
+	}
+
+	for page := int64(1); ; page++ {
+		resp, err := svc.TeamMemberships.GetTeamsTeamIDSystemAccounts(ctx, operations.GetTeamsTeamIDSystemAccountsRequest{
+			TeamID:     teamID,
+			PageSize:   kk.Int64(pageSize),
+			PageNumber: kk.Int64(page),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list the system accounts of team %s: %w", teamName, err)
+		}
+		var members []components.SystemAccount
+		if resp.SystemAccountCollection != nil {
+			members = resp.SystemAccountCollection.Data
+		}
+		for _, member := range members {
+			if member.ID != nil && *member.ID == accountID {
+				return nil
+			}
+		}
+		if len(members) < pageSize {
+			break
+		}
+	}
+	if _, err := svc.TeamMemberships.PostTeamsTeamIDSystemAccounts(ctx, teamID,
+		&components.AddSystemAccountToTeam{AccountID: kk.String(accountID)}); err != nil {
+		return fmt.Errorf("failed to add the system account to team %s: %w", teamName, err)
+	}
+	return nil
+}
+
+// findTeam returns the ID of the team with the name
+func findTeam(ctx context.Context, svc TeamService, teamName string) (string, error) {
+	for page := int64(1); ; page++ {
+		resp, err := svc.ListTeams(ctx, operations.ListTeamsRequest{
+			PageSize:   kk.Int64(pageSize),
+			PageNumber: kk.Int64(page),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to list teams: %w", err)
+		}
+		var teams []components.Team
+		if resp.TeamCollection != nil {
+			teams = resp.TeamCollection.Data
+		}
+		for _, team := range teams {
+			if team.Name != nil && *team.Name == teamName && team.ID != nil {
+				return *team.ID, nil
+			}
+		}
+		if len(teams) < pageSize {
+			return "", fmt.Errorf("team %s of the system account not found", teamName)
+		}
+	}
+}
+
+func applyRole(ctx context.Context,
+	svc RoleService,
+	accountID string,
+	role manifest.RoleAssignment,
+) error {
+	entityID, region := role.EntityID, role.Region
+	if entityID == "" {
+		entityID = "*"
+	}
+	if region == "" {
+		region = "*"
+	}
+
+	// the assigned roles aren't paged, filtering them leaves the assignments of the role to the entity type
+	resp, err := svc.GetSystemAccountsAccountIDAssignedRoles(ctx, accountID,
+		&operations.GetSystemAccountsAccountIDAssignedRolesQueryParamFilter{
+			RoleName:       kk.Pointer(components.CreateStringFieldEqualsFilterStr(role.Role)),
+			EntityTypeName: kk.Pointer(components.CreateStringFieldEqualsFilterStr(role.EntityType)),
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list the roles of the system account: %w", err)
+	}
+	if resp.AssignedRoleCollection != nil {
+		for _, assigned := range resp.AssignedRoleCollection.Data {
+			if assigned.RoleName != nil && *assigned.RoleName == role.Role &&
+				assigned.EntityTypeName != nil && *assigned.EntityTypeName == role.EntityType &&
+				assigned.EntityID != nil && *assigned.EntityID == entityID {
+				return nil
+			}
+		}
+	}
+	if _, err := svc.PostSystemAccountsAccountIDAssignedRoles(ctx, accountID, &components.AssignRole{
+		RoleName:       kk.Pointer(components.RoleName(role.Role)),
+		EntityID:       kk.String(entityID),
+		EntityTypeName: kk.Pointer(components.EntityTypeName(role.EntityType)),
+		EntityRegion:   kk.Pointer(components.EntityRegion(region)),
+	}); err != nil {
+		return fmt.Errorf("failed to assign role %s of %s to the system account: %w", role.Role, role.EntityType, err)
+	}
+	return nil
+}
+
+func applyTokens(ctx context.Context,
+	svc AccessTokenService,
+	accountID, name string,
+	policy Policy,
+	now time.Time,
+	force bool,
+) (*Token, error) {
+	var tokens []components.SystemAccountAccessToken
+	for page := int64(1); ; page++ {
+		resp, err := svc.GetSystemAccountIDAccessTokens(ctx, operations.GetSystemAccountIDAccessTokensRequest{
+			AccountID:  accountID,
+			PageSize:   kk.Int64(pageSize),
+			PageNumber: kk.Int64(page),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the access tokens of system account %s: %w", name, err)
+		}
+		var data []components.SystemAccountAccessToken
+		if resp.SystemAccountAccessTokenCollection != nil {
+			data = resp.SystemAccountAccessTokenCollection.Data
+		}
+		tokens = append(tokens, data...)
+		if len(data) < pageSize {
+			break
+		}
+	}
+
+	// the expired tokens are deleted once listed so the deletions don't shift the pages
+	var newest time.Time
+	for _, token := range tokens {
+		if token.ExpiresAt == nil || token.ID == nil {
+			continue
+		}
+		if token.ExpiresAt.Before(now) {
+			if _, err := svc.DeleteSystemAccountsIDAccessTokensID(ctx, accountID, *token.ID); err != nil {
+				return nil, fmt.Errorf("failed to delete expired access token of system account %s: %w", name, err)
+			}
+			continue
+		}
+		if token.ExpiresAt.After(newest) {
+			newest = *token.ExpiresAt
+		}
+	}
+	if !force && newest.Sub(now) > policy.RotateBefore {
+		return nil, nil
+	}
+
+	expiresAt := now.Add(policy.TTL).UTC()
+	created, err := svc.PostSystemAccountsIDAccessTokens(ctx, accountID, &components.CreateSystemAccountAccessToken{
+		Name:      kk.String(fmt.Sprintf("%s-%s", name, now.UTC().Format("20060102150405"))),
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create an access token of system account %s: %w", name, err)
+	}
+	token := created.SystemAccountAccessTokenCreated
+	if token == nil || token.ID == nil || token.Token == nil {
+		return nil, fmt.Errorf("failed to create an access token of system account %s: no token returned", name)
+	}
+	return &Token{AccountID: accountID, ID: *token.ID, Value: *token.Token, ExpiresAt: expiresAt}, nil
+}
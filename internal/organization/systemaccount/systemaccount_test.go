@@ -0,0 +1,196 @@
+package systemaccount
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/fakekonnect"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	kk "github.com/Kong/sdk-konnect-go"
+	"github.com/Kong/sdk-konnect-go/models/components"
+	"github.com/Kong/sdk-konnect-go/models/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     manifest.SystemAccount
+		want    Policy
+		wantErr string
+	}{
+		{name: "defaults", want: Policy{TTL: DefaultTokenTTL, RotateBefore: DefaultTokenTTL / 3}},
+		{
+			name: "ttl",
+			cfg:  manifest.SystemAccount{TokenTTL: kk.String("168h"), RotateBefore: kk.String("48h")},
+			want: Policy{TTL: 168 * time.Hour, RotateBefore: 48 * time.Hour},
+		},
+		{name: "invalid ttl", cfg: manifest.SystemAccount{TokenTTL: kk.String("30d")}, wantErr: "invalid token-ttl 30d"},
+		{
+			name:    "window longer than ttl",
+			cfg:     manifest.SystemAccount{TokenTTL: kk.String("24h"), RotateBefore: kk.String("48h")},
+			wantErr: "invalid rotate-before 48h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy(tt.cfg)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type redirect struct {
+	target *url.URL
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host, req.Host = r.target.Scheme, r.target.Host, r.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newSDK returns an SDK calling a fake Konnect API
+func newSDK(t *testing.T) *kk.SDK {
+	server := httptest.NewServer(fakekonnect.New())
+	t.Cleanup(server.Close)
+	// the SDK sends the identity operations to the global API, whatever the server URL
+	target, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return kk.New(
+		kk.WithSecurity(components.Security{PersonalAccessToken: kk.String("kpat")}),
+		kk.WithClient(&http.Client{Transport: redirect{target: target}}),
+	)
+}
+
+func TestApply(t *testing.T) {
+	sdk := newSDK(t)
+	svc := NewServices(sdk)
+	ctx := context.Background()
+
+	_, err := sdk.Teams.CreateTeam(ctx, &components.CreateTeam{Name: DefaultTeam})
+	require.NoError(t, err)
+	cfg := manifest.SystemAccount{
+		TokenTTL:     kk.String("72h"),
+		RotateBefore: kk.String("24h"),
+		Roles:        []manifest.RoleAssignment{{Role: "Admin", EntityType: "Control Planes"}},
+	}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	first, err := Apply(ctx, svc, cfg, now, false)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Contains(t, first.Value, "spat_")
+	assert.Equal(t, now.Add(72*time.Hour), first.ExpiresAt)
+
+	// the token is kept until it expires within the rotation window
+	token, err := Apply(ctx, svc, cfg, now.Add(47*time.Hour), false)
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	second, err := Apply(ctx, svc, cfg, now.Add(49*time.Hour), false)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.NotEqual(t, first.Value, second.Value)
+
+	forced, err := Apply(ctx, svc, cfg, now.Add(50*time.Hour), true)
+	require.NoError(t, err)
+	require.NotNil(t, forced)
+
+	// the account, its team membership and its role are created once, expired tokens are deleted
+	accounts, err := sdk.SystemAccounts.GetSystemAccounts(ctx, operations.GetSystemAccountsRequest{})
+	require.NoError(t, err)
+	require.Len(t, accounts.SystemAccountCollection.Data, 1)
+	assert.Equal(t, DefaultName, *accounts.SystemAccountCollection.Data[0].Name)
+	roles, err := sdk.SystemAccountsRoles.GetSystemAccountsAccountIDAssignedRoles(ctx, first.AccountID, nil)
+	require.NoError(t, err)
+	assert.Len(t, roles.AssignedRoleCollection.Data, 1)
+
+	_, err = Apply(ctx, svc, cfg, now.Add(73*time.Hour), false)
+	require.NoError(t, err)
+	tokens, err := sdk.SystemAccountsAccessTokens.GetSystemAccountIDAccessTokens(ctx,
+		operations.GetSystemAccountIDAccessTokensRequest{AccountID: first.AccountID})
+	require.NoError(t, err)
+	assert.Len(t, tokens.SystemAccountAccessTokenCollection.Data, 2)
+
+	require.NoError(t, Revoke(ctx, svc.AccessTokens, *forced))
+
+	_, err = Apply(ctx, svc, manifest.SystemAccount{Teams: []string{"missing"}}, now, false)
+	assert.ErrorContains(t, err, "team missing of the system account not found")
+}
+
+func TestApplyTeamTokens(t *testing.T) {
+	sdk := newSDK(t)
+	svc := NewServices(sdk)
+	ctx := context.Background()
+	cfg := manifest.TeamSystemAccount{TokenTTL: kk.String("72h")}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	accountID, err := ApplyTeamAccount(ctx, svc.Accounts, "payments", cfg)
+	require.NoError(t, err)
+
+	first, err := ApplyTeamTokens(ctx, svc, "payments", cfg, now, false)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, accountID, first.AccountID)
+
+	// the default rotation window is a third of the token TTL
+	token, err := ApplyTeamTokens(ctx, svc, "payments", cfg, now.Add(47*time.Hour), false)
+	require.NoError(t, err)
+	assert.Nil(t, token)
+	token, err = ApplyTeamTokens(ctx, svc, "payments", cfg, now.Add(49*time.Hour), false)
+	require.NoError(t, err)
+	assert.NotNil(t, token)
+
+	accounts, err := sdk.SystemAccounts.GetSystemAccounts(ctx, operations.GetSystemAccountsRequest{})
+	require.NoError(t, err)
+	require.Len(t, accounts.SystemAccountCollection.Data, 1)
+	assert.Equal(t, "konnect-orchestrator-payments", *accounts.SystemAccountCollection.Data[0].Name)
+
+	_, err = ApplyTeamTokens(ctx, svc, "payments", manifest.TeamSystemAccount{RotateBefore: kk.String("1000h")},
+		now, false)
+	assert.ErrorContains(t, err, "invalid rotate-before 1000h")
+}
+
+func TestApplyPagedTeams(t *testing.T) {
+	sdk := newSDK(t)
+	svc := NewServices(sdk)
+	ctx := context.Background()
+
+	// the team of the system account is on the second page of teams
+	for i := 0; i < pageSize; i++ {
+		_, err := sdk.Teams.CreateTeam(ctx, &components.CreateTeam{Name: fmt.Sprintf("team-%d", i)})
+		require.NoError(t, err)
+	}
+	_, err := sdk.Teams.CreateTeam(ctx, &components.CreateTeam{Name: DefaultTeam})
+	require.NoError(t, err)
+	cfg := manifest.SystemAccount{
+		TokenTTL: kk.String("72h"),
+		Roles: []manifest.RoleAssignment{
+			{Role: "Admin", EntityType: "Control Planes"},
+			{Role: "Viewer", EntityType: "Control Planes"},
+		},
+	}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	token, err := Apply(ctx, svc, cfg, now, false)
+	require.NoError(t, err)
+	require.NotNil(t, token)
+	_, err = Apply(ctx, svc, cfg, now, true)
+	require.NoError(t, err)
+
+	roles, err := sdk.SystemAccountsRoles.GetSystemAccountsAccountIDAssignedRoles(ctx, token.AccountID, nil)
+	require.NoError(t, err)
+	assert.Len(t, roles.AssignedRoleCollection.Data, 2)
+}
@@ -0,0 +1,124 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/Kong/konnect-orchestrator/internal/git/gitea"
+)
+
+// LocalOrganization is the Gitea organization holding the repositories of the local demo
+const LocalOrganization = "kong-air"
+
+//go:embed local
+var localFiles embed.FS
+
+// localServices are the example service repositories of the local demo
+var localServices = []string{"flights", "routes"}
+
+// SeedLocal seeds a Gitea server with the example service repositories of the local demo and a platform
+// repository configured for them. webURL is the address of the server for koctl users, written to the
+// configuration of the platform repository. Existing repositories are left untouched, so seeding can be
+// repeated.
+func SeedLocal(ctx context.Context,
+	client *gitea.Client,
+	resourceFiles embed.FS,
+	webURL, username string,
+	statusCh chan<- string,
+) error {
+	exists, err := client.OrganizationExists(ctx, LocalOrganization)
+	if err != nil {
+		return fmt.Errorf("failed to get organization %s: %w", LocalOrganization, err)
+	}
+	if !exists {
+		if err := client.CreateOrganization(ctx, LocalOrganization); err != nil {
+			return fmt.Errorf("failed to create organization %s: %w", LocalOrganization, err)
+		}
+		statusCh <- fmt.Sprintf("✔ Created organization %s\n", LocalOrganization)
+	}
+
+	for _, service := range localServices {
+		spec, err := localFiles.ReadFile(path.Join("local", "services", service, "openapi.yaml"))
+		if err != nil {
+			return err
+		}
+		if err := seedRepository(ctx, client, service, map[string][]byte{"openapi.yaml": spec}, statusCh); err != nil {
+			return err
+		}
+	}
+
+	files, err := localPlatformFiles(resourceFiles, map[string]string{
+		"WebURL":       strings.TrimRight(webURL, "/"),
+		"Organization": LocalOrganization,
+		"Username":     username,
+	})
+	if err != nil {
+		return err
+	}
+	return seedRepository(ctx, client, "platform", files, statusCh)
+}
+
+// seedRepository creates a repository of the local organization holding files, unless it exists
+func seedRepository(ctx context.Context, client *gitea.Client, repo string, files map[string][]byte, statusCh chan<- string) error {
+	_, err := client.GetRepository(ctx, LocalOrganization, repo)
+	if err == nil {
+		statusCh <- fmt.Sprintf("✔ Repository %s/%s already exists\n", LocalOrganization, repo)
+		return nil
+	}
+	if !errors.Is(err, gitea.ErrNotFound) {
+		return fmt.Errorf("failed to get repository %s/%s: %w", LocalOrganization, repo, err)
+	}
+
+	if err := gitea.CreateRepository(ctx, client, LocalOrganization, repo, "main"); err != nil {
+		return err
+	}
+	if err := client.CommitFiles(ctx, LocalOrganization, repo, "main", "Seed the local demo", files); err != nil {
+		return fmt.Errorf("failed to seed repository %s/%s: %w", LocalOrganization, repo, err)
+	}
+	statusCh <- fmt.Sprintf("✔ Seeded repository %s/%s\n", LocalOrganization, repo)
+	return nil
+}
+
+// localPlatformFiles returns the files of the platform repository of the local demo: the default platform
+// files with the GitHub Actions workflows, which Gitea Actions run, and the configuration of the demo
+func localPlatformFiles(resourceFiles embed.FS, data map[string]string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := fs.WalkDir(resourceFiles, "resources/platform", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := strings.TrimPrefix(p, "resources/platform/")
+		if name == gitLabCIFile {
+			return nil
+		}
+		content, err := resourceFiles.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[name] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default platform files: %w", err)
+	}
+
+	tmpl, err := template.ParseFS(localFiles, "local/konnect/*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse local demo configuration: %w", err)
+	}
+	for _, t := range tmpl.Templates() {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", t.Name(), err)
+		}
+		files["konnect/"+t.Name()] = buf.Bytes()
+	}
+	return files, nil
+}
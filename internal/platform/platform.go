@@ -6,17 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/Kong/konnect-orchestrator/internal/git"
 	"github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/git/provider"
 	"github.com/Kong/konnect-orchestrator/internal/manifest"
 	"github.com/Kong/konnect-orchestrator/internal/util"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	giturl "github.com/kubescape/go-git-url"
 	"gopkg.in/yaml.v3"
 )
 
+// gitLabCIFile is the GitLab CI/CD pipeline of the platform repository
+const gitLabCIFile = ".gitlab-ci.yml"
+
 func Init(platformGitCfg manifest.GitConfig, resourceFiles embed.FS, statusCh chan<- string, createNewRepo bool) error {
 	// TODO: Initialize the platform repository with the following steps:
 	// Pre-requisites:
@@ -27,15 +32,16 @@ func Init(platformGitCfg manifest.GitConfig, resourceFiles embed.FS, statusCh ch
 	//			GitHub token
 
 	// 1. Clone the repository locally
-	gitURL, err := giturl.NewGitURL(*platformGitCfg.Remote)
+	hosting, err := provider.New(platformGitCfg)
 	if err != nil {
-		return fmt.Errorf("failed to parse Git URL: %w", err)
+		return fmt.Errorf("failed to configure the git provider of the platform repository: %w", err)
 	}
+	repoName := hosting.Remote().Name()
 
 	platformRepoDir, err := git.Clone(platformGitCfg)
 	if errors.Is(err, transport.ErrRepositoryNotFound) {
 		if createNewRepo {
-			if err = github.CreateRepo(context.Background(), gitURL.GetOwnerName(), gitURL.GetRepoName(), *platformGitCfg.GitHub); err != nil {
+			if err = hosting.CreateRepository(context.Background()); err != nil {
 				return fmt.Errorf("failed to create platform repository: %w", err)
 			}
 			platformRepoDir, err = git.Clone(platformGitCfg)
@@ -47,7 +53,7 @@ func Init(platformGitCfg manifest.GitConfig, resourceFiles embed.FS, statusCh ch
 	if err != nil {
 		return fmt.Errorf("failed to clone platform repository: %w", err)
 	}
-	statusCh <- fmt.Sprintf("✔ Cloned %s repository locally\n", gitURL.GetRepoName())
+	statusCh <- fmt.Sprintf("✔ Cloned %s repository locally\n", repoName)
 
 	branchName := "konnect-orchestrator-init"
 	err = git.CheckoutBranch(platformRepoDir, branchName, platformGitCfg)
@@ -75,7 +81,21 @@ func Init(platformGitCfg manifest.GitConfig, resourceFiles embed.FS, statusCh ch
 	if err != nil {
 		return fmt.Errorf("failed to copy default konnect/ files: %w", err)
 	}
-	statusCh <- "✔ Added GitHub Action files to .github/workflows directory\n"
+	// Only the CI pipelines of the git provider hosting the repository are kept
+	if hosting.Name() == git.ProviderGitLab {
+		if err := os.RemoveAll(filepath.Join(platformRepoDir, ".github", "workflows")); err != nil {
+			return fmt.Errorf("failed to remove GitHub Actions workflows: %w", err)
+		}
+		statusCh <- fmt.Sprintf("✔ Added GitLab CI/CD pipeline to %s\n", gitLabCIFile)
+	} else {
+		if err := os.Remove(filepath.Join(platformRepoDir, gitLabCIFile)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", gitLabCIFile, err)
+		}
+		if err := setWorkflowBranches(platformRepoDir, platformGitCfg.BaseBranchName()); err != nil {
+			return err
+		}
+		statusCh <- "✔ Added GitHub Action files to .github/workflows directory\n"
+	}
 	statusCh <- "✔ Added default files to konnect directory\n"
 
 	err = git.Add(platformRepoDir, ".")
@@ -99,43 +119,39 @@ func Init(platformGitCfg manifest.GitConfig, resourceFiles embed.FS, statusCh ch
 		if err != nil {
 			return fmt.Errorf("failed to push changes: %w", err)
 		}
-		statusCh <- fmt.Sprintf("✔ Pushed changes to the %s repository %s branch\n", gitURL.GetRepoName(), branchName)
+		statusCh <- fmt.Sprintf("✔ Pushed changes to the %s repository %s branch\n", repoName, branchName)
 
-		pr, err := github.CreateOrUpdatePullRequest(
+		pr, err := hosting.CreateOrUpdatePullRequest(
 			context.Background(),
-			gitURL.GetOwnerName(),
-			gitURL.GetRepoName(),
 			branchName,
+			platformGitCfg.BaseBranchName(),
 			"[Konnect Orchestrator] - Init Platform",
 			`The Konnect Orchestrator 'init' function was executed and filed this PR to initialize the Platform repository, 
-			including GitHub Actions and default configuration files.
+			including the CI pipelines and default configuration files.
 			
 			Review and merge this PR to complete the Platform repository initialization (no actions will be performed except for the creation of the repository and github actions)`,
-			*platformGitCfg.GitHub,
 			nil,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to create or update pull request: %w", err)
 		}
-		prURL = *pr.HTMLURL
+		prURL = pr.URL
 	}
 	if prURL == "" {
 		prURL = *platformGitCfg.Remote + "/pulls"
 	}
 
-	// 9. Write the provided GitHub auth token to the repository secrets API
-	secretName := "KONNECT_ORCHESTRATOR_GITHUB_TOKEN" //nolint:gosec
-	err = github.CreateRepoActionSecret(
-		context.Background(),
-		platformGitCfg.GitHub,
-		gitURL.GetOwnerName(),
-		gitURL.GetRepoName(),
-		secretName,
-		platformGitCfg.GitHub.Token)
+	// 9. Write the provided auth token, or the GitHub App, to the repository secrets, or the CI/CD variables on GitLab
+	secrets, err := providerSecrets(hosting.Name(), platformGitCfg)
 	if err != nil {
-		return fmt.Errorf("failed to create GitHub secret: %w", err)
+		return err
+	}
+	for _, secret := range secrets {
+		if err = hosting.SetSecret(context.Background(), secret[0], secret[1]); err != nil {
+			return fmt.Errorf("failed to create repository secret: %w", err)
+		}
+		statusCh <- fmt.Sprintf("✔ Added %s to %s repository secrets\n", secret[0], repoName)
 	}
-	statusCh <- fmt.Sprintf("✔ Added %s to %s repository secrets\n", secretName, gitURL.GetRepoName())
 
 	statusCh <- fmt.Sprintf("✔ PR Filed: %s\n", prURL)
 	statusCh <- "\tReview and Merge to complete platform repository initialization.\n\n"
@@ -147,6 +163,12 @@ func Init(platformGitCfg manifest.GitConfig, resourceFiles embed.FS, statusCh ch
 	return nil
 }
 
+// KonnectTokenSecretName is the CI secret of the platform repository holding the Konnect token of an
+// organization
+func KonnectTokenSecretName(orgName string) string {
+	return strings.ToUpper(orgName) + "_KONNECT_TOKEN"
+}
+
 func AddOrganization(
 	platformGitCfg *manifest.GitConfig,
 	orgName,
@@ -167,16 +189,17 @@ func AddOrganization(
 	defer close(statusCh)
 
 	// 1. Clone the repository locally
-	gitURL, err := giturl.NewGitURL(*platformGitCfg.Remote)
+	hosting, err := provider.New(*platformGitCfg)
 	if err != nil {
-		return fmt.Errorf("failed to parse Git URL: %w", err)
+		return fmt.Errorf("failed to configure the git provider of the platform repository: %w", err)
 	}
+	repoName := hosting.Remote().Name()
 
 	platformRepoDir, err := git.Clone(*platformGitCfg)
 	if err != nil {
 		return fmt.Errorf("failed to clone platform repository: %w", err)
 	}
-	statusCh <- fmt.Sprintf("✔ Cloned %s repository locally\n", gitURL.GetRepoName())
+	statusCh <- fmt.Sprintf("✔ Cloned %s repository locally\n", repoName)
 
 	branchName := fmt.Sprintf("konnect-orchestrator-add-org-%s", orgName)
 	err = git.CheckoutBranch(platformRepoDir, branchName, *platformGitCfg)
@@ -186,7 +209,7 @@ func AddOrganization(
 
 	konnectPath := platformRepoDir + "/konnect"
 	organizationsFilePath := konnectPath + "/organizations.yaml"
-	konnectTokenEnvVarName := strings.ToUpper(orgName) + "_KONNECT_TOKEN"
+	konnectTokenEnvVarName := KonnectTokenSecretName(orgName)
 
 	// 2. Load the organizations file into a manifest struct
 	var man manifest.Orchestrator
@@ -227,12 +250,111 @@ func AddOrganization(
 	if err != nil {
 		return fmt.Errorf("error writing to file: %w", err)
 	}
-	statusCh <- fmt.Sprintf("✔ Added %s to organizations.yaml\n", gitURL.GetRepoName())
+	statusCh <- fmt.Sprintf("✔ Added %s to organizations.yaml\n", repoName)
 
 	// 3. Modify the workflow file to include
 	//	    env:
 	//			<ORGNAME>_KONNECT_TOKEN: {{ .secrets.<ORGNAME>_KONNECT_TOKEN }}
-	// in the koctl apply step
+	// in the koctl apply step. GitLab CI/CD variables are available to every job.
+	if hosting.Name() != git.ProviderGitLab {
+		if err := addWorkflowSecret(platformRepoDir, konnectTokenEnvVarName); err != nil {
+			return err
+		}
+		statusCh <- fmt.Sprintf("✔ Added %s to koctl apply workflow\n", konnectTokenEnvVarName)
+	}
+
+	// Detect changes to the repository
+	isClean, err := git.IsClean(platformRepoDir)
+	if err != nil {
+		return fmt.Errorf("failed to check if platform repository is clean: %w", err)
+	}
+	var prURL string
+	if !isClean {
+		if err = git.Add(platformRepoDir, "."); err != nil {
+			return fmt.Errorf("failed to add files to git: %w", err)
+		}
+		err = git.Commit(platformRepoDir, "Konnect Orchestrator initializing the platform repository", *platformGitCfg.Author)
+		if err != nil {
+			return fmt.Errorf("failed to commit changes: %w", err)
+		}
+		err = git.Push(platformRepoDir, *platformGitCfg)
+		if err != nil {
+			return fmt.Errorf("failed to push changes: %w", err)
+		}
+
+		pr, err := hosting.CreateOrUpdatePullRequest(
+			context.Background(),
+			branchName,
+			platformGitCfg.BaseBranchName(),
+			fmt.Sprintf("[Konnect Orchestrator] - Add %s Organization", orgName),
+			`The Konnect Orchestrator Add Organization function was executed and 
+			 filed this PR to add a new organization to the Platform repository`,
+			nil,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create or update pull request: %w", err)
+		}
+		prURL = pr.URL
+	}
+	if prURL == "" {
+		prURL = *platformGitCfg.Remote + "/pulls"
+	}
+
+	// 9. Write the provided Konnect token to the repository secrets, or the CI/CD variables on GitLab
+	if err = hosting.SetSecret(context.Background(), konnectTokenEnvVarName, konnectToken); err != nil {
+		return fmt.Errorf("failed to create repository secret: %w", err)
+	}
+	statusCh <- fmt.Sprintf("✔ Added %s to %s repository secrets\n", konnectTokenEnvVarName, repoName)
+
+	statusCh <- fmt.Sprintf("✔ PR Filed: %s\n", prURL)
+	statusCh <- "\tReview and Merge to complete adding the organization to the platform repository.\n\n"
+	statusCh <- "Next run the Reference Platform self service UI with:\n"
+	statusCh <- "\tkoctl run\n\n"
+	statusCh <- "See the FAQ page for further questions on the Konnect Reference Platform:\n"
+	statusCh <- "\thttps://deploy-preview-783--kongdeveloper.netlify.app/konnect-reference-platform/faq\n"
+
+	return nil
+}
+
+// setWorkflowBranches has the GitHub Actions workflows triggered by pushes run on pushes to the base branch
+// instead of main. The GitLab pipeline uses the default branch of the repository.
+func setWorkflowBranches(platformRepoDir, branch string) error {
+	workflowsDir := filepath.Join(platformRepoDir, ".github", "workflows")
+	entries, err := os.ReadDir(workflowsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read workflows directory: %w", err)
+	}
+	for _, e := range entries {
+		workflowFilePath := filepath.Join(workflowsDir, e.Name())
+		fd, err := os.ReadFile(workflowFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read workflow file: %w", err)
+		}
+		var root yaml.Node
+		if err := yaml.Unmarshal(fd, &root); err != nil {
+			return fmt.Errorf("failed to unmarshal workflow file %s: %w", e.Name(), err)
+		}
+
+		branches := findMapValuePath(root.Content[0], "on", "push", "branches")
+		if branches == nil || branches.Kind != yaml.SequenceNode ||
+			(len(branches.Content) == 1 && branches.Content[0].Value == branch) {
+			continue
+		}
+		branches.Content = []*yaml.Node{{Kind: yaml.ScalarNode, Value: branch}}
+
+		out, err := yaml.Marshal(&root)
+		if err != nil {
+			return fmt.Errorf("failed to marshal workflow file %s: %w", e.Name(), err)
+		}
+		if err := os.WriteFile(workflowFilePath, out, 0o600); err != nil {
+			return fmt.Errorf("failed to write workflow file %s: %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+// addWorkflowSecret passes a repository secret to the koctl apply step of the GitHub Actions workflow
+func addWorkflowSecret(platformRepoDir, secretName string) error {
 	workflowFilePath := platformRepoDir + "/.github/workflows/konnect-koctl-apply.yaml"
 
 	fd, err := os.ReadFile(workflowFilePath)
@@ -265,7 +387,7 @@ func AddOrganization(
 		applyStep.Content = append(applyStep.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "env"}, koctlApplyStepEnv)
 	}
 
-	setEnvVariable(koctlApplyStepEnv, konnectTokenEnvVarName, fmt.Sprintf("${{secrets.%s}}", konnectTokenEnvVarName))
+	setEnvVariable(koctlApplyStepEnv, secretName, fmt.Sprintf("${{secrets.%s}}", secretName))
 
 	out, err := yaml.Marshal(&root)
 	if err != nil {
@@ -275,68 +397,41 @@ func AddOrganization(
 	if err != nil {
 		return fmt.Errorf("failed to write workflow file: %w", err)
 	}
-	statusCh <- fmt.Sprintf("✔ Added %s to koctl apply workflow\n", konnectTokenEnvVarName)
+	return nil
+}
 
-	// Detect changes to the repository
-	isClean, err := git.IsClean(platformRepoDir)
-	if err != nil {
-		return fmt.Errorf("failed to check if platform repository is clean: %w", err)
-	}
-	var prURL string
-	if !isClean {
-		if err = git.Add(platformRepoDir, "."); err != nil {
-			return fmt.Errorf("failed to add files to git: %w", err)
+// providerSecrets returns the names and values of the secrets holding the GitHub App of the orchestrator,
+// or else the secret holding the token of the git provider, used by
+// the CI pipelines of the platform repository
+func providerSecrets(providerName string, platformGitCfg manifest.GitConfig) ([][2]string, error) {
+	if providerName == git.ProviderGitHub && platformGitCfg.GitHub != nil && platformGitCfg.GitHub.App != nil {
+		app := platformGitCfg.GitHub.App
+		if app.PrivateKey == nil {
+			return nil, fmt.Errorf("private key of GitHub App %d is required for %s", app.ID, github.AppPrivateKeyEnvVar)
 		}
-		err = git.Commit(platformRepoDir, "Konnect Orchestrator initializing the platform repository", *platformGitCfg.Author)
+		key, err := util.ResolveSecretValue(*app.PrivateKey)
 		if err != nil {
-			return fmt.Errorf("failed to commit changes: %w", err)
+			return nil, fmt.Errorf("failed to resolve the private key for %s: %w", github.AppPrivateKeyEnvVar, err)
 		}
-		err = git.Push(platformRepoDir, *platformGitCfg)
-		if err != nil {
-			return fmt.Errorf("failed to push changes: %w", err)
+		secrets := [][2]string{
+			{github.AppIDEnvVar, strconv.FormatInt(app.ID, 10)},
+			{github.AppPrivateKeyEnvVar, key},
 		}
-
-		pr, err := github.CreateOrUpdatePullRequest(
-			context.Background(),
-			gitURL.GetOwnerName(),
-			gitURL.GetRepoName(),
-			branchName,
-			fmt.Sprintf("[Konnect Orchestrator] - Add %s Organization", orgName),
-			`The Konnect Orchestrator Add Organization function was executed and 
-			 filed this PR to add a new organization to the Platform repository`,
-			*platformGitCfg.GitHub,
-			nil,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create or update pull request: %w", err)
+		if app.InstallationID != 0 {
+			secrets = append(secrets, [2]string{github.AppInstallationIDEnvVar, strconv.FormatInt(app.InstallationID, 10)})
 		}
-		prURL = *pr.HTMLURL
-	}
-	if prURL == "" {
-		prURL = *platformGitCfg.Remote + "/pulls"
+		return secrets, nil
 	}
 
-	// 9. Write the provided GitHub auth token to the repository secrets API
-	err = github.CreateRepoActionSecretFromString(
-		context.Background(),
-		platformGitCfg.GitHub,
-		gitURL.GetOwnerName(),
-		gitURL.GetRepoName(),
-		konnectTokenEnvVarName,
-		konnectToken)
+	name := "KONNECT_ORCHESTRATOR_" + strings.ToUpper(strings.ReplaceAll(providerName, "-", "_")) + "_TOKEN"
+	token, err := git.ProviderCredentials(platformGitCfg).Resolve()
 	if err != nil {
-		return fmt.Errorf("failed to create GitHub secret: %w", err)
+		return nil, fmt.Errorf("failed to resolve the token for %s: %w", name, err)
 	}
-	statusCh <- fmt.Sprintf("✔ Added %s to %s repository secrets\n", konnectTokenEnvVarName, gitURL.GetRepoName())
-
-	statusCh <- fmt.Sprintf("✔ PR Filed: %s\n", prURL)
-	statusCh <- "\tReview and Merge to complete adding the organization to the platform repository.\n\n"
-	statusCh <- "Next run the Reference Platform self service UI with:\n"
-	statusCh <- "\tkoctl run\n\n"
-	statusCh <- "See the FAQ page for further questions on the Konnect Reference Platform:\n"
-	statusCh <- "\thttps://deploy-preview-783--kongdeveloper.netlify.app/konnect-reference-platform/faq\n"
-
-	return nil
+	if token == "" {
+		return nil, fmt.Errorf("%s token is required for %s", providerName, name)
+	}
+	return [][2]string{{name, token}}, nil
 }
 
 // Looks up nested keys like jobs > build > steps
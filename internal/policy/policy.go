@@ -0,0 +1,276 @@
+// Package policy renders the plugin policies of the manifest into the decK patches applied to the
+// gateway services generated for each service spec.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/deck/patch"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// Tag is added to the plugins created from policies
+const Tag = "ko-policy"
+
+const dateLayout = "2006-01-02"
+
+// Exempted is a policy which doesn't apply to a service
+type Exempted struct {
+	Plugin        string
+	Justification string
+}
+
+// Validate checks that every plugin policy is named and configured with complete secret references and
+// that every exemption identifies a team and carries a justification
+func Validate(p *manifest.Policies) error {
+	if p == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	for i, plugin := range p.Plugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("plugin policy %d has no name", i)
+		}
+		if seen[plugin.Name] {
+			return fmt.Errorf("plugin policy %s is defined more than once", plugin.Name)
+		}
+		seen[plugin.Name] = true
+
+		for field, ref := range plugin.Secrets {
+			if field == "" || ref.Vault == "" || ref.Key == "" {
+				return fmt.Errorf("plugin policy %s: secret %q needs a config field, vault and key", plugin.Name, field)
+			}
+		}
+		for j, e := range plugin.Exemptions {
+			if e.Team == "" {
+				return fmt.Errorf("plugin policy %s: exemption %d has no team", plugin.Name, j)
+			}
+			if strings.TrimSpace(e.Justification) == "" {
+				return fmt.Errorf("plugin policy %s: exemption of %s needs a justification", plugin.Name, exempted(e))
+			}
+			if e.Expires != nil {
+				if _, err := time.Parse(dateLayout, *e.Expires); err != nil {
+					return fmt.Errorf("plugin policy %s: exemption of %s has an invalid expiry date %q, expected YYYY-MM-DD",
+						plugin.Name, exempted(e), *e.Expires)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ForEnvironment returns the plugin policies applying to an environment: the organization policies
+// followed by the environment ones, which replace the organization policies for the same plugin
+func ForEnvironment(org, env *manifest.Policies, envType string) []manifest.PluginPolicy {
+	var out []manifest.PluginPolicy
+	index := map[string]int{}
+	for _, p := range []*manifest.Policies{org, env} {
+		if p == nil {
+			continue
+		}
+		for _, plugin := range p.Plugins {
+			if !appliesTo(plugin, envType) {
+				continue
+			}
+			if i, ok := index[plugin.Name]; ok {
+				out[i] = plugin
+				continue
+			}
+			index[plugin.Name] = len(out)
+			out = append(out, plugin)
+		}
+	}
+	return out
+}
+
+// Plugins renders the decK plugins enforced on a service of a team, along with the policies the
+// service is exempted from
+func Plugins(policies []manifest.PluginPolicy, teamName, serviceName string, now time.Time) ([]map[string]interface{}, []Exempted) {
+	var plugins []map[string]interface{}
+	var exemptions []Exempted
+	for _, p := range policies {
+		if e := exemption(p, teamName, serviceName, now); e != nil {
+			exemptions = append(exemptions, Exempted{Plugin: p.Name, Justification: e.Justification})
+			continue
+		}
+		plugins = append(plugins, render(p))
+	}
+	return plugins, exemptions
+}
+
+// Patches returns the decK patches adding the plugins to every service of a generated decK file. A plugin
+// the service already configures is replaced, so the platform configuration always wins. Routes can't
+// configure the plugins, see CheckRoutes.
+func Patches(plugins []map[string]interface{}) []patch.Patch {
+	if len(plugins) == 0 {
+		return nil
+	}
+	values := make([]interface{}, 0, len(plugins))
+	for _, p := range plugins {
+		values = append(values, p)
+	}
+	return []patch.Patch{
+		{
+			Selectors: []string{"$..services[*][?(!@.plugins)]"},
+			Values:    map[string]interface{}{"plugins": []interface{}{}},
+		},
+		{
+			Selectors: []string{"$..services[*].plugins"},
+			Values:    values,
+		},
+	}
+}
+
+// CheckRoutes returns an error when a route of a decK file configures a plugin a policy enforces on the
+// route's service. Kong runs the most specific plugin, the route plugin would override the policy.
+func CheckRoutes(doc map[string]interface{}) error {
+	services, _ := doc["services"].([]interface{})
+	topLevelRoutes, _ := doc["routes"].([]interface{})
+	for _, s := range services {
+		service, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		enforced := map[string]bool{}
+		for _, p := range list(service["plugins"]) {
+			if name := stringField(p, "name"); name != "" && enforcedBy(p) == name {
+				enforced[name] = true
+			}
+		}
+		if len(enforced) == 0 {
+			continue
+		}
+
+		serviceName := stringField(service, "name")
+		routes := append([]interface{}{}, list(service["routes"])...)
+		for _, r := range topLevelRoutes {
+			if stringField(field(r, "service"), "name") == serviceName {
+				routes = append(routes, r)
+			}
+		}
+		for _, r := range routes {
+			for _, p := range list(field(r, "plugins")) {
+				name := stringField(p, "name")
+				if enforced[name] && enforcedBy(p) != name {
+					return fmt.Errorf("route %s of service %s configures the plugin %s enforced by a policy, "+
+						"remove it from the route or exempt the service from the policy",
+						stringField(r, "name"), serviceName, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// enforcedBy returns the name of the policy a decK plugin was created from
+func enforcedBy(plugin interface{}) string {
+	for _, t := range list(field(plugin, "tags")) {
+		if tag, ok := t.(string); ok && strings.HasPrefix(tag, Tag+"=") {
+			return strings.TrimPrefix(tag, Tag+"=")
+		}
+	}
+	return ""
+}
+
+func field(v interface{}, key string) interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m[key]
+	}
+	return nil
+}
+
+func stringField(v interface{}, key string) string {
+	s, _ := field(v, key).(string)
+	return s
+}
+
+func list(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+func appliesTo(p manifest.PluginPolicy, envType string) bool {
+	if len(p.EnvironmentTypes) == 0 {
+		return true
+	}
+	for _, t := range p.EnvironmentTypes {
+		if strings.EqualFold(t, envType) {
+			return true
+		}
+	}
+	return false
+}
+
+func exemption(p manifest.PluginPolicy, teamName, serviceName string, now time.Time) *manifest.PolicyExemption {
+	for i, e := range p.Exemptions {
+		if e.Team != teamName || (e.Service != "" && e.Service != serviceName) {
+			continue
+		}
+		if e.Expires != nil {
+			expires, err := time.Parse(dateLayout, *e.Expires)
+			// the exemption is valid through the whole expiry day
+			if err != nil || !now.Before(expires.AddDate(0, 0, 1)) {
+				continue
+			}
+		}
+		return &p.Exemptions[i]
+	}
+	return nil
+}
+
+func render(p manifest.PluginPolicy) map[string]interface{} {
+	config := map[string]interface{}{}
+	for k, v := range p.Config {
+		config[k] = v
+	}
+	for field, ref := range p.Secrets {
+		setField(config, strings.Split(field, "."), reference(ref))
+	}
+
+	enabled := true
+	if p.Enabled != nil {
+		enabled = *p.Enabled
+	}
+	plugin := map[string]interface{}{
+		"name":    p.Name,
+		"enabled": enabled,
+		"tags":    []interface{}{Tag + "=" + p.Name},
+	}
+	if len(config) > 0 {
+		plugin["config"] = config
+	}
+	return plugin
+}
+
+// setField sets a nested config field, copying the intermediate objects so the manifest isn't modified
+func setField(config map[string]interface{}, path []string, value string) {
+	if len(path) == 1 {
+		config[path[0]] = value
+		return
+	}
+	child := map[string]interface{}{}
+	if existing, ok := config[path[0]].(map[string]interface{}); ok {
+		for k, v := range existing {
+			child[k] = v
+		}
+	}
+	setField(child, path[1:], value)
+	config[path[0]] = child
+}
+
+func reference(ref manifest.SecretReference) string {
+	r := "{vault://" + ref.Vault + "/" + ref.Key
+	if ref.Field != nil && *ref.Field != "" {
+		r += "/" + *ref.Field
+	}
+	return r + "}"
+}
+
+func exempted(e manifest.PolicyExemption) string {
+	if e.Service == "" {
+		return "team " + e.Team
+	}
+	return "service " + e.Team + "/" + e.Service
+}
@@ -0,0 +1,256 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Kong/konnect-orchestrator/internal/deck/patch"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies *manifest.Policies
+		wantErr  string
+	}{
+		{
+			name:     "nil policies",
+			policies: nil,
+		},
+		{
+			name: "valid policies",
+			policies: &manifest.Policies{Plugins: []manifest.PluginPolicy{{
+				Name:    "rate-limiting",
+				Secrets: map[string]manifest.SecretReference{"redis.password": {Vault: "env", Key: "PASSWORD"}},
+				Exemptions: []manifest.PolicyExemption{
+					{Team: "flight-data", Service: "flights", Justification: "internal only", Expires: stringPtr("2026-12-31")},
+				},
+			}}},
+		},
+		{
+			name:     "missing name",
+			policies: &manifest.Policies{Plugins: []manifest.PluginPolicy{{}}},
+			wantErr:  "plugin policy 0 has no name",
+		},
+		{
+			name: "duplicate plugin",
+			policies: &manifest.Policies{Plugins: []manifest.PluginPolicy{
+				{Name: "prometheus"}, {Name: "prometheus"},
+			}},
+			wantErr: "defined more than once",
+		},
+		{
+			name: "exemption without justification",
+			policies: &manifest.Policies{Plugins: []manifest.PluginPolicy{{
+				Name:       "prometheus",
+				Exemptions: []manifest.PolicyExemption{{Team: "flight-data", Service: "flights", Justification: " "}},
+			}}},
+			wantErr: "exemption of service flight-data/flights needs a justification",
+		},
+		{
+			name: "invalid expiry",
+			policies: &manifest.Policies{Plugins: []manifest.PluginPolicy{{
+				Name: "prometheus",
+				Exemptions: []manifest.PolicyExemption{
+					{Team: "flight-data", Justification: "legacy", Expires: stringPtr("next year")},
+				},
+			}}},
+			wantErr: "invalid expiry date",
+		},
+		{
+			name: "incomplete secret reference",
+			policies: &manifest.Policies{Plugins: []manifest.PluginPolicy{{
+				Name:    "openid-connect",
+				Secrets: map[string]manifest.SecretReference{"client_secret": {Key: "SECRET"}},
+			}}},
+			wantErr: "needs a config field, vault and key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.policies)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestForEnvironment(t *testing.T) {
+	org := &manifest.Policies{Plugins: []manifest.PluginPolicy{
+		{Name: "prometheus"},
+		{Name: "rate-limiting", EnvironmentTypes: []string{"PROD"}, Config: map[string]interface{}{"minute": 100}},
+		{Name: "cors", Config: map[string]interface{}{"origins": []interface{}{"*"}}},
+	}}
+	env := &manifest.Policies{Plugins: []manifest.PluginPolicy{
+		{Name: "cors", Config: map[string]interface{}{"origins": []interface{}{"https://kongair.com"}}},
+	}}
+
+	dev := ForEnvironment(org, env, "DEV")
+	require.Len(t, dev, 2)
+	assert.Equal(t, "prometheus", dev[0].Name)
+	assert.Equal(t, env.Plugins[0], dev[1])
+
+	prod := ForEnvironment(org, nil, "PROD")
+	require.Len(t, prod, 3)
+	assert.Equal(t, "rate-limiting", prod[1].Name)
+}
+
+func TestPlugins(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	policies := []manifest.PluginPolicy{
+		{
+			Name:   "rate-limiting",
+			Config: map[string]interface{}{"minute": 100, "redis": map[string]interface{}{"host": "redis"}},
+			Secrets: map[string]manifest.SecretReference{
+				"redis.password": {Vault: "env", Key: "REDIS_PASSWORD"},
+			},
+			Exemptions: []manifest.PolicyExemption{
+				{Team: "flight-data", Service: "routes", Justification: "cached at the edge"},
+			},
+		},
+		{
+			Name: "prometheus",
+			Exemptions: []manifest.PolicyExemption{
+				{Team: "flight-data", Justification: "expired", Expires: stringPtr("2026-05-31")},
+			},
+		},
+	}
+
+	plugins, exempted := Plugins(policies, "flight-data", "routes", now)
+	assert.Equal(t, []Exempted{{Plugin: "rate-limiting", Justification: "cached at the edge"}}, exempted)
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "prometheus", "enabled": true, "tags": []interface{}{"ko-policy=prometheus"}},
+	}, plugins)
+
+	plugins, exempted = Plugins(policies, "flight-data", "flights", now)
+	assert.Empty(t, exempted)
+	require.Len(t, plugins, 2)
+	assert.Equal(t, map[string]interface{}{
+		"minute": 100,
+		"redis":  map[string]interface{}{"host": "redis", "password": "{vault://env/REDIS_PASSWORD}"},
+	}, plugins[0]["config"])
+	// the manifest configuration is left untouched
+	assert.Equal(t, map[string]interface{}{"host": "redis"}, policies[0].Config["redis"])
+}
+
+func TestPatches(t *testing.T) {
+	assert.Nil(t, Patches(nil))
+
+	f := patch.File{FormatVersion: patch.FormatVersion, Patches: Patches([]map[string]interface{}{
+		{"name": "prometheus", "enabled": true},
+		{"name": "rate-limiting", "enabled": true, "config": map[string]interface{}{"minute": 100}},
+	})}
+	require.NoError(t, f.Validate())
+
+	doc := map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"name": "a"},
+			map[string]interface{}{"name": "b", "plugins": []interface{}{
+				map[string]interface{}{"name": "rate-limiting", "config": map[string]interface{}{"minute": 100000}},
+				map[string]interface{}{"name": "cors"},
+			}},
+		},
+	}
+	require.NoError(t, f.Apply(doc))
+
+	services := doc["services"].([]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "prometheus", "enabled": true},
+		map[string]interface{}{"name": "rate-limiting", "enabled": true, "config": map[string]interface{}{"minute": 100}},
+	}, services[0].(map[string]interface{})["plugins"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "cors"},
+		map[string]interface{}{"name": "prometheus", "enabled": true},
+		map[string]interface{}{"name": "rate-limiting", "enabled": true, "config": map[string]interface{}{"minute": 100}},
+	}, services[1].(map[string]interface{})["plugins"])
+}
+
+func TestCheckRoutes(t *testing.T) {
+	policyPlugin := map[string]interface{}{"name": "rate-limiting", "tags": []interface{}{"ko-policy=rate-limiting"}}
+	tests := []struct {
+		name    string
+		doc     map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "route plugins not enforced by a policy",
+			doc: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":    "flights",
+						"plugins": []interface{}{policyPlugin},
+						"routes": []interface{}{
+							map[string]interface{}{"name": "get-flights", "plugins": []interface{}{
+								map[string]interface{}{"name": "cors"},
+							}},
+						},
+					},
+					map[string]interface{}{
+						"name": "routes",
+						"routes": []interface{}{
+							map[string]interface{}{"name": "get-routes", "plugins": []interface{}{
+								map[string]interface{}{"name": "rate-limiting"},
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "nested route shadowing a policy",
+			doc: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":    "flights",
+						"plugins": []interface{}{policyPlugin},
+						"routes": []interface{}{
+							map[string]interface{}{"name": "get-flights", "plugins": []interface{}{
+								map[string]interface{}{"name": "rate-limiting", "config": map[string]interface{}{"minute": 100000}},
+							}},
+						},
+					},
+				},
+			},
+			wantErr: "route get-flights of service flights configures the plugin rate-limiting enforced by a policy",
+		},
+		{
+			name: "top level route shadowing a policy",
+			doc: map[string]interface{}{
+				"services": []interface{}{
+					map[string]interface{}{"name": "flights", "plugins": []interface{}{policyPlugin}},
+				},
+				"routes": []interface{}{
+					map[string]interface{}{
+						"name":    "get-flights",
+						"service": map[string]interface{}{"name": "flights"},
+						"plugins": []interface{}{map[string]interface{}{"name": "rate-limiting"}},
+					},
+				},
+			},
+			wantErr: "route get-flights of service flights",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRoutes(tt.doc)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
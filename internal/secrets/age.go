@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/age"
+)
+
+// Environment variables of the age identities and recipients, shared with sops
+const (
+	AgeKeyEnvVar        = "SOPS_AGE_KEY"
+	AgeKeyFileEnvVar    = "SOPS_AGE_KEY_FILE"
+	AgeRecipientsEnvVar = "SOPS_AGE_RECIPIENTS"
+)
+
+// resolveAge decrypts an inline ASCII armored age value, or an age encrypted file given by its path
+func resolveAge(_ context.Context, ref string) (string, error) {
+	ciphertext := []byte(ref)
+	if !age.IsArmored(ref) {
+		var err error
+		if ciphertext, err = os.ReadFile(os.ExpandEnv(strings.TrimSpace(ref))); err != nil {
+			return "", fmt.Errorf("failed to read age encrypted file: %w", err)
+		}
+	}
+	identities, err := AgeIdentities()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := age.Decrypt(ciphertext, identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt age secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// AgeIdentities returns the age identities of SOPS_AGE_KEY, or of the file SOPS_AGE_KEY_FILE, by default the
+// keys.txt of sops in the user config directory, e.g. ~/.config/sops/age/keys.txt
+func AgeIdentities() ([]*age.Identity, error) {
+	if keys := os.Getenv(AgeKeyEnvVar); keys != "" {
+		ids, err := age.ParseIdentities(keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", AgeKeyEnvVar, err)
+		}
+		return ids, nil
+	}
+	path := os.Getenv(AgeKeyFileEnvVar)
+	if path == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("no age identity, set %s or %s: %w", AgeKeyEnvVar, AgeKeyFileEnvVar, err)
+		}
+		path = filepath.Join(configDir, "sops", "age", "keys.txt")
+	}
+	keys, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no age identity, set %s or %s", AgeKeyEnvVar, AgeKeyFileEnvVar)
+		}
+		return nil, fmt.Errorf("failed to read age identities: %w", err)
+	}
+	ids, err := age.ParseIdentities(string(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identities of %s: %w", path, err)
+	}
+	return ids, nil
+}
+
+// ParseAgeRecipients parses comma or whitespace separated age recipients, SOPS_AGE_RECIPIENTS when none are
+// given
+func ParseAgeRecipients(values ...string) ([]*age.Recipient, error) {
+	if len(values) == 0 && os.Getenv(AgeRecipientsEnvVar) != "" {
+		values = []string{os.Getenv(AgeRecipientsEnvVar)}
+	}
+	var recipients []*age.Recipient
+	for _, value := range values {
+		for _, s := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+			r, err := age.ParseRecipient(s)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, r)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients, pass them or set %s", AgeRecipientsEnvVar)
+	}
+	return recipients, nil
+}
+
+// EncryptAge returns the ASCII armored value of an age secret encrypted to recipients
+func EncryptAge(value string, recipients []*age.Recipient) (string, error) {
+	ciphertext, err := age.Encrypt([]byte(value), recipients...)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt age secret: %w", err)
+	}
+	return age.Armor(ciphertext), nil
+}
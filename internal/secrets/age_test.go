@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/age"
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolveAge(t *testing.T) {
+	id, err := age.GenerateIdentity()
+	require.NoError(t, err)
+	armored, err := EncryptAge(`{"token":"kpat_123"}`, []*age.Recipient{id.Recipient()})
+	require.NoError(t, err)
+	ciphertext, err := age.Dearmor(armored)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token.age")
+	require.NoError(t, os.WriteFile(file, ciphertext, 0o600))
+	keyFile := filepath.Join(dir, "keys.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte("# public key: "+id.Recipient().String()+"\n"+id.String()+"\n"), 0o600))
+
+	t.Setenv(AgeKeyEnvVar, id.String())
+	value, err := Resolve(context.Background(), manifest.Secret{Type: TypeAge, Value: armored, Key: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, "kpat_123", value)
+
+	t.Setenv(AgeKeyEnvVar, "")
+	t.Setenv(AgeKeyFileEnvVar, keyFile)
+	value, err = Resolve(context.Background(), manifest.Secret{Type: TypeAge, Value: file})
+	require.NoError(t, err)
+	assert.Equal(t, `{"token":"kpat_123"}`, value)
+
+	other, err := age.GenerateIdentity()
+	require.NoError(t, err)
+	t.Setenv(AgeKeyEnvVar, other.String())
+	_, err = Resolve(context.Background(), manifest.Secret{Type: TypeAge, Value: armored})
+	assert.ErrorIs(t, err, age.ErrNoIdentity)
+}
+
+func TestTransformYAML(t *testing.T) {
+	id, err := age.GenerateIdentity()
+	require.NoError(t, err)
+	recipients := []*age.Recipient{id.Recipient()}
+	t.Setenv(AgeKeyEnvVar, id.String())
+
+	input := `Org:
+  # the admin token
+  access-token:
+    type: literal
+    value: kpat_123
+  environments:
+    dev:
+      type: DEV
+      region: us
+  client-secret:
+    type: env
+    value: CLIENT_SECRET
+`
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(input), &doc))
+
+	n, err := TransformYAML(&doc, func(secret *manifest.Secret) (bool, error) {
+		if secret.Type != TypeLiteral {
+			return false, nil
+		}
+		secret.Type = TypeAge
+		secret.Value, err = EncryptAge(secret.Value, recipients)
+		return true, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	out, err := marshalYAML(&doc)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "# the admin token")
+	assert.Contains(t, string(out), "type: age")
+	assert.Contains(t, string(out), "value: |\n      -----BEGIN AGE ENCRYPTED FILE-----")
+	assert.Contains(t, string(out), "value: CLIENT_SECRET")
+	assert.Contains(t, string(out), "type: DEV")
+
+	var parsed map[string]struct {
+		AccessToken manifest.Secret `yaml:"access-token"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &parsed))
+	value, err := Resolve(context.Background(), parsed["Org"].AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "kpat_123", value)
+
+	n, err = TransformYAML(&doc, func(secret *manifest.Secret) (bool, error) {
+		if secret.Type != TypeAge {
+			return false, nil
+		}
+		secret.Value, err = Resolve(context.Background(), *secret)
+		secret.Type = TypeLiteral
+		return true, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	out, err = marshalYAML(&doc)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "type: literal\n    value: kpat_123\n")
+}
+
+func marshalYAML(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(doc)
+	return buf.Bytes(), err
+}
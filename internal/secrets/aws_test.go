@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSSigningKey(t *testing.T) {
+	// The example of the AWS Signature Version 4 documentation
+	key := awsSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	t.Cleanup(ResetCache)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKID/\d{8}/eu-west-1/secretsmanager/aws4_request, `+
+			`SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=[0-9a-f]{64}$`,
+			r.Header.Get("Authorization"))
+
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch body["SecretId"] {
+		case "konnect/prod":
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"token":"kpat_123"}`})
+		case "arn:aws:secretsmanager:eu-west-1:123456789012:secret:binary":
+			_ = json.NewEncoder(w).Encode(map[string]string{"SecretBinary": "a3BhdF80NTY="})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", server.URL)
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+
+	got, err := Resolve(context.Background(), manifest.Secret{Type: TypeAWSSM, Value: "konnect/prod", Key: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, "kpat_123", got)
+
+	got, err = Resolve(context.Background(), manifest.Secret{
+		Type:  TypeAWSSM,
+		Value: "arn:aws:secretsmanager:eu-west-1:123456789012:secret:binary",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "kpat_456", got)
+
+	_, err = Resolve(context.Background(), manifest.Secret{Type: TypeAWSSM, Value: "konnect/dev"})
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+}
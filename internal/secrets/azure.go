@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const azureKeyVaultScope = "https://vault.azure.net"
+
+// resolveAzureSecret reads a secret of Azure Key Vault, given by its identifier,
+// e.g. https://my-vault.vault.azure.net/secrets/konnect-token, or as <vault>/<name>[/<version>]. The access
+// token is AZURE_ACCESS_TOKEN, or requested for the service principal of AZURE_TENANT_ID, AZURE_CLIENT_ID and
+// AZURE_CLIENT_SECRET from AZURE_AUTHORITY_HOST, or for the managed identity of the host.
+func resolveAzureSecret(ctx context.Context, ref string) (string, error) {
+	secretURL := ref
+	if !strings.Contains(ref, "://") {
+		parts := strings.SplitN(ref, "/", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("azure key vault secret %s isn't of the form <vault>/<name>[/<version>]", ref)
+		}
+		secretURL = fmt.Sprintf("https://%s.vault.azure.net/secrets/%s", parts[0], parts[1])
+	}
+	token, err := azureToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL+"?api-version=7.4", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get Azure Key Vault secret %s: %w", ref, err)
+	}
+	return resp.Value, nil
+}
+
+func azureToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("AZURE_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	var req *http.Request
+	var err error
+	tenantID, clientID, clientSecret := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_CLIENT_SECRET")
+	if clientSecret != "" {
+		authority := os.Getenv("AZURE_AUTHORITY_HOST")
+		if authority == "" {
+			authority = "https://login.microsoftonline.com/"
+		}
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {clientSecret},
+			"scope":         {azureKeyVaultScope + "/.default"},
+		}
+		tokenURL := strings.TrimRight(authority, "/") + "/" + tenantID + "/oauth2/v2.0/token"
+		if req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode())); err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		// The managed identity of a virtual machine, or of the client id of a user assigned identity
+		q := url.Values{"api-version": {"2018-02-01"}, "resource": {azureKeyVaultScope}}
+		if clientID != "" {
+			q.Set("client_id", clientID)
+		}
+		endpoint := os.Getenv("AZURE_IMDS_ENDPOINT")
+		if endpoint == "" {
+			endpoint = "http://169.254.169.254"
+		}
+		if req, err = http.NewRequestWithContext(ctx, http.MethodGet,
+			strings.TrimRight(endpoint, "/")+"/metadata/identity/oauth2/token?"+q.Encode(), nil); err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get an Azure access token: %w", err)
+	}
+	return resp.AccessToken, nil
+}
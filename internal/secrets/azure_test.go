@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureKeyVault(t *testing.T) {
+	t.Cleanup(ResetCache)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tenant/oauth2/v2.0/token":
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "https://vault.azure.net/.default", r.PostForm.Get("scope"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "eyJ0"})
+		case "/secrets/konnect-token":
+			assert.Equal(t, "Bearer eyJ0", r.Header.Get("Authorization"))
+			assert.NotEmpty(t, r.URL.Query().Get("api-version"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"value": "kpat_123"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("AZURE_ACCESS_TOKEN", "")
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL)
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+
+	got, err := Resolve(context.Background(), manifest.Secret{Type: TypeAzureKV, Value: server.URL + "/secrets/konnect-token"})
+	require.NoError(t, err)
+	assert.Equal(t, "kpat_123", got)
+
+	_, err = Resolve(context.Background(), manifest.Secret{Type: TypeAzureKV, Value: "konnect-token"})
+	assert.ErrorContains(t, err, "isn't of the form <vault>/<name>[/<version>]")
+}
@@ -0,0 +1,28 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// resolveExec runs a command with the shell and returns its output without the trailing newlines, e.g.
+// `op read op://platform/konnect/token` or `pass show konnect/token`
+func resolveExec(ctx context.Context, ref string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", ref)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", ref)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run secret command %q: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,150 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+
+// gcpProvider reads secret versions of Google Cloud Secret Manager, given by resource name, e.g.
+// projects/my-project/secrets/konnect-token, with /versions/latest by default. The access token is
+// GOOGLE_OAUTH_ACCESS_TOKEN, or minted for the service account key of GOOGLE_APPLICATION_CREDENTIALS, or
+// for the service account of the compute instance from the metadata server.
+type gcpProvider struct {
+	endpoint string
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+type gcpServiceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func (p *gcpProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	name := strings.Trim(ref, "/")
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	token, err := p.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to access Google Cloud secret %s: %w", ref, err)
+	}
+	value, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode Google Cloud secret %s: %w", ref, err)
+	}
+	return string(value), nil
+}
+
+func (p *gcpProvider) token(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Until(p.expiry) > time.Minute {
+		return p.accessToken, nil
+	}
+
+	var req *http.Request
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		assertion, tokenURI, err := gcpServiceAccountAssertion(path)
+		if err != nil {
+			return "", err
+		}
+		form := url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+		if req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode())); err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		host := os.Getenv("GCE_METADATA_HOST")
+		if host == "" {
+			host = "metadata.google.internal"
+		}
+		var err error
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet,
+			"http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get a Google Cloud access token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("failed to get a Google Cloud access token: empty token")
+	}
+	p.accessToken, p.expiry = resp.AccessToken, time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second)
+	return p.accessToken, nil
+}
+
+// gcpServiceAccountAssertion returns the signed JWT exchanged for an access token of a service account key
+func gcpServiceAccountAssertion(path string) (string, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read Google Cloud credentials: %w", err)
+	}
+	var key gcpServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return "", "", fmt.Errorf("failed to parse Google Cloud credentials: %w", err)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(key.PrivateKey))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse the private key of %s: %w", key.ClientEmail, err)
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   key.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/cloud-platform",
+		"aud":   key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(privateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign the token of %s: %w", key.ClientEmail, err)
+	}
+	return assertion, key.TokenURI, nil
+}
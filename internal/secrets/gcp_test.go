@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPSecretManager(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			require.NoError(t, r.ParseForm())
+			claims := jwt.MapClaims{}
+			_, err := jwt.ParseWithClaims(r.PostForm.Get("assertion"), claims,
+				func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+			assert.NoError(t, err)
+			assert.Equal(t, "orchestrator@kong-air.iam.gserviceaccount.com", claims["iss"])
+			assert.Equal(t, server.URL+"/token", claims["aud"])
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "ya29", "expires_in": 3600})
+		case "/v1/projects/kong-air/secrets/konnect-token/versions/latest:access":
+			assert.Equal(t, "Bearer ya29", r.Header.Get("Authorization"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"payload": map[string]string{"data": "a3BhdF8xMjM="}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	credentials := filepath.Join(t.TempDir(), "credentials.json")
+	data, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "orchestrator@kong-air.iam.gserviceaccount.com",
+		"private_key": string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})),
+		"token_uri": server.URL + "/token",
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(credentials, data, 0o600))
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", credentials)
+
+	p := &gcpProvider{endpoint: server.URL + "/v1/"}
+	got, err := p.Resolve(context.Background(), "projects/kong-air/secrets/konnect-token")
+	require.NoError(t, err)
+	assert.Equal(t, "kpat_123", got)
+
+	_, err = p.Resolve(context.Background(), "projects/kong-air/secrets/missing/versions/1")
+	assert.ErrorContains(t, err, "failed to access Google Cloud secret projects/kong-air/secrets/missing/versions/1")
+}
@@ -0,0 +1,230 @@
+// Package secrets resolves the values of manifest secrets from files, environment variables, age encrypted
+// values and external secret stores like HashiCorp Vault and the secret managers of AWS, Google Cloud and Azure.
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// EncodingBase64 decodes base64 encoded secrets
+const EncodingBase64 = "base64"
+
+// Secret types
+const (
+	TypeFile    = "file"
+	TypeEnv     = "env"
+	TypeLiteral = "literal"
+	TypeVault   = "vault"
+	TypeAWSSM   = "aws-sm"
+	TypeGCPSM   = "gcp-sm"
+	TypeAzureKV = "azure-kv"
+	TypeExec    = "exec"
+	TypeAge     = "age"
+)
+
+// Provider resolves the secrets of a type, ref is the value of the secret, e.g. a path or a secret name
+type Provider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// unwrapper is implemented by the providers of secrets holding JSON objects, unwrap returns the value of a
+// secret read without a key
+type unwrapper interface {
+	unwrap(value string) string
+}
+
+// ProviderFunc adapts a function to a Provider
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+func (f ProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+type registration struct {
+	provider Provider
+	// cached providers resolve each secret once per run
+	cached bool
+}
+
+var (
+	mu        sync.Mutex
+	providers = map[string]registration{}
+	cache     = map[string]string{}
+)
+
+// httpClient calls the APIs of the secret stores
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+func init() {
+	Register(TypeFile, ProviderFunc(resolveFile), false)
+	Register(TypeEnv, ProviderFunc(resolveEnv), false)
+	Register(TypeLiteral, ProviderFunc(func(_ context.Context, ref string) (string, error) { return ref, nil }), false)
+	Register(TypeVault, &vaultProvider{}, true)
+	Register(TypeAWSSM, ProviderFunc(resolveAWSSecret), true)
+	Register(TypeGCPSM, &gcpProvider{endpoint: gcpSecretManagerURL}, true)
+	Register(TypeAzureKV, ProviderFunc(resolveAzureSecret), true)
+	Register(TypeExec, ProviderFunc(resolveExec), true)
+	Register(TypeAge, ProviderFunc(resolveAge), false)
+}
+
+// Register adds or replaces the provider of a secret type. The values of cached providers are resolved once
+// per run.
+func Register(secretType string, provider Provider, cached bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[secretType] = registration{provider: provider, cached: cached}
+}
+
+// Types returns the registered secret types
+func Types() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	types := make([]string, 0, len(providers))
+	for t := range providers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Resolve returns the value of a secret, or the value of the key of the JSON object it holds when the secret
+// has a key, trimmed and decoded as configured by the secret
+func Resolve(ctx context.Context, secret manifest.Secret) (string, error) {
+	mu.Lock()
+	r, ok := providers[secret.Type]
+	cacheKey := secret.Type + "\x00" + secret.Value
+	value, cached := cache[cacheKey]
+	mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("unsupported token type: %s, expected one of %s", secret.Type, strings.Join(Types(), ", "))
+	}
+
+	if !r.cached || !cached {
+		var err error
+		if value, err = r.provider.Resolve(ctx, secret.Value); err != nil {
+			return "", err
+		}
+		if r.cached {
+			mu.Lock()
+			cache[cacheKey] = value
+			mu.Unlock()
+		}
+	}
+	if secret.Key != "" {
+		var err error
+		if value, err = selectKey(value, secret.Key); err != nil {
+			return "", err
+		}
+	} else if u, ok := r.provider.(unwrapper); ok {
+		value = u.unwrap(value)
+	}
+	return postProcess(value, secret)
+}
+
+// postProcess trims and decodes the value of a secret
+func postProcess(value string, secret manifest.Secret) (string, error) {
+	trim := secret.Trim == nil || *secret.Trim
+	if trim {
+		value = strings.TrimSpace(value)
+	}
+	switch secret.Encoding {
+	case "":
+		return value, nil
+	case EncodingBase64:
+		decoded, err := decodeBase64(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode base64 secret: %w", err)
+		}
+		if trim {
+			decoded = strings.TrimSpace(decoded)
+		}
+		return decoded, nil
+	default:
+		return "", fmt.Errorf("unsupported secret encoding: %s, expected %s", secret.Encoding, EncodingBase64)
+	}
+}
+
+// decodeBase64 decodes standard or URL safe base64, padded or not, ignoring line breaks
+func decodeBase64(value string) (string, error) {
+	value = strings.Join(strings.Fields(value), "")
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding,
+		base64.RawURLEncoding} {
+		var decoded []byte
+		if decoded, err = enc.DecodeString(value); err == nil {
+			return string(decoded), nil
+		}
+	}
+	return "", err
+}
+
+// ResetCache forgets the cached secret values
+func ResetCache() {
+	mu.Lock()
+	defer mu.Unlock()
+	cache = map[string]string{}
+}
+
+// selectKey returns the value of a key of a JSON object. Values which aren't strings are returned as JSON.
+func selectKey(value, key string) (string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return "", fmt.Errorf("failed to select key %s, the secret isn't a JSON object: %w", key, err)
+	}
+	raw, ok := object[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key %s", key)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	return string(raw), nil
+}
+
+func resolveFile(_ context.Context, ref string) (string, error) {
+	content, err := os.ReadFile(os.ExpandEnv(ref))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return string(content), nil
+}
+
+func resolveEnv(_ context.Context, ref string) (string, error) {
+	value := os.Getenv(ref)
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s not set", ref)
+	}
+	return value, nil
+}
+
+// doJSON sends a request and decodes the JSON response into out
+func doJSON(req *http.Request, out interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var msg strings.Builder
+		_, _ = fmt.Fprintf(&msg, "%s %s: unexpected status %d", req.Method, req.URL.Redacted(), resp.StatusCode)
+		var body json.RawMessage
+		if json.NewDecoder(resp.Body).Decode(&body) == nil {
+			_, _ = fmt.Fprintf(&msg, ": %s", body)
+		}
+		return errors.New(msg.String())
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
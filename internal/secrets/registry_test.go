@@ -0,0 +1,117 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secret.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"token":"kpat_123","port":8443}`), 0o600))
+	t.Setenv("SECRET_TEST_TOKEN", "spat_456")
+	patFile := filepath.Join(dir, "token.pat")
+	require.NoError(t, os.WriteFile(patFile, []byte("kpat_789\n"), 0o600))
+	t.Setenv("SECRET_TEST_BASE64", "a3BhdF8xMjMK\n")
+	t.Setenv("SECRET_TEST_BASE64_URL", "a3BhdF8_Pz8")
+	verbatim := false
+
+	tests := []struct {
+		name    string
+		secret  manifest.Secret
+		want    string
+		wantErr string
+	}{
+		{name: "literal", secret: manifest.Secret{Type: TypeLiteral, Value: "value"}, want: "value"},
+		{name: "env", secret: manifest.Secret{Type: TypeEnv, Value: "SECRET_TEST_TOKEN"}, want: "spat_456"},
+		{name: "file", secret: manifest.Secret{Type: TypeFile, Value: file}, want: `{"token":"kpat_123","port":8443}`},
+		{name: "string key", secret: manifest.Secret{Type: TypeFile, Value: file, Key: "token"}, want: "kpat_123"},
+		{name: "number key", secret: manifest.Secret{Type: TypeFile, Value: file, Key: "port"}, want: "8443"},
+		{
+			name:    "missing key",
+			secret:  manifest.Secret{Type: TypeFile, Value: file, Key: "password"},
+			wantErr: "secret has no key password",
+		},
+		{
+			name:    "key of a string",
+			secret:  manifest.Secret{Type: TypeEnv, Value: "SECRET_TEST_TOKEN", Key: "token"},
+			wantErr: "isn't a JSON object",
+		},
+		{name: "trimmed file", secret: manifest.Secret{Type: TypeFile, Value: patFile}, want: "kpat_789"},
+		{
+			name:   "verbatim file",
+			secret: manifest.Secret{Type: TypeFile, Value: patFile, Trim: &verbatim},
+			want:   "kpat_789\n",
+		},
+		{
+			name:   "base64",
+			secret: manifest.Secret{Type: TypeEnv, Value: "SECRET_TEST_BASE64", Encoding: EncodingBase64},
+			want:   "kpat_123",
+		},
+		{
+			name:   "url safe base64",
+			secret: manifest.Secret{Type: TypeEnv, Value: "SECRET_TEST_BASE64_URL", Encoding: EncodingBase64},
+			want:   "kpat_???",
+		},
+		{
+			name:    "invalid base64",
+			secret:  manifest.Secret{Type: TypeLiteral, Value: "kpat_123!", Encoding: EncodingBase64},
+			wantErr: "failed to decode base64 secret",
+		},
+		{
+			name:    "unsupported encoding",
+			secret:  manifest.Secret{Type: TypeLiteral, Value: "kpat_123", Encoding: "hex"},
+			wantErr: "unsupported secret encoding: hex",
+		},
+		{
+			name:    "unset env",
+			secret:  manifest.Secret{Type: TypeEnv, Value: "SECRET_TEST_UNSET"},
+			wantErr: "environment variable SECRET_TEST_UNSET not set",
+		},
+		{name: "unsupported type", secret: manifest.Secret{Type: "keychain"}, wantErr: "unsupported token type: keychain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(context.Background(), tt.secret)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command uses sh")
+	}
+	t.Cleanup(ResetCache)
+	counter := filepath.Join(t.TempDir(), "runs")
+	secret := manifest.Secret{Type: TypeExec, Value: `echo run >> ` + counter + `; printf '{"token":"kpat_123"}\n'`}
+
+	// Commands run once per run, the keys are selected from the cached output
+	for _, key := range []string{"", "token"} {
+		secret.Key = key
+		_, err := Resolve(context.Background(), secret)
+		require.NoError(t, err)
+	}
+	secret.Key = "token"
+	got, err := Resolve(context.Background(), secret)
+	require.NoError(t, err)
+	assert.Equal(t, "kpat_123", got)
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(runs))
+
+	_, err = Resolve(context.Background(), manifest.Secret{Type: TypeExec, Value: "echo denied >&2; exit 3"})
+	assert.ErrorContains(t, err, "denied")
+}
@@ -0,0 +1,154 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// vaultProvider reads secrets of the KV v1 and v2 secrets engines of HashiCorp Vault. The ref is the path of
+// the secret including its mount, e.g. secret/konnect/prod. The server and credentials are configured with
+// the environment variables of the vault CLI: VAULT_ADDR, VAULT_NAMESPACE and VAULT_TOKEN, or VAULT_ROLE_ID
+// and VAULT_SECRET_ID to log in with AppRole at VAULT_APPROLE_MOUNT (approle by default).
+type vaultProvider struct {
+	mu sync.Mutex
+	// token is the client token of the AppRole login, by server
+	tokens map[string]string
+}
+
+type vaultMount struct {
+	Path    string `json:"path"`
+	Options struct {
+		Version string `json:"version"`
+	} `json:"options"`
+}
+
+func (p *vaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		addr = "http://127.0.0.1:8200"
+	}
+	token, err := p.token(ctx, addr)
+	if err != nil {
+		return "", err
+	}
+
+	path := strings.Trim(ref, "/")
+	mount := vaultMount{}
+	// The mount and the version of its KV engine, v1 is assumed when the token can't read the mount
+	var mountResp struct {
+		Data vaultMount `json:"data"`
+	}
+	if err := p.do(ctx, addr, token, http.MethodGet, "sys/internal/ui/mounts/"+path, nil, &mountResp); err == nil {
+		mount = mountResp.Data
+	}
+
+	var data map[string]interface{}
+	if mount.Options.Version == "2" {
+		var resp struct {
+			Data struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"data"`
+		}
+		rest := strings.TrimPrefix(path, strings.Trim(mount.Path, "/"))
+		err = p.do(ctx, addr, token, http.MethodGet, strings.Trim(mount.Path, "/")+"/data"+rest, nil, &resp)
+		data = resp.Data.Data
+	} else {
+		var resp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		err = p.do(ctx, addr, token, http.MethodGet, path, nil, &resp)
+		data = resp.Data
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret %s: %w", ref, err)
+	}
+	if data == nil {
+		return "", fmt.Errorf("vault secret %s has no data", ref)
+	}
+
+	value, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// unwrap returns the value of a secret of a single string field, the keys of other secrets must be selected
+func (p *vaultProvider) unwrap(value string) string {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &data); err != nil || len(data) != 1 {
+		return value
+	}
+	for _, v := range data {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return value
+}
+
+// token returns VAULT_TOKEN, or the client token of an AppRole login
+func (p *vaultProvider) token(ctx context.Context, addr string) (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	roleID, secretID := os.Getenv("VAULT_ROLE_ID"), os.Getenv("VAULT_SECRET_ID")
+	if roleID == "" {
+		return "", errors.New("no vault credentials configured, set VAULT_TOKEN, or VAULT_ROLE_ID and VAULT_SECRET_ID")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if token, ok := p.tokens[addr]; ok {
+		return token, nil
+	}
+	mount := os.Getenv("VAULT_APPROLE_MOUNT")
+	if mount == "" {
+		mount = "approle"
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": roleID, "secret_id": secretID}
+	if err := p.do(ctx, addr, "", http.MethodPost, "auth/"+strings.Trim(mount, "/")+"/login", body, &resp); err != nil {
+		return "", fmt.Errorf("failed to log in to vault with AppRole: %w", err)
+	}
+	if p.tokens == nil {
+		p.tokens = map[string]string{}
+	}
+	p.tokens[addr] = resp.Auth.ClientToken
+	return resp.Auth.ClientToken, nil
+}
+
+func (p *vaultProvider) do(ctx context.Context, addr, token, method, path string, body, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, addr+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	return doJSON(req, out)
+}
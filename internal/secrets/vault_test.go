@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVaultServer fakes a vault dev server with a KV v2 engine at secret/ and a KV v1 engine at kv/
+func newVaultServer(t *testing.T, token string) *[]string {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, map[string]string{"role_id": "role", "secret_id": "secret"}, body)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]string{"client_token": token}})
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var resp interface{}
+		switch r.URL.Path {
+		case "/v1/sys/internal/ui/mounts/secret/konnect/prod":
+			resp = map[string]interface{}{"data": map[string]interface{}{"path": "secret/", "options": map[string]string{"version": "2"}}}
+		case "/v1/secret/data/konnect/prod":
+			resp = map[string]interface{}{"data": map[string]interface{}{"data": map[string]string{"token": "kpat_123", "org": "kong-air"}}}
+		case "/v1/sys/internal/ui/mounts/kv/konnect":
+			resp = map[string]interface{}{"data": map[string]interface{}{"path": "kv/", "options": nil}}
+		case "/v1/kv/konnect":
+			resp = map[string]interface{}{"data": map[string]string{"token": "kpat_456"}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("VAULT_ADDR", server.URL)
+	return &requests
+}
+
+func TestVault(t *testing.T) {
+	t.Run("reads KV v2 secrets with a token", func(t *testing.T) {
+		t.Cleanup(ResetCache)
+		newVaultServer(t, "root")
+		t.Setenv("VAULT_TOKEN", "root")
+
+		got, err := Resolve(context.Background(), manifest.Secret{Type: TypeVault, Value: "secret/konnect/prod", Key: "token"})
+		require.NoError(t, err)
+		assert.Equal(t, "kpat_123", got)
+
+		_, err = Resolve(context.Background(), manifest.Secret{Type: TypeVault, Value: "secret/konnect/prod"})
+		require.NoError(t, err)
+		_, err = Resolve(context.Background(), manifest.Secret{Type: TypeVault, Value: "secret/konnect/dev"})
+		assert.ErrorContains(t, err, "failed to read vault secret secret/konnect/dev")
+	})
+
+	t.Run("reads single field KV v1 secrets with AppRole", func(t *testing.T) {
+		t.Cleanup(ResetCache)
+		requests := newVaultServer(t, "approle-token")
+		t.Setenv("VAULT_TOKEN", "")
+		t.Setenv("VAULT_ROLE_ID", "role")
+		t.Setenv("VAULT_SECRET_ID", "secret")
+		Register(TypeVault, &vaultProvider{}, true)
+
+		got, err := Resolve(context.Background(), manifest.Secret{Type: TypeVault, Value: "kv/konnect"})
+		require.NoError(t, err)
+		assert.Equal(t, "kpat_456", got)
+		assert.Equal(t, []string{
+			"POST /v1/auth/approle/login",
+			"GET /v1/sys/internal/ui/mounts/kv/konnect",
+			"GET /v1/kv/konnect",
+		}, *requests)
+
+		// the field can be selected too
+		got, err = Resolve(context.Background(), manifest.Secret{Type: TypeVault, Value: "kv/konnect", Key: "token"})
+		require.NoError(t, err)
+		assert.Equal(t, "kpat_456", got)
+	})
+}
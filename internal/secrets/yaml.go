@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// Transform rewrites a secret in place and reports whether it changed
+type Transform func(secret *manifest.Secret) (bool, error)
+
+// TransformYAML applies transform to the secrets of a YAML document, the mappings of a registered type and a
+// value, and returns the number of changed secrets. The rest of the document, including comments, is kept.
+func TransformYAML(node *yaml.Node, transform Transform) (int, error) {
+	changed := 0
+	if node.Kind == yaml.MappingNode {
+		if secret, typeNode, valueNode := secretNodes(node); secret != nil {
+			ok, err := transform(secret)
+			if err != nil || !ok {
+				return 0, err
+			}
+			if typeNode.Value != secret.Type {
+				// e.g. the comment of a file path doesn't describe the encrypted value
+				valueNode.LineComment = ""
+			}
+			typeNode.Value = secret.Type
+			valueNode.Value = secret.Value
+			valueNode.Tag = "!!str"
+			valueNode.Style = 0
+			if secret.Type == TypeAge {
+				valueNode.Style = yaml.LiteralStyle
+			}
+			return 1, nil
+		}
+	}
+	for _, child := range node.Content {
+		n, err := TransformYAML(child, transform)
+		if err != nil {
+			return changed, err
+		}
+		changed += n
+	}
+	return changed, nil
+}
+
+// secretNodes returns the secret of a mapping node with its type and value nodes, or nil when the mapping
+// isn't a secret
+func secretNodes(node *yaml.Node) (*manifest.Secret, *yaml.Node, *yaml.Node) {
+	var secret manifest.Secret
+	var typeNode, valueNode *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return nil, nil, nil
+		}
+		switch key.Value {
+		case "type":
+			secret.Type, typeNode = value.Value, value
+		case "value":
+			secret.Value, valueNode = value.Value, value
+		case "key":
+			secret.Key = value.Value
+		case "trim":
+			trim := value.Value == "true"
+			secret.Trim = &trim
+		case "encoding":
+			secret.Encoding = value.Value
+		default:
+			return nil, nil, nil
+		}
+	}
+	if typeNode == nil || valueNode == nil {
+		return nil, nil, nil
+	}
+	mu.Lock()
+	_, registered := providers[secret.Type]
+	mu.Unlock()
+	if !registered {
+		return nil, nil, nil
+	}
+	return &secret, typeNode, valueNode
+}
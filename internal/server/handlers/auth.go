@@ -282,7 +282,13 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// Create a GitHub client
-	client := gh.CreateGitHubClient(c.Request.Context(), githubToken.(string))
+	client, err := h.authService.Endpoints().TokenClient(c.Request.Context(), githubToken.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create GitHub client",
+		})
+		return
+	}
 
 	// Get the user from GitHub
 	user, _, err := client.Users.Get(c.Request.Context(), "")
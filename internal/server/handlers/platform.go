@@ -6,7 +6,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +18,7 @@ import (
 
 	"github.com/Kong/konnect-orchestrator/internal/git"
 	gh "github.com/Kong/konnect-orchestrator/internal/git/github"
+	"github.com/Kong/konnect-orchestrator/internal/git/provider"
 	"github.com/Kong/konnect-orchestrator/internal/manifest"
 	giturl "github.com/kubescape/go-git-url"
 	"gopkg.in/yaml.v3"
@@ -92,6 +92,27 @@ func (h *PlatformHandler) GetRepositoryPullRequests(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// ListRepositories lists the repositories of the owner of the platform repository, on any git provider
+func (h *PlatformHandler) ListRepositories(c *gin.Context) {
+	hosting, err := provider.New(h.platformGitConfig)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	repos, err := hosting.ListRepositories(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get repositories: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"repositories": repos,
+	})
+}
+
 func (h *PlatformHandler) GetExistingServices(c *gin.Context) {
 	auth, err := git.GetAuthMethod(h.platformGitConfig)
 	if err != nil {
@@ -108,7 +129,7 @@ func (h *PlatformHandler) GetExistingServices(c *gin.Context) {
 	r, err := goGit.Clone(memory.NewStorage(), fs, &goGit.CloneOptions{
 		URL:           *h.platformGitConfig.Remote,
 		SingleBranch:  true,
-		ReferenceName: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", "main")), // Assuming main branch
+		ReferenceName: plumbing.NewBranchReferenceName(h.platformGitConfig.BaseBranchName()),
 		Auth:          auth,
 	})
 	if err != nil {
@@ -187,12 +208,8 @@ func (h *PlatformHandler) GetExistingServices(c *gin.Context) {
 					repoPath := serviceKey
 					if service.Git != nil && service.Git.Remote != nil {
 						// Extract repo path from full URL
-						remoteURL := *service.Git.Remote
-						if strings.Contains(remoteURL, "github.com") {
-							parts := strings.Split(remoteURL, "github.com/")
-							if len(parts) > 1 {
-								repoPath = strings.TrimSuffix(parts[1], ".git")
-							}
+						if remote, err := git.ParseRemote(*service.Git.Remote); err == nil {
+							repoPath = remote.Path
 						}
 					}
 
@@ -254,7 +271,7 @@ func (h *PlatformHandler) AddServiceRepo(c *gin.Context) {
 		return
 	}
 
-	baseBranch := "main"
+	baseBranch := h.platformGitConfig.BaseBranchName()
 
 	// Create unique branch name with timestamp
 	newBranchName := fmt.Sprintf("add-service-%s", repoInfo.Name)
@@ -419,24 +436,18 @@ func (h *PlatformHandler) AddServiceRepo(c *gin.Context) {
 		return
 	}
 
-	gitURL, err := giturl.NewGitURL(*h.platformGitConfig.Remote)
+	hosting, err := provider.New(h.platformGitConfig)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get path parameters
-	owner := gitURL.GetOwnerName()
-	repo := gitURL.GetRepoName()
-
-	_, err = gh.CreateOrUpdatePullRequest(
+	_, err = hosting.CreateOrUpdatePullRequest(
 		c.Request.Context(),
-		owner,
-		repo,
 		newBranchName,
+		baseBranch,
 		fmt.Sprintf("[Konnect Orchestrator] - Add Service: %s", repoInfo.Name),
 		"Adding service manifest",
-		*h.platformGitConfig.GitHub,
 		[]string{"new-service"},
 	)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -125,7 +126,7 @@ func setupRouter(
 	router.Use(sessions.Sessions("github_session", store))
 
 	// Set up security headers
-	router.Use(securityHeaders())
+	router.Use(securityHeaders(services.NewEndpoints(cfg.GitHubURL, cfg.GitHubAPIURL, cfg.GitHubUploadURL)))
 
 	// Set up CORS
 	corsConfig := cors.DefaultConfig()
@@ -167,6 +168,7 @@ func setupRouter(
 		api.GET("/enterprise/:server/orgs", repoHandler.ListEnterpriseOrganizations)
 		api.GET("/platform/pulls", platformHandler.GetRepositoryPullRequests)
 		api.GET("/platform/service", platformHandler.GetExistingServices)
+		api.GET("/platform/repositories", platformHandler.ListRepositories)
 		api.POST("/platform/service", platformHandler.AddServiceRepo)
 
 		// Any POST, PUT, DELETE or PATCH requests need CSRF protection
@@ -220,10 +222,22 @@ func csrfProtected() gin.HandlerFunc {
 	}
 }
 
-func securityHeaders() gin.HandlerFunc {
+func securityHeaders(endpoints services.Endpoints) gin.HandlerFunc {
+	// The UI calls the API of the GitHub instance and shows the avatars of its users, served by the instance
+	// or by its avatars subdomain on GitHub Enterprise Server
+	connectSrc, imgSrc := "https://api.github.com", "https://avatars.githubusercontent.com"
+	if u, err := url.Parse(endpoints.APIURL); err == nil && u.Host != "" {
+		connectSrc = u.Scheme + "://" + u.Host
+	}
+	if u, err := url.Parse(endpoints.WebURL); err == nil && u.Host != "" && endpoints.IsEnterprise() {
+		imgSrc = fmt.Sprintf("%s://%s %s://avatars.%s", u.Scheme, u.Host, u.Scheme, u.Host)
+	}
+	csp := fmt.Sprintf("default-src 'self'; script-src 'self'; connect-src 'self' %s; img-src 'self' %s data:;",
+		connectSrc, imgSrc)
+
 	return func(c *gin.Context) {
 		// Content Security Policy
-		c.Header("Content-Security-Policy", "default-src 'self'; script-src 'self'; connect-src 'self' https://api.github.com; img-src 'self' https://avatars.githubusercontent.com data:;")
+		c.Header("Content-Security-Policy", csp)
 
 		// Other security headers
 		c.Header("X-Content-Type-Options", "nosniff")
@@ -0,0 +1,431 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// componentSections are the OpenAPI 3 component types a bundled $ref can be stored under
+var componentSections = map[string]bool{
+	"schemas":         true,
+	"responses":       true,
+	"parameters":      true,
+	"examples":        true,
+	"requestBodies":   true,
+	"headers":         true,
+	"securitySchemes": true,
+	"links":           true,
+	"callbacks":       true,
+}
+
+// swaggerSections maps the OpenAPI 3 component types to their Swagger 2 root level equivalents
+var swaggerSections = map[string]string{
+	"schemas":    "definitions",
+	"parameters": "parameters",
+	"responses":  "responses",
+}
+
+// Bundle resolves the external $refs of an OpenAPI spec against the files of the repository it was
+// loaded from and returns a single self-contained document. Referenced documents are moved into the
+// components (or Swagger 2 definitions) of the spec, path items are inlined. Remote (http) refs are
+// left untouched. The output keeps the YAML or JSON format of the input.
+func Bundle(repoDir string, f File) (File, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(f.Content, &doc); err != nil {
+		return File{}, fmt.Errorf("failed to parse spec %s: %w", f.Path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return File{}, fmt.Errorf("spec %s is not an object", f.Path)
+	}
+
+	b := &bundler{
+		repoDir:  repoDir,
+		rootFile: path.Clean(filepath.ToSlash(f.Path)),
+		root:     doc.Content[0],
+		swagger:  f.Type == OAS2,
+		docs:     map[string]*yaml.Node{},
+		refs:     map[string]string{},
+		names:    map[string]bool{},
+	}
+	b.collectNames()
+
+	if err := b.walk(b.root, b.rootFile, nil); err != nil {
+		return File{}, fmt.Errorf("failed to bundle spec %s: %w", f.Path, err)
+	}
+	if !b.changed {
+		return f, nil
+	}
+
+	var content []byte
+	var err error
+	if isJSON(f.Content) {
+		var buf bytes.Buffer
+		writeJSON(&buf, b.root, "")
+		buf.WriteString("\n")
+		content = buf.Bytes()
+	} else {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err = enc.Encode(&doc); err == nil {
+			err = enc.Close()
+		}
+		content = buf.Bytes()
+	}
+	if err != nil {
+		return File{}, fmt.Errorf("failed to write bundled spec %s: %w", f.Path, err)
+	}
+
+	f.Content = content
+	return f, nil
+}
+
+type bundler struct {
+	repoDir  string
+	rootFile string
+	root     *yaml.Node
+	swagger  bool
+	changed  bool
+	// docs caches the parsed external files by repository relative path
+	docs map[string]*yaml.Node
+	// refs maps an external file#pointer to the local ref it was bundled as
+	refs map[string]string
+	// names holds the section/name pairs already used in the root document
+	names map[string]bool
+}
+
+func (b *bundler) walk(node *yaml.Node, file string, keys []string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if ref := refValue(node); ref != "" {
+			return b.resolve(node, ref, file, keys)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := b.walk(node.Content[i+1], file, append(keys, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if err := b.walk(item, file, append(keys, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case yaml.DocumentNode, yaml.AliasNode, yaml.ScalarNode:
+	}
+	return nil
+}
+
+// resolve rewrites a $ref node found in file so that it points inside the root document
+func (b *bundler) resolve(node *yaml.Node, ref, file string, keys []string) error {
+	if strings.Contains(ref, "://") {
+		return nil
+	}
+
+	refFile, pointer, _ := strings.Cut(ref, "#")
+	if refFile == "" {
+		if b.isRoot(file) {
+			return nil
+		}
+		// a local ref inside an external document points into that document
+		refFile = file
+	} else {
+		refFile = path.Join(path.Dir(file), refFile)
+	}
+
+	// a ref from an external document back into the root document
+	if b.isRoot(refFile) {
+		setRefValue(node, "#"+pointer)
+		b.changed = true
+		return nil
+	}
+	target, err := b.load(refFile)
+	if err != nil {
+		return err
+	}
+	target, err = lookupPointer(target, pointer)
+	if err != nil {
+		return fmt.Errorf("failed to resolve $ref %s in %s: %w", ref, file, err)
+	}
+
+	// Path items can't be stored as components, they are copied in place
+	if len(keys) == 2 && keys[0] == "paths" {
+		inlined := deepCopy(target)
+		*node = *inlined
+		b.changed = true
+		return b.walk(node, refFile, keys)
+	}
+
+	key := refFile + "#" + pointer
+	if local, ok := b.refs[key]; ok {
+		setRefValue(node, local)
+		b.changed = true
+		return nil
+	}
+
+	section, name := componentLocation(refFile, pointer)
+	name = b.uniqueName(section, name)
+	local := b.localRef(section, name)
+	b.refs[key] = local
+
+	component := deepCopy(target)
+	if err := b.walk(component, refFile, nil); err != nil {
+		return err
+	}
+	b.addComponent(section, name, component)
+
+	setRefValue(node, local)
+	b.changed = true
+	return nil
+}
+
+func (b *bundler) isRoot(file string) bool {
+	return file == b.rootFile
+}
+
+func (b *bundler) load(file string) (*yaml.Node, error) {
+	if doc, ok := b.docs[file]; ok {
+		return doc, nil
+	}
+	fullPath, err := repoPath(b.repoDir, file)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read referenced file %s: %w", file, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse referenced file %s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("referenced file %s is empty", file)
+	}
+	b.docs[file] = doc.Content[0]
+	return doc.Content[0], nil
+}
+
+// componentLocation picks the component section and name for a referenced document. Pointers into
+// another document's components keep their section and name, whole file refs are named after the
+// file and stored in the section of the directory they live in (schemas by default).
+func componentLocation(file, pointer string) (string, string) {
+	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	if len(parts) == 3 && parts[0] == "components" && componentSections[parts[1]] {
+		return parts[1], unescapePointer(parts[2])
+	}
+	if len(parts) == 2 && (parts[0] == "definitions" || parts[0] == "parameters" || parts[0] == "responses") {
+		section := parts[0]
+		if section == "definitions" {
+			section = "schemas"
+		}
+		return section, unescapePointer(parts[1])
+	}
+
+	section := "schemas"
+	if dir := path.Base(path.Dir(file)); componentSections[dir] {
+		section = dir
+	}
+	name := strings.TrimSuffix(path.Base(file), path.Ext(file))
+	if pointer != "" {
+		name = name + "_" + unescapePointer(parts[len(parts)-1])
+	}
+	return section, name
+}
+
+func (b *bundler) uniqueName(section, name string) string {
+	candidate := name
+	for i := 2; b.names[section+"/"+candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+	b.names[section+"/"+candidate] = true
+	return candidate
+}
+
+func (b *bundler) localRef(section, name string) string {
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+	if b.swagger {
+		return "#/" + swaggerSection(section) + "/" + name
+	}
+	return "#/components/" + section + "/" + name
+}
+
+// collectNames records the components already defined in the root document
+func (b *bundler) collectNames() {
+	for section := range componentSections {
+		m := b.sectionNode(section, false)
+		if m == nil {
+			continue
+		}
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			b.names[section+"/"+m.Content[i].Value] = true
+		}
+	}
+}
+
+func (b *bundler) addComponent(section, name string, component *yaml.Node) {
+	m := b.sectionNode(section, true)
+	m.Content = append(m.Content, scalarNode(name), component)
+}
+
+// sectionNode returns the mapping holding the components of a section, optionally creating it
+func (b *bundler) sectionNode(section string, create bool) *yaml.Node {
+	parent := b.root
+	key := section
+	if b.swagger {
+		key = swaggerSection(section)
+	} else {
+		parent = mappingValue(b.root, "components", create)
+		if parent == nil {
+			return nil
+		}
+	}
+	return mappingValue(parent, key, create)
+}
+
+func swaggerSection(section string) string {
+	if s, ok := swaggerSections[section]; ok {
+		return s
+	}
+	return "definitions"
+}
+
+func mappingValue(m *yaml.Node, key string, create bool) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	if !create {
+		return nil
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, scalarNode(key), v)
+	return v
+}
+
+func lookupPointer(node *yaml.Node, pointer string) (*yaml.Node, error) {
+	if pointer == "" || pointer == "/" {
+		return node, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = unescapePointer(token)
+		switch node.Kind {
+		case yaml.MappingNode:
+			next := mappingValue(node, token, false)
+			if next == nil {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			node = next
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			node = node.Content[i]
+		case yaml.DocumentNode, yaml.AliasNode, yaml.ScalarNode:
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+	return node, nil
+}
+
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+func refValue(node *yaml.Node) string {
+	v := mappingValue(node, "$ref", false)
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return v.Value
+}
+
+func setRefValue(node *yaml.Node, ref string) {
+	mappingValue(node, "$ref", false).Value = ref
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func deepCopy(node *yaml.Node) *yaml.Node {
+	c := *node
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return deepCopy(node.Alias)
+	}
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = deepCopy(child)
+	}
+	return &c
+}
+
+func isJSON(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// writeJSON writes a YAML node tree as indented JSON, keeping the key order of the document
+func writeJSON(buf *bytes.Buffer, node *yaml.Node, indent string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			writeJSON(buf, node.Content[0], indent)
+		}
+	case yaml.AliasNode:
+		writeJSON(buf, node.Alias, indent)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			buf.WriteString(indent + "  ")
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteString(": ")
+			writeJSON(buf, node.Content[i+1], indent+"  ")
+			if i+2 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[\n")
+		for i, item := range node.Content {
+			buf.WriteString(indent + "  ")
+			writeJSON(buf, item, indent+"  ")
+			if i+1 < len(node.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!null":
+			buf.WriteString("null")
+		default:
+			value, _ := json.Marshal(node.Value)
+			buf.Write(value)
+		}
+	}
+}
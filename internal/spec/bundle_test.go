@@ -0,0 +1,130 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		spec    File
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "no external refs",
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\npaths: {}\ncomponents:\n  schemas:\n    Flight:\n      type: object\n")},
+			want: map[string]interface{}{
+				"openapi":    "3.0.3",
+				"paths":      map[string]interface{}{},
+				"components": map[string]interface{}{"schemas": map[string]interface{}{"Flight": map[string]interface{}{"type": "object"}}},
+			},
+		},
+		{
+			name: "file and pointer refs are moved into components",
+			files: map[string]string{
+				"api/schemas/Flight.yaml": "type: object\nproperties:\n  route:\n    $ref: '../common.yaml#/components/schemas/Route'\n",
+				"api/common.yaml":         "components:\n  schemas:\n    Route:\n      type: string\n",
+			},
+			spec: File{Path: "api/openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\npaths:\n  /flights:\n    get:\n      responses:\n        '200':\n          content:\n            application/json:\n              schema:\n                $ref: 'schemas/Flight.yaml'\n")},
+			want: map[string]interface{}{
+				"openapi": "3.0.3",
+				"paths": map[string]interface{}{"/flights": map[string]interface{}{"get": map[string]interface{}{"responses": map[string]interface{}{"200": map[string]interface{}{
+					"content": map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Flight"}}},
+				}}}}},
+				"components": map[string]interface{}{"schemas": map[string]interface{}{
+					"Route": map[string]interface{}{"type": "string"},
+					"Flight": map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+						"route": map[string]interface{}{"$ref": "#/components/schemas/Route"},
+					}},
+				}},
+			},
+		},
+		{
+			name: "path items are inlined and name collisions renamed",
+			files: map[string]string{
+				"paths/flights.yaml":    "get:\n  responses:\n    '200':\n      $ref: '../responses/Flight.yaml'\n",
+				"responses/Flight.yaml": "description: a flight\n",
+			},
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\npaths:\n  /flights:\n    $ref: 'paths/flights.yaml'\ncomponents:\n  responses:\n    Flight:\n      description: existing\n")},
+			want: map[string]interface{}{
+				"openapi": "3.0.3",
+				"paths": map[string]interface{}{"/flights": map[string]interface{}{"get": map[string]interface{}{"responses": map[string]interface{}{
+					"200": map[string]interface{}{"$ref": "#/components/responses/Flight_2"},
+				}}}},
+				"components": map[string]interface{}{"responses": map[string]interface{}{
+					"Flight":   map[string]interface{}{"description": "existing"},
+					"Flight_2": map[string]interface{}{"description": "a flight"},
+				}},
+			},
+		},
+		{
+			name: "swagger 2 refs are moved into definitions",
+			files: map[string]string{
+				"Flight.json": `{"type": "object"}`,
+			},
+			spec: File{Path: "swagger.json", Type: OAS2, Content: []byte(
+				`{"swagger": "2.0", "paths": {"/flights": {"get": {"responses": {"200": {"description": "ok", "schema": {"$ref": "Flight.json"}}}}}}}`)},
+			want: map[string]interface{}{
+				"swagger": "2.0",
+				"paths": map[string]interface{}{"/flights": map[string]interface{}{"get": map[string]interface{}{"responses": map[string]interface{}{
+					"200": map[string]interface{}{"description": "ok", "schema": map[string]interface{}{"$ref": "#/definitions/Flight"}},
+				}}}},
+				"definitions": map[string]interface{}{"Flight": map[string]interface{}{"type": "object"}},
+			},
+		},
+		{
+			name: "refs outside of the repository are rejected",
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\ncomponents:\n  schemas:\n    Flight:\n      $ref: '../../etc/passwd'\n")},
+			wantErr: true,
+		},
+		{
+			name: "missing pointer",
+			files: map[string]string{
+				"common.yaml": "components: {}\n",
+			},
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\ncomponents:\n  schemas:\n    Flight:\n      $ref: 'common.yaml#/components/schemas/Flight'\n")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			got, err := Bundle(dir, tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, isJSON(tt.spec.Content), isJSON(got.Content))
+
+			var doc map[string]interface{}
+			require.NoError(t, yaml.Unmarshal(got.Content, &doc))
+			assert.Equal(t, tt.want, doc)
+		})
+	}
+}
@@ -0,0 +1,496 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change is a difference between two revisions of an OpenAPI document
+type Change struct {
+	// Location is the operation or element changed, e.g. `GET /flights` or `GET /flights 200 response`
+	Location string
+	Message  string
+	// Breaking is set when clients written for the previous revision may fail with the new one
+	Breaking bool
+}
+
+// Changelog lists the changes between two revisions of an OpenAPI document
+type Changelog struct {
+	OldVersion string
+	NewVersion string
+	Changes    []Change
+}
+
+// HasBreaking reports whether any change is breaking
+func (c Changelog) HasBreaking() bool {
+	for _, ch := range c.Changes {
+		if ch.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionBumped reports whether the new version is an appropriate successor of the old one: breaking
+// changes require a new major version (or minor version before 1.0.0), other changes any greater version.
+// Versions which aren't semantic versions only need to differ.
+func (c Changelog) VersionBumped() bool {
+	oldV, okOld := parseSemver(c.OldVersion)
+	newV, okNew := parseSemver(c.NewVersion)
+	if !okOld || !okNew {
+		return len(c.Changes) == 0 || c.OldVersion != c.NewVersion
+	}
+	if !c.HasBreaking() {
+		return len(c.Changes) == 0 || compareSemver(newV, oldV) > 0
+	}
+	if oldV[0] == 0 {
+		return newV[0] > 0 || newV[1] > oldV[1]
+	}
+	return newV[0] > oldV[0]
+}
+
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?(?:[-+].*)?$`)
+
+func parseSemver(v string) ([3]int, bool) {
+	var out [3]int
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return out, false
+	}
+	for i := 0; i < 3; i++ {
+		if m[i+1] != "" {
+			out[i], _ = strconv.Atoi(m[i+1])
+		}
+	}
+	return out, true
+}
+
+func compareSemver(a, b [3]int) int {
+	for i := 0; i < 3; i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return 0
+}
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// maxSchemaDepth bounds the comparison of recursive schemas
+const maxSchemaDepth = 20
+
+// Diff compares two revisions of an OpenAPI 3 or Swagger 2 document. Removed paths and operations,
+// new required parameters and properties, narrowed request enums, removed success responses and
+// removed or retyped response properties are breaking changes.
+func Diff(oldContent, newContent []byte) (Changelog, error) {
+	var oldDoc, newDoc map[string]interface{}
+	if err := yaml.Unmarshal(oldContent, &oldDoc); err != nil {
+		return Changelog{}, fmt.Errorf("failed to parse previous spec: %w", err)
+	}
+	if err := yaml.Unmarshal(newContent, &newDoc); err != nil {
+		return Changelog{}, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
+	d := &differ{old: oldDoc, new: newDoc}
+	c := Changelog{
+		OldVersion: infoVersion(oldContent),
+		NewVersion: infoVersion(newContent),
+	}
+	d.paths()
+	c.Changes = d.changes
+	return c, nil
+}
+
+type differ struct {
+	old, new map[string]interface{}
+	changes  []Change
+}
+
+func (d *differ) add(breaking bool, location, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{Location: location, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+}
+
+var pathParamName = regexp.MustCompile(`\{[^}]*\}`)
+
+// paths matches the paths of both revisions ignoring the names of the path parameters
+func (d *differ) paths() {
+	oldPaths := indexPaths(mapValue(d.old, "paths"))
+	newPaths := indexPaths(mapValue(d.new, "paths"))
+
+	for _, key := range sortedMapKeys(oldPaths) {
+		oldPath := oldPaths[key]
+		newPath, ok := newPaths[key]
+		if !ok {
+			d.add(true, oldPath.name, "path removed")
+			continue
+		}
+		d.operations(oldPath, newPath)
+	}
+	for _, key := range sortedMapKeys(newPaths) {
+		if _, ok := oldPaths[key]; !ok {
+			d.add(false, newPaths[key].name, "path added")
+		}
+	}
+}
+
+type pathItem struct {
+	name string
+	item map[string]interface{}
+}
+
+func indexPaths(paths map[string]interface{}) map[string]pathItem {
+	out := map[string]pathItem{}
+	for name, v := range paths {
+		if item, ok := v.(map[string]interface{}); ok {
+			out[pathParamName.ReplaceAllString(name, "{}")] = pathItem{name: name, item: item}
+		}
+	}
+	return out
+}
+
+func (d *differ) operations(oldPath, newPath pathItem) {
+	for _, method := range httpMethods {
+		location := strings.ToUpper(method) + " " + newPath.name
+		oldOp, hasOld := oldPath.item[method].(map[string]interface{})
+		newOp, hasNew := newPath.item[method].(map[string]interface{})
+		switch {
+		case hasOld && !hasNew:
+			d.add(true, strings.ToUpper(method)+" "+oldPath.name, "operation removed")
+		case !hasOld && hasNew:
+			d.add(false, location, "operation added")
+		case hasOld && hasNew:
+			d.parameters(location,
+				d.resolveParameters(d.old, oldPath.item, oldOp),
+				d.resolveParameters(d.new, newPath.item, newOp))
+			d.requestBody(location, d.requestContent(d.old, oldOp), d.requestContent(d.new, newOp))
+			d.responses(location, mapValue(oldOp, "responses"), mapValue(newOp, "responses"))
+			if !truthyValue(oldOp["deprecated"]) && truthyValue(newOp["deprecated"]) {
+				d.add(false, location, "operation deprecated")
+			}
+		}
+	}
+}
+
+// resolveParameters returns the parameters of an operation by location and name, including the
+// parameters of the path item. Swagger 2 body parameters are handled as request bodies.
+func (d *differ) resolveParameters(doc, pathItem, op map[string]interface{}) map[string]map[string]interface{} {
+	out := map[string]map[string]interface{}{}
+	for _, source := range []map[string]interface{}{pathItem, op} {
+		list, _ := source["parameters"].([]interface{})
+		for _, p := range list {
+			param, ok := resolve(doc, p).(map[string]interface{})
+			if !ok || stringField(param, "in") == "body" {
+				continue
+			}
+			out[stringField(param, "in")+" "+stringField(param, "name")] = param
+		}
+	}
+	return out
+}
+
+func (d *differ) parameters(location string, oldParams, newParams map[string]map[string]interface{}) {
+	for _, key := range sortedMapKeys(oldParams) {
+		oldParam := oldParams[key]
+		newParam, ok := newParams[key]
+		if !ok {
+			d.add(false, location, "%s parameter %s removed", stringField(oldParam, "in"), stringField(oldParam, "name"))
+			continue
+		}
+		if !truthyValue(oldParam["required"]) && truthyValue(newParam["required"]) {
+			d.add(true, location, "%s parameter %s became required", stringField(newParam, "in"), stringField(newParam, "name"))
+		}
+		label := fmt.Sprintf("%s parameter %s", stringField(newParam, "in"), stringField(newParam, "name"))
+		d.schema(location, label, parameterSchema(d.old, oldParam), parameterSchema(d.new, newParam), true, 0)
+	}
+	for _, key := range sortedMapKeys(newParams) {
+		if _, ok := oldParams[key]; ok {
+			continue
+		}
+		newParam := newParams[key]
+		required := truthyValue(newParam["required"])
+		kind := "optional"
+		if required {
+			kind = "required"
+		}
+		d.add(required, location, "new %s %s parameter %s", kind, stringField(newParam, "in"), stringField(newParam, "name"))
+	}
+}
+
+// parameterSchema returns the schema of an OpenAPI 3 parameter, or the parameter itself in Swagger 2
+// where the type and enum are set on the parameter
+func parameterSchema(doc, param map[string]interface{}) interface{} {
+	if s, ok := param["schema"]; ok {
+		return resolve(doc, s)
+	}
+	return param
+}
+
+type body struct {
+	required bool
+	content  map[string]interface{}
+}
+
+// requestContent returns the request body schemas by media type
+func (d *differ) requestContent(doc, op map[string]interface{}) *body {
+	if rb, ok := resolve(doc, op["requestBody"]).(map[string]interface{}); ok {
+		b := &body{required: truthyValue(rb["required"]), content: map[string]interface{}{}}
+		for mediaType, v := range mapValue(rb, "content") {
+			if m, ok := v.(map[string]interface{}); ok {
+				b.content[mediaType] = m["schema"]
+			}
+		}
+		return b
+	}
+	list, _ := op["parameters"].([]interface{})
+	for _, p := range list {
+		param, ok := resolve(doc, p).(map[string]interface{})
+		if ok && stringField(param, "in") == "body" {
+			return &body{required: truthyValue(param["required"]), content: map[string]interface{}{"*": param["schema"]}}
+		}
+	}
+	return nil
+}
+
+func (d *differ) requestBody(location string, oldBody, newBody *body) {
+	switch {
+	case oldBody == nil && newBody == nil:
+		return
+	case oldBody == nil:
+		d.add(newBody.required, location, "request body added")
+		return
+	case newBody == nil:
+		d.add(false, location, "request body removed")
+		return
+	}
+	if !oldBody.required && newBody.required {
+		d.add(true, location, "request body became required")
+	}
+	for _, mediaType := range sortedMapKeys(oldBody.content) {
+		newSchema, ok := newBody.content[mediaType]
+		if !ok {
+			d.add(true, location, "request media type %s removed", mediaType)
+			continue
+		}
+		d.schema(location, "request body", resolve(d.old, oldBody.content[mediaType]), resolve(d.new, newSchema), true, 0)
+	}
+}
+
+func (d *differ) responses(location string, oldResponses, newResponses map[string]interface{}) {
+	for _, status := range sortedMapKeys(oldResponses) {
+		oldResp, _ := resolve(d.old, oldResponses[status]).(map[string]interface{})
+		newValue, ok := newResponses[status]
+		if !ok {
+			d.add(strings.HasPrefix(status, "2"), location, "%s response removed", status)
+			continue
+		}
+		newResp, _ := resolve(d.new, newValue).(map[string]interface{})
+		oldContent, newContent := responseContent(oldResp), responseContent(newResp)
+		for _, mediaType := range sortedMapKeys(oldContent) {
+			newSchema, ok := newContent[mediaType]
+			if !ok {
+				d.add(true, location, "%s response media type %s removed", status, mediaType)
+				continue
+			}
+			d.schema(location, status+" response", resolve(d.old, oldContent[mediaType]), resolve(d.new, newSchema), false, 0)
+		}
+	}
+	for _, status := range sortedMapKeys(newResponses) {
+		if _, ok := oldResponses[status]; !ok {
+			d.add(false, location, "%s response added", status)
+		}
+	}
+}
+
+func responseContent(resp map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	if s, ok := resp["schema"]; ok {
+		out["*"] = s
+	}
+	for mediaType, v := range mapValue(resp, "content") {
+		if m, ok := v.(map[string]interface{}); ok {
+			out[mediaType] = m["schema"]
+		}
+	}
+	return out
+}
+
+// schema compares two schemas. Requests break clients when the schema accepts less than before and
+// responses when they return less, or differently typed, data than before.
+func (d *differ) schema(location, label string, oldValue, newValue interface{}, request bool, depth int) {
+	oldSchema, _ := oldValue.(map[string]interface{})
+	newSchema, _ := newValue.(map[string]interface{})
+	if oldSchema == nil || newSchema == nil || depth > maxSchemaDepth {
+		return
+	}
+
+	if oldType, newType := typeOf(oldSchema), typeOf(newSchema); oldType != "" && newType != "" && oldType != newType {
+		d.add(true, location, "%s type changed from %s to %s", label, oldType, newType)
+		return
+	}
+
+	d.enum(location, label, oldSchema, newSchema, request)
+
+	oldProps, newProps := mapValue(oldSchema, "properties"), mapValue(newSchema, "properties")
+	oldRequired, newRequired := stringSet(oldSchema["required"]), stringSet(newSchema["required"])
+	for _, name := range sortedMapKeys(oldProps) {
+		propLabel := label + " property " + name
+		newProp, ok := newProps[name]
+		if !ok {
+			d.add(!request, location, "%s removed", propLabel)
+			continue
+		}
+		switch {
+		case request && !oldRequired[name] && newRequired[name]:
+			d.add(true, location, "%s became required", propLabel)
+		case !request && oldRequired[name] && !newRequired[name]:
+			d.add(true, location, "%s became optional", propLabel)
+		}
+		d.schema(location, propLabel, resolve(d.old, oldProps[name]), resolve(d.new, newProp), request, depth+1)
+	}
+	for _, name := range sortedMapKeys(newProps) {
+		if _, ok := oldProps[name]; ok {
+			continue
+		}
+		if request && newRequired[name] {
+			d.add(true, location, "new required %s property %s", label, name)
+		} else {
+			d.add(false, location, "%s property %s added", label, name)
+		}
+	}
+
+	if _, ok := oldSchema["items"]; ok {
+		d.schema(location, label+" items", resolve(d.old, oldSchema["items"]), resolve(d.new, newSchema["items"]), request, depth+1)
+	}
+}
+
+func (d *differ) enum(location, label string, oldSchema, newSchema map[string]interface{}, request bool) {
+	oldEnum, oldHas := oldSchema["enum"].([]interface{})
+	newEnum, newHas := newSchema["enum"].([]interface{})
+	switch {
+	case !oldHas && !newHas:
+		return
+	case !oldHas:
+		d.add(request, location, "%s restricted to the values %s", label, formatValues(newEnum))
+		return
+	case !newHas:
+		d.add(!request, location, "%s no longer restricted to the values %s", label, formatValues(oldEnum))
+		return
+	}
+	newValues, oldValues := valueSet(newEnum), valueSet(oldEnum)
+	var removed, added []interface{}
+	for _, v := range oldEnum {
+		if !newValues[fmt.Sprint(v)] {
+			removed = append(removed, v)
+		}
+	}
+	for _, v := range newEnum {
+		if !oldValues[fmt.Sprint(v)] {
+			added = append(added, v)
+		}
+	}
+	if len(removed) > 0 {
+		d.add(request, location, "%s enum values removed: %s", label, formatValues(removed))
+	}
+	if len(added) > 0 {
+		d.add(false, location, "%s enum values added: %s", label, formatValues(added))
+	}
+}
+
+// resolve follows local $refs, e.g. #/components/schemas/Flight, the specs being bundled
+func resolve(doc map[string]interface{}, v interface{}) interface{} {
+	for i := 0; i < maxSchemaDepth; i++ {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return v
+		}
+		var target interface{} = doc
+		for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			tm, ok := target.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			target = tm[unescapePointer(token)]
+		}
+		v = target
+	}
+	return v
+}
+
+func typeOf(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		return formatValues(t)
+	}
+	return ""
+}
+
+// infoVersion reads the version as written in the spec, a generic YAML value would turn 1.10 into 1.1
+func infoVersion(content []byte) string {
+	doc, err := Parse(File{Content: content})
+	if err != nil {
+		return ""
+	}
+	return doc.Info.Version
+}
+
+func mapValue(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	if v, ok := m[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func truthyValue(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+func stringSet(v interface{}) map[string]bool {
+	out := map[string]bool{}
+	list, _ := v.([]interface{})
+	for _, item := range list {
+		out[fmt.Sprint(item)] = true
+	}
+	return out
+}
+
+func valueSet(values []interface{}) map[string]bool {
+	out := map[string]bool{}
+	for _, v := range values {
+		out[fmt.Sprint(v)] = true
+	}
+	return out
+}
+
+func formatValues(values []interface{}) string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, fmt.Sprint(v))
+	}
+	return strings.Join(out, ", ")
+}
+
+func sortedMapKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,211 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flightsV1 = `
+openapi: 3.0.3
+info:
+  title: flights
+  version: 1.2.0
+paths:
+  /flights:
+    get:
+      parameters:
+        - name: status
+          in: query
+          schema:
+            type: string
+            enum: [scheduled, delayed, cancelled]
+      responses:
+        "200":
+          description: flights
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Flight"
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Flight"
+      responses:
+        "201":
+          description: created
+  /flights/{id}:
+    get:
+      responses:
+        "200":
+          description: flight
+  /routes:
+    get:
+      responses:
+        "200":
+          description: routes
+components:
+  schemas:
+    Flight:
+      type: object
+      required: [number]
+      properties:
+        number:
+          type: string
+        origin:
+          type: string
+        duration:
+          type: integer
+`
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		newSpec      string
+		want         []Change
+		wantBreaking bool
+	}{
+		{
+			name:    "identical specs",
+			newSpec: flightsV1,
+			want:    nil,
+		},
+		{
+			name: "breaking changes",
+			newSpec: `
+openapi: 3.0.3
+info:
+  title: flights
+  version: 1.3.0
+paths:
+  /flights:
+    get:
+      parameters:
+        - name: status
+          in: query
+          schema:
+            type: string
+            enum: [scheduled, delayed]
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: flights
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Flight"
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/NewFlight"
+      responses:
+        "201":
+          description: created
+  /flights/{flightId}:
+    get:
+      deprecated: true
+      responses:
+        "200":
+          description: flight
+components:
+  schemas:
+    Flight:
+      type: object
+      required: [number]
+      properties:
+        number:
+          type: string
+        duration:
+          type: string
+    NewFlight:
+      type: object
+      required: [number, origin]
+      properties:
+        number:
+          type: string
+        origin:
+          type: string
+        duration:
+          type: integer
+`,
+			want: []Change{
+				{Location: "GET /flights", Message: "query parameter status enum values removed: cancelled", Breaking: true},
+				{Location: "GET /flights", Message: "new required query parameter limit", Breaking: true},
+				{Location: "GET /flights", Message: "200 response items property duration type changed from integer to string", Breaking: true},
+				{Location: "GET /flights", Message: "200 response items property origin removed", Breaking: true},
+				{Location: "POST /flights", Message: "request body property origin became required", Breaking: true},
+				{Location: "GET /flights/{flightId}", Message: "operation deprecated"},
+				{Location: "/routes", Message: "path removed", Breaking: true},
+			},
+			wantBreaking: true,
+		},
+		{
+			name: "additions",
+			newSpec: flightsV1 + `
+        gate:
+          type: string
+`,
+			want: []Change{
+				{Location: "GET /flights", Message: "200 response items property gate added"},
+				{Location: "POST /flights", Message: "request body property gate added"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Diff([]byte(flightsV1), []byte(tt.newSpec))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Changes)
+			assert.Equal(t, tt.wantBreaking, c.HasBreaking())
+		})
+	}
+}
+
+func TestDiffNumericVersions(t *testing.T) {
+	log, err := Diff(
+		[]byte("openapi: 3.0.3\ninfo:\n  title: flights\n  version: 1.9\npaths: {}\n"),
+		[]byte("openapi: 3.0.3\ninfo:\n  title: flights\n  version: 1.10\npaths:\n  /flights:\n    get: {}\n"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "1.9", log.OldVersion)
+	assert.Equal(t, "1.10", log.NewVersion)
+	assert.True(t, log.VersionBumped())
+}
+
+func TestVersionBumped(t *testing.T) {
+	breaking := []Change{{Breaking: true}}
+	compatible := []Change{{}}
+	tests := []struct {
+		name     string
+		log      Changelog
+		expected bool
+	}{
+		{"no changes", Changelog{OldVersion: "1.0.0", NewVersion: "1.0.0"}, true},
+		{"compatible changes need a new version", Changelog{OldVersion: "1.0.0", NewVersion: "1.0.0", Changes: compatible}, false},
+		{"compatible changes with a patch version", Changelog{OldVersion: "1.0.0", NewVersion: "1.0.1", Changes: compatible}, true},
+		{"breaking changes with a minor version", Changelog{OldVersion: "1.2.0", NewVersion: "1.3.0", Changes: breaking}, false},
+		{"breaking changes with a major version", Changelog{OldVersion: "1.2.0", NewVersion: "v2.0.0", Changes: breaking}, true},
+		{"breaking changes before 1.0.0", Changelog{OldVersion: "0.3.1", NewVersion: "0.4.0", Changes: breaking}, true},
+		{"versions which aren't semantic", Changelog{OldVersion: "2024-01", NewVersion: "2024-02", Changes: breaking}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.log.VersionBumped())
+		})
+	}
+}
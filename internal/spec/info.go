@@ -0,0 +1,156 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the part of an OpenAPI, Swagger or AsyncAPI document which describes the API itself
+type Document struct {
+	OpenAPI  string `yaml:"openapi"`
+	Swagger  string `yaml:"swagger"`
+	AsyncAPI string `yaml:"asyncapi"`
+	Info     *Info  `yaml:"info"`
+	// Extensions are the scalar `x-` properties at the root of the document, without the prefix
+	Extensions map[string]string `yaml:"-"`
+}
+
+// Info is the info object of a spec. Scalar values are decoded as written, so a numeric
+// version like 1.10 is kept as "1.10".
+type Info struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Version     string `yaml:"version"`
+	// Extensions are the scalar `x-` properties of the info object, without the prefix
+	Extensions map[string]string `yaml:"-"`
+}
+
+type (
+	documentAlias Document
+	infoAlias     Info
+)
+
+func (d *Document) UnmarshalYAML(node *yaml.Node) error {
+	var a documentAlias
+	if err := node.Decode(&a); err != nil {
+		return err
+	}
+	a.Extensions = extensions(node)
+	*d = Document(a)
+	return nil
+}
+
+func (i *Info) UnmarshalYAML(node *yaml.Node) error {
+	var a infoAlias
+	if err := node.Decode(&a); err != nil {
+		return err
+	}
+	a.Extensions = extensions(node)
+	*i = Info(a)
+	return nil
+}
+
+// extensions collects the scalar `x-` properties of a mapping node
+func extensions(node *yaml.Node) map[string]string {
+	ext := map[string]string{}
+	if node.Kind != yaml.MappingNode {
+		return ext
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !strings.HasPrefix(key.Value, "x-") || value.Kind != yaml.ScalarNode {
+			continue
+		}
+		ext[strings.TrimPrefix(key.Value, "x-")] = value.Value
+	}
+	return ext
+}
+
+// Parse reads the document metadata of an OpenAPI, Swagger or AsyncAPI spec in JSON or YAML format
+// and validates the fields needed to register it as an API
+func Parse(f File) (Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(f.Content, &doc); err != nil {
+		return Document{}, fmt.Errorf("spec %s is not a valid %s document: %w", f.Path, f.Type, err)
+	}
+	if doc.Info == nil {
+		return Document{}, fmt.Errorf("spec %s has no info section", f.Path)
+	}
+	if strings.TrimSpace(doc.Info.Version) == "" {
+		return Document{}, fmt.Errorf("spec %s has no info.version", f.Path)
+	}
+	return doc, nil
+}
+
+// Describe returns the metadata of the spec describing a service: the first OpenAPI spec, or the
+// first AsyncAPI spec when there is none. The document is empty if no spec carries an info section.
+func Describe(files []File) (Document, error) {
+	describing, found := Primary(files)
+	if !found {
+		for _, f := range files {
+			if f.Type == AsyncAPI {
+				describing, found = f, true
+				break
+			}
+		}
+	}
+	if !found {
+		return Document{}, nil
+	}
+	return Parse(describing)
+}
+
+var (
+	invalidLabelChars      = regexp.MustCompile(`[^a-z0-9._-]+`)
+	invalidLabelValueChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// reservedLabelPrefixes can't be used for user defined labels in Konnect
+var reservedLabelPrefixes = []string{"kong", "konnect", "mesh", "kic", "insomnia"}
+
+const maxLabelLength = 63
+
+// LabelValue converts a value, e.g. a spec title, to a Konnect label value: runs of unsupported characters
+// are replaced by a dash, the value starts and ends with a letter or a digit and is truncated to 63
+// characters. It returns an empty string when nothing of the value is left.
+func LabelValue(v string) string {
+	v = strings.Trim(invalidLabelValueChars.ReplaceAllString(v, "-"), "-_.")
+	if len(v) > maxLabelLength {
+		v = strings.TrimRight(v[:maxLabelLength], "-_.")
+	}
+	return v
+}
+
+// Labels converts the document and info `x-` extensions to labels, info values taking precedence.
+// Keys and values are normalized to the label format, extensions which can't be represented as a label
+// are skipped.
+func (d Document) Labels() map[string]string {
+	labels := map[string]string{}
+	add := func(ext map[string]string) {
+		for k, v := range ext {
+			key := strings.Trim(invalidLabelChars.ReplaceAllString(strings.ToLower(k), "-"), "-_.")
+			v = LabelValue(v)
+			if key == "" || len(key) > maxLabelLength || v == "" {
+				continue
+			}
+			reserved := false
+			for _, prefix := range reservedLabelPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					reserved = true
+					break
+				}
+			}
+			if !reserved {
+				labels[key] = v
+			}
+		}
+	}
+	add(d.Extensions)
+	if d.Info != nil {
+		add(d.Info.Extensions)
+	}
+	return labels
+}
@@ -0,0 +1,117 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       File
+		want       Info
+		wantLabels map[string]string
+		wantErr    string
+	}{
+		{
+			name: "yaml with numeric version",
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\ninfo:\n  title: Flights\n  description: Flight data\n  version: 1.10\n")},
+			want:       Info{Title: "Flights", Description: "Flight data", Version: "1.10", Extensions: map[string]string{}},
+			wantLabels: map[string]string{},
+		},
+		{
+			name: "json with integer version",
+			spec: File{Path: "swagger.json", Type: OAS2, Content: []byte(
+				`{"swagger": "2.0", "info": {"title": "Flights", "version": 2}}`)},
+			want:       Info{Title: "Flights", Version: "2", Extensions: map[string]string{}},
+			wantLabels: map[string]string{},
+		},
+		{
+			name: "extensions become labels",
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\nx-domain: travel\nx-owner: root\nx-kong-name: reserved\nx-tags:\n  - a\n" +
+					"info:\n  title: Flights\n  version: 1.0.0\n  x-Owner Team: flights team\n  x-owner: info\n")},
+			want: Info{Title: "Flights", Version: "1.0.0", Extensions: map[string]string{
+				"Owner Team": "flights team",
+				"owner":      "info",
+			}},
+			wantLabels: map[string]string{
+				"domain":     "travel",
+				"owner":      "info",
+				"owner-team": "flights-team",
+			},
+		},
+		{
+			name: "label values are sanitized and truncated",
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\ninfo:\n  title: Flights\n  version: 1.0.0\n" +
+					"  x-contact: \"  Flights & Bookings (EMEA)!  \"\n" +
+					"  x-summary: " + strings.Repeat("long ", 20) + "\n" +
+					"  x-empty: \"!!!\"\n")},
+			want: Info{Title: "Flights", Version: "1.0.0", Extensions: map[string]string{
+				"contact": "  Flights & Bookings (EMEA)!  ",
+				"summary": strings.TrimSpace(strings.Repeat("long ", 20)),
+				"empty":   "!!!",
+			}},
+			wantLabels: map[string]string{
+				"contact": "Flights-Bookings-EMEA",
+				"summary": "long-long-long-long-long-long-long-long-long-long-long-long-lon",
+			},
+		},
+		{
+			name:    "missing info",
+			spec:    File{Path: "api/openapi.yaml", Type: OAS3, Content: []byte("openapi: 3.0.3\npaths: {}\n")},
+			wantErr: "spec api/openapi.yaml has no info section",
+		},
+		{
+			name: "missing version",
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\ninfo:\n  title: Flights\n")},
+			wantErr: "spec openapi.yaml has no info.version",
+		},
+		{
+			name: "invalid version",
+			spec: File{Path: "openapi.yaml", Type: OAS3, Content: []byte(
+				"openapi: 3.0.3\ninfo:\n  title: Flights\n  version:\n    major: 1\n")},
+			wantErr: "spec openapi.yaml is not a valid oas3 document",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, *got.Info)
+			assert.Equal(t, tt.wantLabels, got.Labels())
+		})
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "flights", want: "flights"},
+		{value: "Flights API", want: "Flights-API"},
+		{value: "_v1.2_", want: "v1.2"},
+		{value: "Vols / Réservations", want: "Vols-R-servations"},
+		{value: strings.Repeat("a", 62) + ".b", want: strings.Repeat("a", 62)},
+		{value: "***", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := LabelValue(tt.value)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len(got), 63)
+		})
+	}
+}
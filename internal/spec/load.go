@@ -0,0 +1,101 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+)
+
+// Load reads the declared spec files from a checked out service repository and
+// resolves the type of each one
+func Load(repoDir string, specFiles []manifest.SpecFile) ([]File, error) {
+	files := make([]File, 0, len(specFiles))
+	names := map[string]string{}
+
+	for _, sf := range specFiles {
+		fullPath, err := repoPath(repoDir, sf.Path)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec %s: %w", sf.Path, err)
+		}
+
+		var t Type
+		if sf.Type != nil && *sf.Type != "" {
+			t, err = ParseType(*sf.Type)
+		} else {
+			t, err = Detect(sf.Path, content)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		f := File{Path: sf.Path, Type: t, Content: content}
+		if other, ok := names[f.Name()]; ok {
+			return nil, fmt.Errorf("specs %s and %s have the same file name", other, sf.Path)
+		}
+		names[f.Name()] = sf.Path
+		files = append(files, f)
+	}
+
+	// the API catalog holds a single spec per type, a second one would overwrite the first on every apply
+	registered := map[Type]string{}
+	for _, f := range files {
+		if !f.Type.IsRegistered() {
+			continue
+		}
+		if other, ok := registered[f.Type]; ok {
+			return nil, fmt.Errorf("specs %s and %s are both of type %s, a service has a single spec per type",
+				other, f.Path, f.Type)
+		}
+		registered[f.Type] = f.Path
+	}
+
+	// the deck spec is also stored as openapi.yaml, another spec can't take its place
+	deckSpec, _ := DeckSpec(files)
+	for _, f := range files {
+		if f.Name() == DeckFileName && f.Path != deckSpec.Path {
+			return nil, fmt.Errorf("spec %s: the file name %s is reserved for the first OpenAPI 3 spec, "+
+				"which feeds the decK generation", f.Path, DeckFileName)
+		}
+	}
+
+	return files, nil
+}
+
+// Primary returns the first OpenAPI spec in files, which is the input to the decK generation pipeline
+func Primary(files []File) (File, bool) {
+	for _, f := range files {
+		if f.Type.IsOpenAPI() {
+			return f, true
+		}
+	}
+	return File{}, false
+}
+
+// DeckSpec returns the first OpenAPI 3 spec in files, which is stored as openapi.yaml in the platform
+// repository and converted to a decK file. Swagger 2 specs are registered to the catalog only.
+func DeckSpec(files []File) (File, bool) {
+	for _, f := range files {
+		if f.Type == OAS3 {
+			return f, true
+		}
+	}
+	return File{}, false
+}
+
+// repoPath joins a repository relative path to the repository directory,
+// rejecting paths which escape the repository
+func repoPath(repoDir, path string) (string, error) {
+	fullPath := filepath.Join(repoDir, filepath.FromSlash(path))
+	rel, err := filepath.Rel(repoDir, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid spec path %s: outside of the repository", path)
+	}
+	return fullPath, nil
+}
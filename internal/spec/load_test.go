@@ -0,0 +1,89 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kong/konnect-orchestrator/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	const (
+		oas3  = "openapi: 3.0.3\ninfo:\n  title: flights\n  version: 1.0.0\n"
+		oas2  = "swagger: \"2.0\"\ninfo:\n  title: flights\n  version: 1.0.0\n"
+		async = "asyncapi: 2.6.0\ninfo:\n  title: events\n  version: 1.0.0\n"
+	)
+	tests := []struct {
+		name string
+		// files are the spec paths and contents, in declaration order
+		files    [][2]string
+		wantDeck string
+		wantErr  string
+	}{
+		{
+			name:     "deck spec named openapi.yaml",
+			files:    [][2]string{{"openapi.yaml", oas3}, {"events/openapi.json", async}},
+			wantDeck: "openapi.yaml",
+		},
+		{
+			name:     "deck spec after a swagger spec",
+			files:    [][2]string{{"swagger.yaml", oas2}, {"api/flights.yaml", oas3}},
+			wantDeck: "api/flights.yaml",
+		},
+		{
+			name:  "swagger spec only",
+			files: [][2]string{{"swagger.yaml", oas2}},
+		},
+		{
+			name:    "swagger spec named openapi.yaml",
+			files:   [][2]string{{"openapi.yaml", oas2}},
+			wantErr: "spec openapi.yaml: the file name openapi.yaml is reserved",
+		},
+		{
+			name:    "asyncapi spec named openapi.yaml",
+			files:   [][2]string{{"api/flights.yaml", oas3}, {"events/openapi.yaml", async}},
+			wantErr: "spec events/openapi.yaml: the file name openapi.yaml is reserved",
+		},
+		{
+			name:    "two specs of the same type",
+			files:   [][2]string{{"openapi.yaml", oas3}, {"admin.yaml", oas3}},
+			wantErr: "specs openapi.yaml and admin.yaml are both of type oas3",
+		},
+		{
+			name:     "several specs of types which aren't registered",
+			files:    [][2]string{{"openapi.yaml", oas3}, {"a.graphql", "type Query { a: A }"}, {"b.graphql", "type Query { b: B }"}},
+			wantDeck: "openapi.yaml",
+		},
+		{
+			name:    "same file names",
+			files:   [][2]string{{"v1/flights.yaml", oas3}, {"v2/flights.yaml", oas3}},
+			wantErr: "have the same file name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var specFiles []manifest.SpecFile
+			for _, f := range tt.files {
+				full := filepath.Join(dir, filepath.FromSlash(f[0]))
+				require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+				require.NoError(t, os.WriteFile(full, []byte(f[1]), 0o600))
+				specFiles = append(specFiles, manifest.SpecFile{Path: f[0]})
+			}
+
+			files, err := Load(dir, specFiles)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			deckSpec, ok := DeckSpec(files)
+			assert.Equal(t, tt.wantDeck != "", ok)
+			assert.Equal(t, tt.wantDeck, deckSpec.Path)
+		})
+	}
+}
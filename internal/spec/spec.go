@@ -0,0 +1,104 @@
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Type is the kind of API description a spec file contains
+type Type string
+
+const (
+	OAS2     Type = "oas2"
+	OAS3     Type = "oas3"
+	AsyncAPI Type = "asyncapi"
+	GraphQL  Type = "graphql"
+	Proto    Type = "proto"
+)
+
+// DeckFileName is the name of the spec converted to a decK file in a service directory of the platform repository
+const DeckFileName = "openapi.yaml"
+
+// File is a spec file loaded from a service repository
+type File struct {
+	// Path is the location of the file relative to the root of the service repository
+	Path    string
+	Type    Type
+	Content []byte
+}
+
+// Name is the file name the spec is stored under in the platform repository
+func (f File) Name() string {
+	return filepath.Base(f.Path)
+}
+
+// IsOpenAPI reports whether the spec type is an OpenAPI (Swagger 2 or OpenAPI 3) document
+func (t Type) IsOpenAPI() bool {
+	return t == OAS2 || t == OAS3
+}
+
+// IsRegistered reports whether specs of the type are registered to the API catalog, the other
+// types are only stored in the platform repository
+func (t Type) IsRegistered() bool {
+	return t == OAS2 || t == OAS3 || t == AsyncAPI
+}
+
+// ParseType validates a user provided spec type
+func ParseType(s string) (Type, error) {
+	switch t := Type(strings.ToLower(s)); t {
+	case OAS2, OAS3, AsyncAPI, GraphQL, Proto:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unsupported spec type: %s", s)
+	}
+}
+
+var (
+	protoPattern   = regexp.MustCompile(`(?m)^\s*(syntax\s*=\s*"proto[23]"|service\s+\w+\s*\{|package\s+[\w.]+\s*;)`)
+	graphqlPattern = regexp.MustCompile(`(?m)^\s*(schema\s*\{|type\s+(Query|Mutation|Subscription)\b|extend\s+type\s+\w+)`)
+)
+
+// Detect determines the spec type from the file content, using the file extension
+// as a hint for the formats which aren't YAML or JSON documents
+func Detect(path string, content []byte) (Type, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".proto":
+		return Proto, nil
+	case ".graphql", ".graphqls", ".gql":
+		return GraphQL, nil
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(content, &doc); err == nil && doc != nil {
+		if v, ok := doc["openapi"]; ok {
+			if strings.HasPrefix(fmt.Sprint(v), "3") {
+				return OAS3, nil
+			}
+			return "", fmt.Errorf("unsupported OpenAPI version %v in %s", v, path)
+		}
+		if v, ok := doc["swagger"]; ok {
+			if strings.HasPrefix(fmt.Sprint(v), "2") {
+				return OAS2, nil
+			}
+			return "", fmt.Errorf("unsupported Swagger version %v in %s", v, path)
+		}
+		if _, ok := doc["asyncapi"]; ok {
+			return AsyncAPI, nil
+		}
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if protoPattern.Match(trimmed) {
+		return Proto, nil
+	}
+	if graphqlPattern.Match(trimmed) {
+		return GraphQL, nil
+	}
+
+	return "", fmt.Errorf("unable to detect the spec type of %s", path)
+}
@@ -0,0 +1,84 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    Type
+		wantErr bool
+	}{
+		{
+			name:    "openapi 3 yaml",
+			path:    "openapi.yaml",
+			content: "openapi: 3.0.3\ninfo:\n  title: flights\n  version: 1.0.0\n",
+			want:    OAS3,
+		},
+		{
+			name:    "openapi 3 json",
+			path:    "api/openapi.json",
+			content: `{"openapi": "3.1.0", "info": {"title": "flights", "version": "1.0.0"}}`,
+			want:    OAS3,
+		},
+		{
+			name:    "swagger 2",
+			path:    "swagger.json",
+			content: `{"swagger": "2.0", "info": {"title": "flights", "version": "1.0.0"}}`,
+			want:    OAS2,
+		},
+		{
+			name:    "asyncapi",
+			path:    "events.yaml",
+			content: "asyncapi: 2.6.0\ninfo:\n  title: events\n  version: 1.0.0\n",
+			want:    AsyncAPI,
+		},
+		{
+			name:    "graphql by extension",
+			path:    "schema.graphql",
+			content: "type Query { flights: [Flight] }",
+			want:    GraphQL,
+		},
+		{
+			name:    "graphql by content",
+			path:    "schema.txt",
+			content: "schema {\n  query: Query\n}\n\ntype Query {\n  flights: [Flight]\n}\n",
+			want:    GraphQL,
+		},
+		{
+			name:    "proto by content",
+			path:    "flights.txt",
+			content: "syntax = \"proto3\";\n\npackage flights.v1;\n\nservice Flights {\n  rpc List(Req) returns (Res);\n}\n",
+			want:    Proto,
+		},
+		{
+			name:    "unsupported openapi version",
+			path:    "openapi.yaml",
+			content: "openapi: 4.0.0\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown content",
+			path:    "README.md",
+			content: "# Flights\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect(tt.path, []byte(tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}